@@ -17,10 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"errors"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -31,10 +32,34 @@ type RedisSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
+	// Mode is the topology of the instance, sentinel (default) or cluster
+	// +kubebuilder:validation:Enum=sentinel;cluster
+	Mode     RedisMode        `json:"mode,omitempty"`
 	Redis    RedisSettings    `json:"redis,omitempty"`
 	Sentinel SentinelSettings `json:"sentinel,omitempty"`
+	Cluster  ClusterSettings  `json:"cluster,omitempty"`
 	Exporter Exporter         `json:"exporter,omitempty"`
 	Auth     AuthSettings     `json:"auth,omitempty"`
+	// Restore loads a backup into the master when the redis StatefulSets are created
+	Restore RestoreSettings `json:"restore,omitempty"`
+	// TLS serves redis and sentinel over TLS only, including the replication and the cluster bus
+	TLS TLSSettings `json:"tls,omitempty"`
+	// SplitBrain is what the operator does when more than one redis is a master, it is not used in cluster mode
+	SplitBrain SplitBrainSettings `json:"splitBrain,omitempty"`
+}
+
+type RedisMode string
+
+var (
+	SentinelMode RedisMode = "sentinel"
+	ClusterMode  RedisMode = "cluster"
+)
+
+// ClusterSettings defines the shards of a redis cluster, the pods of every
+// shard are created from Spec.Redis
+type ClusterSettings struct {
+	Shards           int32 `json:"shards,omitempty"`
+	ReplicasPerShard int32 `json:"replicasPerShard,omitempty"`
 }
 
 // RedisSettings defines the specification of the redis cluster
@@ -61,6 +86,68 @@ type RedisSettings struct {
 	PriorityClassName      string                        `json:"priorityClassName,omitempty"`
 	EnabledPodAntiAffinity bool                          `json:"enabledPodAntiAffinity,omitempty"`
 	StaticResources        []StaticResource              `json:"staticResources,omitempty"`
+	// ReplicaPriorities set the replica-priority of the redis of some indexes, e.g. 0 for the ones in a remote
+	// zone so that they are never promoted. The other indexes keep the one of CustomConfig, or 50
+	ReplicaPriorities []ReplicaPriority `json:"replicaPriorities,omitempty"`
+	// MaxReplicationLag is the bytes a slave may be behind the offset of its master before it is degraded,
+	// 0 only degrades a slave whose link to the master is down
+	MaxReplicationLag int64 `json:"maxReplicationLag,omitempty"`
+	// ExtraVolumes are added to the redis pods, e.g. a ConfigMap with an ACL file or the binaries of a module,
+	// ExtraVolumeMounts mount them in the redis container
+	ExtraVolumes      []corev1.Volume      `json:"extraVolumes,omitempty"`
+	ExtraVolumeMounts []corev1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+	// PodDisruptionBudget keeps all the redis but one available by default
+	PodDisruptionBudget PodDisruptionBudgetSettings `json:"podDisruptionBudget,omitempty"`
+	// PodTemplate overrides the pod template of the redis StatefulSets
+	PodTemplate PodTemplateSettings `json:"podTemplate,omitempty"`
+}
+
+// PodDisruptionBudgetSettings defines the PodDisruptionBudget of the redis or sentinel pods in sentinel mode
+type PodDisruptionBudgetSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// MinAvailable or MaxUnavailable replaces the default minAvailable, only one of them can be set
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// PodTemplateSettings are merged into the pod template the operator generates, a change rolls the StatefulSets
+type PodTemplateSettings struct {
+	// Labels are added to the pods, the labels the operator sets take precedence
+	Labels map[string]string `json:"labels,omitempty"`
+	// Env is added to the main container and the init containers of the operator, a variable of the same name
+	// replaces the default one, e.g. TZ
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// InitContainerResources replaces the resources of the init container copying the config, 10m/32Mi by default
+	InitContainerResources *corev1.ResourceRequirements `json:"initContainerResources,omitempty"`
+	// Containers are added to the pods as sidecars, InitContainers run after the init containers of the operator
+	Containers     []corev1.Container `json:"containers,omitempty"`
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// ReadinessProbe and LivenessProbe override the timings of the probes of the main container
+	ReadinessProbe *ProbeSettings `json:"readinessProbe,omitempty"`
+	LivenessProbe  *ProbeSettings `json:"livenessProbe,omitempty"`
+	// Lifecycle is the lifecycle of the main container, e.g. a preStop hook
+	Lifecycle                 *corev1.Lifecycle                 `json:"lifecycle,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	ServiceAccountName        string                            `json:"serviceAccountName,omitempty"`
+	RuntimeClassName          *string                           `json:"runtimeClassName,omitempty"`
+}
+
+// ProbeSettings are the timings of a probe, the fields left 0 keep the default
+type ProbeSettings struct {
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	TimeoutSeconds      int32 `json:"timeoutSeconds,omitempty"`
+	PeriodSeconds       int32 `json:"periodSeconds,omitempty"`
+	SuccessThreshold    int32 `json:"successThreshold,omitempty"`
+	FailureThreshold    int32 `json:"failureThreshold,omitempty"`
+}
+
+// ReplicaPriority is the replica-priority of the redis of an index, sentinel and the operator never promote a
+// redis with priority 0 and prefer the lowest priority otherwise
+type ReplicaPriority struct {
+	// +kubebuilder:validation:Minimum=0
+	Index int32 `json:"index"`
+	// +kubebuilder:validation:Minimum=0
+	Priority int32 `json:"priority"`
 }
 
 type StaticResource struct {
@@ -77,6 +164,37 @@ type Exporter struct {
 	Affinity        *corev1.Affinity  `json:"affinity,omitempty"`
 }
 
+// RestoreSettings defines the backup to restore, it is downloaded by an init container of the
+// first redis pod, the other pods replicate from it
+type RestoreSettings struct {
+	From RestoreSource `json:"from,omitempty"`
+	// Image of the init container, it runs wget. Spec.Redis.Image when it is empty
+	Image string `json:"image,omitempty"`
+}
+
+// RestoreSource is a RedisBackup or the url of a rdb file
+type RestoreSource struct {
+	// BackupName is a RedisBackup in the same namespace
+	BackupName string `json:"backupName,omitempty"`
+	// Key is the key of the backup file in the storage of BackupName, the latest successful backup when it is empty
+	Key string `json:"key,omitempty"`
+	// URL is the http(s) url of a rdb file, e.g. a public or presigned S3 url, when BackupName is empty
+	URL string `json:"url,omitempty"`
+}
+
+func (r RestoreSource) IsEmpty() bool {
+	return r.BackupName == "" && r.URL == ""
+}
+
+// TLSSettings defines the certificates mounted in the redis, sentinel and exporter pods, the port of the
+// spec becomes the tls-port and the plain port is disabled. Enabled is immutable
+type TLSSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// SecretName is a kubernetes.io/tls Secret with tls.crt, tls.key and ca.crt, e.g. the Secret of a
+	// cert-manager Certificate. The certificate is used as a client certificate too
+	SecretName string `json:"secretName,omitempty"`
+}
+
 // RedisCommandRename defines the specification of a "rename-command" configuration option
 type RedisCommandRename struct {
 	From string `json:"from,omitempty"`
@@ -114,8 +232,32 @@ type SentinelSettings struct {
 	PriorityClassName      string                        `json:"priorityClassName,omitempty"`
 	EnabledPodAntiAffinity bool                          `json:"enabledPodAntiAffinity,omitempty"`
 	StaticResources        []StaticResource              `json:"staticResources,omitempty"`
+	// PodDisruptionBudget keeps a quorum of the sentinels available by default, when there are more sentinels
+	// than the quorum
+	PodDisruptionBudget PodDisruptionBudgetSettings `json:"podDisruptionBudget,omitempty"`
+	// PodTemplate overrides the pod template of the sentinel StatefulSets
+	PodTemplate PodTemplateSettings `json:"podTemplate,omitempty"`
+}
+
+// SplitBrainSettings defines the resolution of more than one master
+type SplitBrainSettings struct {
+	// Policy is manual (default), the operator only reports the masters, or auto, the operator keeps the
+	// master most of the sentinels monitor, or the one with the largest replication offset, and makes the
+	// other masters its slaves
+	// +kubebuilder:validation:Enum=manual;auto
+	Policy SplitBrainPolicy `json:"policy,omitempty"`
+	// SnapshotBeforeDemote saves the dataset of a demoted master to split-brain-<time>.rdb next to dump.rdb
+	// before the full resync replaces it
+	SnapshotBeforeDemote bool `json:"snapshotBeforeDemote,omitempty"`
 }
 
+type SplitBrainPolicy string
+
+var (
+	SplitBrainManual SplitBrainPolicy = "manual"
+	SplitBrainAuto   SplitBrainPolicy = "auto"
+)
+
 type SentinelService struct {
 	Enabled            bool              `json:"enabled,omitempty"`
 	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
@@ -125,18 +267,46 @@ type SentinelService struct {
 type AuthSettings struct {
 	SecretPath string   `json:"secretPath,omitempty"`
 	Password   Password `json:"password,omitempty"`
+	// Users are the ACL users of redis 6.2+, they are created with ACL SETUSER on every redis pod and
+	// persisted with CONFIG REWRITE. The OperatorACLUser is added for the replication and the sentinels
+	Users []ACLUser `json:"users,omitempty"`
+}
+
+// OperatorACLUser is the ACL user the slaves and the sentinels authenticate with when Spec.Auth.Users is set,
+// its password is the one of Spec.Auth
+const OperatorACLUser = "redis-operator"
+
+// ACLUser is a redis ACL user, it can only access the keys and channels it lists
+type ACLUser struct {
+	Name string `json:"name"`
+	// PasswordSecret is the key of the Secret in the namespace of the Redis with the password of the user
+	PasswordSecret corev1.SecretKeySelector `json:"passwordSecret"`
+	// Commands are the allowed and denied commands and categories, e.g. +@read, -flushall
+	Commands []string `json:"commands,omitempty"`
+	// Keys are the key patterns the user can access, e.g. cache:*
+	Keys []string `json:"keys,omitempty"`
+	// Channels are the Pub/Sub channel patterns the user can access
+	Channels []string `json:"channels,omitempty"`
 }
 
 type Password struct {
 	EncodeType PasswordEncodeType `json:"encodeType,omitempty"`
 	Value      string             `json:"value,omitempty"`
+	// KeySecret is the key of the Secret in the namespace of the Redis with the key material of EncodeType,
+	// the Secret given by the --password-key-secret flag of the operator is used when it is empty
+	KeySecret *corev1.SecretKeySelector `json:"keySecret,omitempty"`
 }
 
 type PasswordEncodeType string
 
 var (
 	BASE64 PasswordEncodeType = "base64"
-	SM4    PasswordEncodeType = "sm4"
+	// SM4 is hex of SM4 ECB, the key is 16 bytes
+	SM4 PasswordEncodeType = "sm4"
+	// AESGCM is base64 of the 12 bytes nonce followed by the AES-GCM ciphertext, the key is 16, 24 or 32 bytes
+	AESGCM PasswordEncodeType = "aes-gcm"
+	// KMS is decrypted by the plugin given by the --kms-plugin-endpoint flag of the operator, the key is the key id
+	KMS PasswordEncodeType = "kms"
 )
 
 func GetDefaultPasswordEncodeType() PasswordEncodeType {
@@ -152,13 +322,144 @@ type RedisStatus struct {
 	Sentinel SentinelState `json:"sentinel,omitempty"`
 	Exporter ExporterState `json:"exporter,omitempty"`
 	State    State         `json:"state,omitempty"`
+	Restore  RestoreState  `json:"restore,omitempty"`
+	// Switchover is the last switchover requested by SwitchoverAnnotation
+	Switchover SwitchoverState `json:"switchover,omitempty"`
+	// TLS records the certificates loaded by the pods
+	TLS TLSState `json:"tls,omitempty"`
+	// VolumeExpansions are the PersistentVolumeClaims being expanded after the storage of the spec was increased
+	VolumeExpansions []VolumeExpansionState `json:"volumeExpansions,omitempty"`
+	// RecreatingStatefulSets are the StatefulSets deleted without their pods to expand their VolumeClaimTemplates,
+	// they are created again from the kept object
+	RecreatingStatefulSets []RecreatingStatefulSet `json:"recreatingStatefulSets,omitempty"`
+	// ObservedGeneration is the metadata.generation of the spec the conditions were observed with
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the latest observations of the reconcile, e.g. Available
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// the types of Status.Conditions
+const (
+	// ConditionAvailable is true when all the pods are ready and the master is elected
+	ConditionAvailable = "Available"
+	// ConditionMasterElected is true when there is exactly one master, one per shard in cluster mode
+	ConditionMasterElected = "MasterElected"
+	// ConditionSentinelsConsistent is true when all the sentinels monitor the master and know the
+	// right number of sentinels and slaves, it is not set in cluster mode
+	ConditionSentinelsConsistent = "SentinelsConsistent"
+	// ConditionConfigApplied is true when Spec.Redis.CustomConfig and Spec.Sentinel.CustomConfig are applied to all the pods
+	ConditionConfigApplied = "ConfigApplied"
+	// ConditionPasswordApplied is true when the password of the spec is applied to all the pods
+	ConditionPasswordApplied = "PasswordApplied"
+	// ConditionReplicasSynced is true when no slave is degraded, see ReplicationState, it is not set in cluster mode
+	ConditionReplicasSynced = "ReplicasSynced"
+	// ConditionDegraded is true when the last reconcile failed or is waiting for a heal to take effect
+	ConditionDegraded = "Degraded"
+)
+
+// RestoreState records the backup restored when the instance was created
+type RestoreState struct {
+	Phase      RestorePhase `json:"phase,omitempty"`
+	BackupName string       `json:"backupName,omitempty"`
+	Key        string       `json:"key,omitempty"`
+	// URL is the url downloaded without its query, which may have a signature
+	URL            string       `json:"url,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+type RestorePhase string
+
+var (
+	// Restoring is set before the StatefulSet of the master is created, until the master has loaded the data
+	Restoring       RestorePhase = "Restoring"
+	RestoreComplete RestorePhase = "Completed"
+)
+
+// SwitchoverAnnotation requests a planned switchover of the master to the redis pod of its value, e.g.
+// redis-redis-sample-1-0. The operator removes it when the switchover is completed or failed
+const SwitchoverAnnotation = "redis.component.zhizuqiu/switchover-to"
+
+// SwitchoverState records a switchover requested by SwitchoverAnnotation
+type SwitchoverState struct {
+	Phase SwitchoverPhase `json:"phase,omitempty"`
+	// Target is the redis pod that becomes the master
+	Target         string       `json:"target,omitempty"`
+	PreviousMaster string       `json:"previousMaster,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+type SwitchoverPhase string
+
+var (
+	// SwitchoverPending waits for the target to catch up with the replication offset of the master
+	SwitchoverPending SwitchoverPhase = "Pending"
+	// SwitchoverFailingOver waits for the sentinels to promote the target and agree on it
+	SwitchoverFailingOver SwitchoverPhase = "FailingOver"
+	SwitchoverCompleted   SwitchoverPhase = "Completed"
+	SwitchoverFailed      SwitchoverPhase = "Failed"
+)
+
+// IsSwitchingOver returns true while a switchover is not completed or failed
+func (s SwitchoverState) IsSwitchingOver() bool {
+	return s.Phase == SwitchoverPending || s.Phase == SwitchoverFailingOver
+}
+
+// TLSState records the md5 of the Secret of Spec.TLS and when it was last rotated
+type TLSState struct {
+	CertMd5 string `json:"certMd5,omitempty"`
+	// RotationTime is set when CertMd5 changes, the redis pods reload the certificates for a while
+	// after it since the kubelet updates the mounted Secret eventually
+	RotationTime *metav1.Time `json:"rotationTime,omitempty"`
+}
+
+// VolumeExpansionState is the progress of the expansion of a PersistentVolumeClaim, it is removed once the
+// capacity reaches the request
+type VolumeExpansionState struct {
+	Name string `json:"name"`
+	// Requested and Capacity are the storage of the spec and of the status of the PersistentVolumeClaim
+	Requested string               `json:"requested,omitempty"`
+	Capacity  string               `json:"capacity,omitempty"`
+	Phase     VolumeExpansionPhase `json:"phase,omitempty"`
+}
+
+type VolumeExpansionPhase string
+
+// RecreatingStatefulSet is a StatefulSet deleted with orphan propagation, StatefulSet is the object it is created
+// again with: its pod template and the expanded VolumeClaimTemplates
+type RecreatingStatefulSet struct {
+	Name string `json:"name"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	StatefulSet runtime.RawExtension `json:"statefulSet"`
+}
+
+var (
+	// VolumeResizing is set while the volume is expanded by the storage provider
+	VolumeResizing VolumeExpansionPhase = "Resizing"
+	// VolumeFileSystemResizePending is set when the volume is expanded and the kubelet has to grow the file
+	// system of the mounted volume
+	VolumeFileSystemResizePending VolumeExpansionPhase = "FileSystemResizePending"
+)
+
 type State struct {
 	Pods    map[string]PodState `json:"pods,omitempty"`
 	Phase   corev1.PodPhase     `json:"phase,omitempty"`
 	Ready   bool                `json:"ready,omitempty"`
 	Cluster bool                `json:"cluster,omitempty"`
+	Shards  []ShardState        `json:"shards,omitempty"`
+}
+
+// ShardState is the observed state of a shard in cluster mode
+type ShardState struct {
+	Name     string   `json:"name,omitempty"`
+	Slots    []string `json:"slots,omitempty"`
+	Master   string   `json:"master,omitempty"`
+	Replicas []string `json:"replicas,omitempty"`
 }
 
 type PodState struct {
@@ -170,11 +471,39 @@ type PodState struct {
 	ContainerPort int32           `json:"containerPort,omitempty"`
 	PodIPs        []corev1.PodIP  `json:"podIPs,omitempty"`
 	StartTime     *metav1.Time    `json:"startTime,omitempty"`
+	// ClusterRole is master or replica in cluster mode
+	ClusterRole string `json:"clusterRole,omitempty"`
+	// Replication is the replication of a redis slave in sentinel mode
+	Replication *ReplicationState `json:"replication,omitempty"`
+}
+
+// ReplicationState is the INFO replication of a slave. The lag and the seconds change on every probe, they are only
+// updated when they move to another power of two, the exact values are published as metrics
+type ReplicationState struct {
+	MasterLinkStatus string `json:"masterLinkStatus,omitempty"`
+	// Lag is the bytes the offset of the slave is behind the one of its master
+	Lag int64 `json:"lag,omitempty"`
+	// LastIOSecondsAgo is the master_last_io_seconds_ago of a slave whose link is up
+	LastIOSecondsAgo int64 `json:"lastIOSecondsAgo,omitempty"`
+	// LinkDownSinceSeconds is the master_link_down_since_seconds of a slave whose link is down
+	LinkDownSinceSeconds int64 `json:"linkDownSinceSeconds,omitempty"`
+	// Degraded is true when the link is down or the lag is more than Spec.Redis.MaxReplicationLag
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 type RedisState struct {
 	RedisCustomConfig RedisConfig   `json:"redisCustomConfig,omitempty"`
 	RedisPassword     RedisPassword `json:"redisPassword,omitempty"`
+	// ReplicaPriorities is the md5 of Spec.Redis.ReplicaPriorities applied to the redis pods
+	ReplicaPriorities RedisConfig `json:"replicaPriorities,omitempty"`
+	// ACLUsers are the ACL users applied to the redis pods
+	ACLUsers []ACLUserState `json:"aclUsers,omitempty"`
+}
+
+// ACLUserState is the md5 of the rules, including the hash of the password, an ACL user was applied with
+type ACLUserState struct {
+	Name string `json:"name"`
+	Md5  string `json:"md5,omitempty"`
 }
 
 type SentinelState struct {
@@ -211,6 +540,7 @@ type ExporterState struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.state.phase",description="Phase of instances in Redis"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Available\")].status",description="Available condition of instances in Redis"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.state.ready",description="Ready status of instances in Redis"
 // +kubebuilder:printcolumn:name="Cluster",type="boolean",JSONPath=".status.state.cluster",description="cluster status of instances in Redis"
 // +kubebuilder:printcolumn:name="Redis_Replicas",type="integer",JSONPath=".spec.redis.replicas",description="Redis Replicas of instances in Redis"
@@ -227,34 +557,12 @@ type Redis struct {
 	Status RedisStatus `json:"status,omitempty"`
 }
 
-func (r *Redis) Check() error {
-	if r.Spec.Redis.HostNetwork {
-		if r.Spec.Redis.Replicas > 0 {
-			if r.Spec.Redis.StaticResources == nil {
-				return errors.New("Spec.Redis.StaticResources==nil")
-			}
-			if len(r.Spec.Redis.StaticResources) < int(r.Spec.Redis.Replicas) {
-				return errors.New("len(Spec.Redis.StaticResources) < Spec.Redis.Replicas")
-			}
-		}
-	}
-	if r.Spec.Sentinel.HostNetwork {
-		if r.Spec.Sentinel.Replicas > 0 {
-			if r.Spec.Sentinel.StaticResources == nil {
-				return errors.New("Spec.Sentinel.StaticResources==nil")
-			}
-			if len(r.Spec.Sentinel.StaticResources) < int(r.Spec.Sentinel.Replicas) {
-				return errors.New("len(Spec.Sentinel.StaticResources) < Spec.Sentinel.Replicas")
-			}
-		}
-	}
-	if !r.Spec.Redis.HostNetwork || !r.Spec.Sentinel.HostNetwork {
-		if r.Spec.Exporter.HostNetwork {
-			return errors.New("(!Spec.Redis.HostNetwork || !Spec.Sentinel.HostNetwork) when Spec.Exporter.HostNetwork=true")
-		}
-	}
-
-	return nil
+func (r *Redis) IsClusterMode() bool {
+	return r.Spec.Mode == ClusterMode
+}
+
+func (r *Redis) IsTLSEnabled() bool {
+	return r.Spec.TLS.Enabled
 }
 
 // +kubebuilder:object:root=true
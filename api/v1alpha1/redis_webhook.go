@@ -16,12 +16,42 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"errors"
+	"fmt"
 	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DefaultRedisImage    = "redis:5.0-alpine"
+	DefaultExporterImage = "docker.io/zhizuqiu/redis-exporter:latest"
+	DefaultRedisPort     = 6379
+	DefaultSentinelPort  = 26379
 )
 
+// redisOwnedConfigs are written to redis.conf by the operator, setting them in Spec.Redis.CustomConfig
+// would break the replication, the password or the TLS
+var redisOwnedConfigs = []string{"port", "replicaof", "slaveof", "requirepass", "masterauth", "masteruser", "dir",
+	"tls-port", "tls-cert-file", "tls-key-file", "tls-ca-cert-file", "tls-replication", "tls-cluster"}
+
+// redisOwnedVolumes are the volumes of the redis pods the operator adds, besides the PersistentVolumeClaims of
+// the storage
+var redisOwnedVolumes = []string{"redis-config", "redis-readiness-config", "redis-data", "redis-log", "redis-tls"}
+
+// redisOwnedContainers and sentinelOwnedContainers are the containers of the pods the operator adds
+var redisOwnedContainers = []string{"redis", "redis-config-copy", "redis-restore"}
+var sentinelOwnedContainers = []string{"sentinel", "sentinel-config-copy"}
+
+// roleLabelKey is the label the operator sets on the redis pods for the master and replicas Services
+const roleLabelKey = "redis.component.zhizuqiu/role"
+
 // log is for logging in this package.
 var redislog = logf.Log.WithName("redis-resource")
 
@@ -31,9 +61,7 @@ func (r *Redis) SetupWebhookWithManager(mgr ctrl.Manager) error {
 		Complete()
 }
 
-// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-
-// +kubebuilder:webhook:path=/mutate-component-zhizuqiu-v1alpha1-redis,mutating=true,failurePolicy=fail,groups=component.zhizuqiu,resources=redis,verbs=create;update,versions=v1alpha1,name=mredis.kb.io
+// +kubebuilder:webhook:path=/mutate-component-zhizuqiu-v1alpha1-redis,mutating=true,failurePolicy=fail,sideEffects=None,groups=component.zhizuqiu,resources=redis,verbs=create;update,versions=v1alpha1,name=mredis.kb.io,admissionReviewVersions={v1,v1beta1}
 
 var _ webhook.Defaulter = &Redis{}
 
@@ -41,11 +69,47 @@ var _ webhook.Defaulter = &Redis{}
 func (r *Redis) Default() {
 	redislog.Info("default", "name", r.Name)
 
-	// TODO(user): fill in your defaulting logic.
+	if r.Spec.Mode == "" {
+		r.Spec.Mode = SentinelMode
+	}
+
+	if r.Spec.Redis.Image == "" {
+		r.Spec.Redis.Image = DefaultRedisImage
+	}
+	if r.Spec.Redis.ImagePullPolicy == "" {
+		r.Spec.Redis.ImagePullPolicy = corev1.PullIfNotPresent
+	}
+	defaultStaticResourcePorts(r.Spec.Redis.StaticResources, DefaultRedisPort)
+
+	if r.Spec.Sentinel.Image == "" {
+		r.Spec.Sentinel.Image = DefaultRedisImage
+	}
+	if r.Spec.Sentinel.ImagePullPolicy == "" {
+		r.Spec.Sentinel.ImagePullPolicy = corev1.PullIfNotPresent
+	}
+	defaultStaticResourcePorts(r.Spec.Sentinel.StaticResources, DefaultSentinelPort)
+
+	if r.Spec.Exporter.Enabled && r.Spec.Exporter.Image == "" {
+		r.Spec.Exporter.Image = DefaultExporterImage
+	}
+	if r.Spec.Exporter.ImagePullPolicy == "" {
+		r.Spec.Exporter.ImagePullPolicy = corev1.PullIfNotPresent
+	}
+
+	if r.Spec.Auth.Password.Value != "" && r.Spec.Auth.Password.EncodeType == "" {
+		r.Spec.Auth.Password.EncodeType = GetDefaultPasswordEncodeType()
+	}
 }
 
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-// +kubebuilder:webhook:verbs=create;update,path=/validate-component-zhizuqiu-v1alpha1-redis,mutating=false,failurePolicy=fail,groups=component.zhizuqiu,resources=redis,versions=v1alpha1,name=vredis.kb.io
+func defaultStaticResourcePorts(staticResources []StaticResource, port int) {
+	for i := range staticResources {
+		if staticResources[i].Port == 0 {
+			staticResources[i].Port = port
+		}
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-component-zhizuqiu-v1alpha1-redis,mutating=false,failurePolicy=fail,sideEffects=None,groups=component.zhizuqiu,resources=redis,versions=v1alpha1,name=vredis.kb.io,admissionReviewVersions={v1,v1beta1}
 
 var _ webhook.Validator = &Redis{}
 
@@ -53,15 +117,65 @@ var _ webhook.Validator = &Redis{}
 func (r *Redis) ValidateCreate() error {
 	redislog.Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil
+	return r.validate(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Redis) ValidateUpdate(old runtime.Object) error {
 	redislog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
+	oldRedis, ok := old.(*Redis)
+	if !ok {
+		return fmt.Errorf("expected a Redis but got a %T", old)
+	}
+	// the finalizer of an instance being deleted is removed by the operator, it must not be blocked by the rules
+	// the instance was created before
+	if r.DeletionTimestamp != nil {
+		return nil
+	}
+	if err := r.validate(oldRedis); err != nil {
+		return err
+	}
+	// the pods and the ConfigMaps of a live instance can not be moved to another network or topology
+	if r.IsClusterMode() != oldRedis.IsClusterMode() {
+		return errors.New("Spec.Mode is immutable")
+	}
+	// the slots are only assigned when the cluster is created, a shard added later would get none
+	if r.IsClusterMode() && r.Spec.Cluster.Shards != oldRedis.Spec.Cluster.Shards {
+		return errors.New("Spec.Cluster.Shards is immutable, resharding is not supported")
+	}
+	if r.Spec.Redis.HostNetwork != oldRedis.Spec.Redis.HostNetwork {
+		return errors.New("Spec.Redis.HostNetwork is immutable")
+	}
+	if r.Spec.Sentinel.HostNetwork != oldRedis.Spec.Sentinel.HostNetwork {
+		return errors.New("Spec.Sentinel.HostNetwork is immutable")
+	}
+	if r.Spec.Exporter.HostNetwork != oldRedis.Spec.Exporter.HostNetwork {
+		return errors.New("Spec.Exporter.HostNetwork is immutable")
+	}
+	// redis.conf is only copied from the ConfigMap when the pod is created, the pods would keep their port
+	if r.IsTLSEnabled() != oldRedis.IsTLSEnabled() {
+		return errors.New("Spec.TLS.Enabled is immutable")
+	}
+	// a PersistentVolumeClaim can be expanded but not shrunk
+	storages := []struct {
+		name     string
+		pvc, old *corev1.PersistentVolumeClaim
+	}{
+		{"Spec.Redis.Storage", r.Spec.Redis.Storage.PersistentVolumeClaim, oldRedis.Spec.Redis.Storage.PersistentVolumeClaim},
+		{"Spec.Redis.StorageLog", r.Spec.Redis.StorageLog.PersistentVolumeClaim, oldRedis.Spec.Redis.StorageLog.PersistentVolumeClaim},
+		{"Spec.Sentinel.Storage", r.Spec.Sentinel.Storage.PersistentVolumeClaim, oldRedis.Spec.Sentinel.Storage.PersistentVolumeClaim},
+		{"Spec.Sentinel.StorageLog", r.Spec.Sentinel.StorageLog.PersistentVolumeClaim, oldRedis.Spec.Sentinel.StorageLog.PersistentVolumeClaim},
+	}
+	for _, storage := range storages {
+		if storage.pvc == nil || storage.old == nil {
+			continue
+		}
+		request, oldRequest := storage.pvc.Spec.Resources.Requests.Storage(), storage.old.Spec.Resources.Requests.Storage()
+		if request.Cmp(*oldRequest) < 0 {
+			return fmt.Errorf("%s can not be decreased from %s to %s", storage.name, oldRequest.String(), request.String())
+		}
+	}
 	return nil
 }
 
@@ -69,6 +183,331 @@ func (r *Redis) ValidateUpdate(old runtime.Object) error {
 func (r *Redis) ValidateDelete() error {
 	redislog.Info("validate delete", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object deletion.
+	return nil
+}
+
+// validate runs Check and the rules which are only enforced on admission, the instances
+// created before them are still reconciled. old is nil on create, on update a rule is only enforced when
+// its fields change, so that the metadata of such an instance, e.g. the finalizer, can still be updated
+func (r *Redis) validate(old *Redis) error {
+	prev := old
+	if prev == nil {
+		prev = &Redis{}
+	}
+	changed := func(value, oldValue interface{}) bool {
+		return old == nil || !reflect.DeepEqual(value, oldValue)
+	}
+
+	if changed(r.Annotations[SwitchoverAnnotation], prev.Annotations[SwitchoverAnnotation]) &&
+		r.IsClusterMode() && r.Annotations[SwitchoverAnnotation] != "" {
+		return fmt.Errorf("annotation %s is not supported in cluster mode", SwitchoverAnnotation)
+	}
+	if !changed(r.Spec, prev.Spec) {
+		return nil
+	}
+	if err := r.Check(); err != nil {
+		return err
+	}
+	if changed(r.Spec.Mode, prev.Spec.Mode) && r.Spec.Mode != "" && r.Spec.Mode != SentinelMode && r.Spec.Mode != ClusterMode {
+		return fmt.Errorf("unknown Spec.Mode %q", r.Spec.Mode)
+	}
+	if changed(r.Spec.Sentinel.Replicas, prev.Spec.Sentinel.Replicas) && !r.IsClusterMode() && r.Spec.Sentinel.Replicas%2 == 0 {
+		return errors.New("Spec.Sentinel.Replicas must be odd, an even number of sentinels does not add to the failure tolerance")
+	}
+	if changed(r.Spec.Redis.CustomConfig, prev.Spec.Redis.CustomConfig) {
+		if err := validateCustomConfig(r.Spec.Redis.CustomConfig); err != nil {
+			return err
+		}
+	}
+	if changed(r.Spec.Redis.ReplicaPriorities, prev.Spec.Redis.ReplicaPriorities) {
+		if err := validateReplicaPriorities(r.Spec.Redis.ReplicaPriorities); err != nil {
+			return err
+		}
+	}
+	if changed(r.Spec.Redis.Storage, prev.Spec.Redis.Storage) || changed(r.Spec.Redis.StorageLog, prev.Spec.Redis.StorageLog) {
+		if err := validateStorages("Spec.Redis", r.Spec.Redis.Storage, r.Spec.Redis.StorageLog); err != nil {
+			return err
+		}
+	}
+	if changed(r.Spec.Sentinel.Storage, prev.Spec.Sentinel.Storage) || changed(r.Spec.Sentinel.StorageLog, prev.Spec.Sentinel.StorageLog) {
+		if err := validateStorages("Spec.Sentinel", r.Spec.Sentinel.Storage, r.Spec.Sentinel.StorageLog); err != nil {
+			return err
+		}
+	}
+	if changed(r.Spec.Redis.ExtraVolumes, prev.Spec.Redis.ExtraVolumes) || changed(r.Spec.Redis.ExtraVolumeMounts, prev.Spec.Redis.ExtraVolumeMounts) ||
+		changed(r.Spec.Redis.Storage, prev.Spec.Redis.Storage) || changed(r.Spec.Redis.StorageLog, prev.Spec.Redis.StorageLog) {
+		if err := validateExtraVolumes(r.Spec.Redis); err != nil {
+			return err
+		}
+	}
+	if changed(r.Spec.Redis.PodTemplate, prev.Spec.Redis.PodTemplate) {
+		if err := validatePodTemplate("Spec.Redis.PodTemplate", r.Spec.Redis.PodTemplate, redisOwnedContainers); err != nil {
+			return err
+		}
+	}
+	if changed(r.Spec.Sentinel.PodTemplate, prev.Spec.Sentinel.PodTemplate) {
+		if err := validatePodTemplate("Spec.Sentinel.PodTemplate", r.Spec.Sentinel.PodTemplate, sentinelOwnedContainers); err != nil {
+			return err
+		}
+	}
+	if pdb := r.Spec.Redis.PodDisruptionBudget; changed(pdb, prev.Spec.Redis.PodDisruptionBudget) && pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+		return errors.New("only one of Spec.Redis.PodDisruptionBudget.MinAvailable and MaxUnavailable can be set")
+	}
+	if pdb := r.Spec.Sentinel.PodDisruptionBudget; changed(pdb, prev.Spec.Sentinel.PodDisruptionBudget) && pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+		return errors.New("only one of Spec.Sentinel.PodDisruptionBudget.MinAvailable and MaxUnavailable can be set")
+	}
+	if changed(r.Spec.SplitBrain.Policy, prev.Spec.SplitBrain.Policy) {
+		switch r.Spec.SplitBrain.Policy {
+		case "", SplitBrainManual, SplitBrainAuto:
+		default:
+			return fmt.Errorf("unknown Spec.SplitBrain.Policy %q", r.Spec.SplitBrain.Policy)
+		}
+	}
+	if changed(r.Spec.Auth.Password.EncodeType, prev.Spec.Auth.Password.EncodeType) {
+		switch r.Spec.Auth.Password.EncodeType {
+		case "", BASE64, SM4, AESGCM, KMS:
+		default:
+			return fmt.Errorf("unknown Spec.Auth.Password.EncodeType %q", r.Spec.Auth.Password.EncodeType)
+		}
+	}
+	imagesChanged := changed(r.Spec.Redis.Image, prev.Spec.Redis.Image) || changed(r.Spec.Sentinel.Image, prev.Spec.Sentinel.Image)
+	if r.IsTLSEnabled() && (imagesChanged || changed(r.Spec.TLS, prev.Spec.TLS)) {
+		if err := requireRedis("Spec.TLS", "Spec.Redis.Image", r.Spec.Redis.Image, 6, 0); err != nil {
+			return err
+		}
+		if !r.IsClusterMode() {
+			if err := requireRedis("Spec.TLS", "Spec.Sentinel.Image", r.Spec.Sentinel.Image, 6, 0); err != nil {
+				return err
+			}
+		}
+	}
+	if !changed(r.Spec.Auth.Users, prev.Spec.Auth.Users) && !imagesChanged {
+		return nil
+	}
+	if len(r.Spec.Auth.Users) > 0 {
+		// the channel rules, &* of the operator user included, are only known from 6.2
+		if err := requireRedis("Spec.Auth.Users", "Spec.Redis.Image", r.Spec.Redis.Image, 6, 2); err != nil {
+			return err
+		}
+	}
+	return validateACLUsers(r.Spec.Auth.Users)
+}
+
+// validateCustomConfig rejects the configs redis.conf of the operator sets
+func validateCustomConfig(customConfig []string) error {
+	for _, config := range customConfig {
+		fields := strings.Fields(config)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, owned := range redisOwnedConfigs {
+			if strings.ToLower(fields[0]) == owned {
+				return fmt.Errorf("Spec.Redis.CustomConfig can not set %s, it is managed by the operator", owned)
+			}
+		}
+	}
+	return nil
+}
+
+// validateReplicaPriorities rejects the negative and the duplicate indexes
+func validateReplicaPriorities(priorities []ReplicaPriority) error {
+	indexes := make(map[int32]bool)
+	for _, priority := range priorities {
+		if priority.Index < 0 || priority.Priority < 0 {
+			return fmt.Errorf("Spec.Redis.ReplicaPriorities index %d: the index and the priority can not be negative", priority.Index)
+		}
+		if indexes[priority.Index] {
+			return fmt.Errorf("duplicate Spec.Redis.ReplicaPriorities index %d", priority.Index)
+		}
+		indexes[priority.Index] = true
+	}
+	return nil
+}
+
+// requireRedis rejects an image whose tag is a redis version before major.minor, which does not know the config or
+// the rules of the feature, e.g. the default redis:5.0-alpine. The tags without a version, e.g. latest, and without a
+// minor version, e.g. 6-alpine, the latest of 6, are accepted
+func requireRedis(feature, path, image string, major, minor int) error {
+	imageMajor, imageMinor, ok := imageVersion(image)
+	if ok && (imageMajor < major || imageMajor == major && imageMinor >= 0 && imageMinor < minor) {
+		return fmt.Errorf("%s requires redis %d.%d or later, %s is %s", feature, major, minor, path, image)
+	}
+	return nil
+}
+
+// imageVersion returns the major and the minor version of the tag of an image, e.g. 5 and 0 for redis:5.0-alpine,
+// the minor is -1 when the tag has none
+func imageVersion(image string) (int, int, bool) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return 0, 0, false
+	}
+	tag := strings.TrimPrefix(image[i+1:], "v")
+	major, tag := leadingNumber(tag)
+	if major < 0 {
+		return 0, 0, false
+	}
+	minor := -1
+	if strings.HasPrefix(tag, ".") {
+		minor, _ = leadingNumber(tag[1:])
+	}
+	return major, minor, true
+}
+
+// leadingNumber returns the number str starts with, -1 when there is none, and the rest of str
+func leadingNumber(str string) (int, string) {
+	end := 0
+	for end < len(str) && str[end] >= '0' && str[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(str[:end])
+	if err != nil {
+		return -1, str
+	}
+	return n, str[end:]
+}
+
+// validateStorages rejects a data and a log PersistentVolumeClaim of the same name, they are two VolumeClaimTemplates
+// of the StatefulSet
+func validateStorages(path string, storage, storageLog RedisStorage) error {
+	if storage.PersistentVolumeClaim == nil || storageLog.PersistentVolumeClaim == nil {
+		return nil
+	}
+	if storage.PersistentVolumeClaim.Name == storageLog.PersistentVolumeClaim.Name {
+		return fmt.Errorf("%s.Storage and %s.StorageLog can not have the same PersistentVolumeClaim name %q", path, path, storage.PersistentVolumeClaim.Name)
+	}
+	return nil
+}
+
+// validateExtraVolumes rejects the volumes of the redis pods the operator adds, and the mounts of unknown volumes
+func validateExtraVolumes(settings RedisSettings) error {
+	names := make(map[string]bool)
+	for _, storage := range []RedisStorage{settings.Storage, settings.StorageLog} {
+		if storage.PersistentVolumeClaim != nil {
+			names[storage.PersistentVolumeClaim.Name] = true
+		}
+	}
+	for _, owned := range redisOwnedVolumes {
+		names[owned] = true
+	}
+	for _, volume := range settings.ExtraVolumes {
+		if names[volume.Name] {
+			return fmt.Errorf("Spec.Redis.ExtraVolumes can not add the volume %s, it is managed by the operator or duplicated", volume.Name)
+		}
+		names[volume.Name] = true
+	}
+	for _, volumeMount := range settings.ExtraVolumeMounts {
+		if !names[volumeMount.Name] {
+			return fmt.Errorf("Spec.Redis.ExtraVolumeMounts %s is not a volume of the redis pods", volumeMount.Name)
+		}
+	}
+	return nil
+}
+
+// validatePodTemplate rejects the role label, and the containers of the same name as the ones the operator adds or
+// as each other
+func validatePodTemplate(path string, settings PodTemplateSettings, owned []string) error {
+	if _, ok := settings.Labels[roleLabelKey]; ok {
+		return fmt.Errorf("%s.Labels can not set %s, it is managed by the operator", path, roleLabelKey)
+	}
+	names := make(map[string]bool)
+	for _, name := range owned {
+		names[name] = true
+	}
+	for _, container := range append(append([]corev1.Container{}, settings.InitContainers...), settings.Containers...) {
+		if names[container.Name] {
+			return fmt.Errorf("%s can not add the container %s, it is managed by the operator or duplicated", path, container.Name)
+		}
+		names[container.Name] = true
+	}
+	return nil
+}
+
+// validateACLUsers rejects the rules ACL SETUSER would fail on, and the users the operator manages
+func validateACLUsers(users []ACLUser) error {
+	names := make(map[string]bool)
+	for _, user := range users {
+		if user.Name == "" || strings.ContainsAny(user.Name, " \t\n") {
+			return fmt.Errorf("invalid Spec.Auth.Users name %q", user.Name)
+		}
+		if user.Name == "default" || user.Name == OperatorACLUser {
+			return fmt.Errorf("Spec.Auth.Users can not set the user %s, it is managed by the operator", user.Name)
+		}
+		if names[user.Name] {
+			return fmt.Errorf("duplicate Spec.Auth.Users name %q", user.Name)
+		}
+		names[user.Name] = true
+		if user.PasswordSecret.Name == "" {
+			return fmt.Errorf("Spec.Auth.Users %s: passwordSecret.name is required", user.Name)
+		}
+		for _, command := range user.Commands {
+			if len(command) < 2 || (command[0] != '+' && command[0] != '-') || strings.ContainsAny(command, " \t\n") {
+				return fmt.Errorf("Spec.Auth.Users %s: command rule %q must start with + or -", user.Name, command)
+			}
+		}
+		for _, pattern := range append(append([]string{}, user.Keys...), user.Channels...) {
+			if pattern == "" || strings.ContainsAny(pattern, " \t\n") {
+				return fmt.Errorf("Spec.Auth.Users %s: invalid key or channel pattern %q", user.Name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Check validates the rules the reconcile depends on, it also runs in Reconcile in case the webhook is disabled
+func (r *Redis) Check() error {
+	if r.IsTLSEnabled() && r.Spec.TLS.SecretName == "" {
+		return errors.New("Spec.TLS.SecretName is required when Spec.TLS.Enabled=true")
+	}
+	if r.IsClusterMode() {
+		if r.Spec.Cluster.Shards < 3 {
+			return errors.New("Spec.Cluster.Shards < 3 when Spec.Mode=cluster")
+		}
+		if r.Spec.Cluster.ReplicasPerShard < 0 {
+			return errors.New("Spec.Cluster.ReplicasPerShard < 0")
+		}
+		if r.Spec.Redis.HostNetwork || len(r.Spec.Redis.StaticResources) > 0 {
+			return errors.New("Spec.Redis.HostNetwork and Spec.Redis.StaticResources are not supported when Spec.Mode=cluster")
+		}
+		if r.Spec.Exporter.Enabled {
+			return errors.New("Spec.Exporter is not supported when Spec.Mode=cluster")
+		}
+		if !r.Spec.Restore.From.IsEmpty() {
+			return errors.New("Spec.Restore is not supported when Spec.Mode=cluster")
+		}
+		return nil
+	}
+	if r.Spec.Redis.HostNetwork {
+		if r.Spec.Redis.Replicas > 0 {
+			if r.Spec.Redis.StaticResources == nil {
+				return errors.New("Spec.Redis.StaticResources==nil")
+			}
+			if len(r.Spec.Redis.StaticResources) < int(r.Spec.Redis.Replicas) {
+				return errors.New("len(Spec.Redis.StaticResources) < Spec.Redis.Replicas")
+			}
+		}
+	}
+	if r.Spec.Sentinel.HostNetwork {
+		if r.Spec.Sentinel.Replicas > 0 {
+			if r.Spec.Sentinel.StaticResources == nil {
+				return errors.New("Spec.Sentinel.StaticResources==nil")
+			}
+			if len(r.Spec.Sentinel.StaticResources) < int(r.Spec.Sentinel.Replicas) {
+				return errors.New("len(Spec.Sentinel.StaticResources) < Spec.Sentinel.Replicas")
+			}
+		}
+	}
+	if r.Spec.Restore.From.BackupName != "" && r.Spec.Restore.From.URL != "" {
+		return errors.New("only one of Spec.Restore.From.BackupName and Spec.Restore.From.URL can be set")
+	}
+	if !r.Spec.Redis.HostNetwork || !r.Spec.Sentinel.HostNetwork {
+		if r.Spec.Exporter.HostNetwork {
+			return errors.New("(!Spec.Redis.HostNetwork || !Spec.Sentinel.HostNetwork) when Spec.Exporter.HostNetwork=true")
+		}
+	}
+
 	return nil
 }
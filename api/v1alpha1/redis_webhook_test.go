@@ -0,0 +1,302 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newWebhookRedis() *Redis {
+	r := &Redis{}
+	r.Name = "redis-sample"
+	r.Spec.Redis.Replicas = 2
+	r.Spec.Sentinel.Replicas = 3
+	return r
+}
+
+func TestRedisDefault(t *testing.T) {
+	r := newWebhookRedis()
+	r.Spec.Redis.HostNetwork = true
+	r.Spec.Redis.StaticResources = []StaticResource{{Host: "node01"}, {Host: "node02", Port: 6389}}
+	r.Spec.Auth.Password.Value = "MTIzNDU2"
+	r.Default()
+
+	if r.Spec.Mode != SentinelMode {
+		t.Errorf("Spec.Mode = %q; expected %q", r.Spec.Mode, SentinelMode)
+	}
+	if r.Spec.Redis.Image != DefaultRedisImage || r.Spec.Sentinel.Image != DefaultRedisImage {
+		t.Errorf("images = %q, %q; expected %q", r.Spec.Redis.Image, r.Spec.Sentinel.Image, DefaultRedisImage)
+	}
+	if r.Spec.Redis.ImagePullPolicy != corev1.PullIfNotPresent {
+		t.Errorf("Spec.Redis.ImagePullPolicy = %q; expected %q", r.Spec.Redis.ImagePullPolicy, corev1.PullIfNotPresent)
+	}
+	if r.Spec.Redis.StaticResources[0].Port != DefaultRedisPort || r.Spec.Redis.StaticResources[1].Port != 6389 {
+		t.Errorf("Spec.Redis.StaticResources = %+v", r.Spec.Redis.StaticResources)
+	}
+	if r.Spec.Exporter.Image != "" {
+		t.Errorf("Spec.Exporter.Image = %q; expected empty when the exporter is disabled", r.Spec.Exporter.Image)
+	}
+	if r.Spec.Auth.Password.EncodeType != BASE64 {
+		t.Errorf("Spec.Auth.Password.EncodeType = %q; expected %q", r.Spec.Auth.Password.EncodeType, BASE64)
+	}
+}
+
+func TestRedisValidateCreate(t *testing.T) {
+	var tests = []struct {
+		name    string
+		change  func(r *Redis)
+		message string
+	}{
+		{"valid", func(r *Redis) {}, ""},
+		{"even sentinels", func(r *Redis) { r.Spec.Sentinel.Replicas = 2 }, "must be odd"},
+		{"no sentinel", func(r *Redis) { r.Spec.Sentinel.Replicas = 0 }, "must be odd"},
+		{"even sentinels in cluster mode", func(r *Redis) {
+			r.Spec.Mode = ClusterMode
+			r.Spec.Cluster.Shards = 3
+			r.Spec.Sentinel.Replicas = 0
+		}, ""},
+		{"custom config", func(r *Redis) { r.Spec.Redis.CustomConfig = []string{"maxmemory 100mb", ""} }, ""},
+		{"owned config", func(r *Redis) { r.Spec.Redis.CustomConfig = []string{"maxmemory 100mb", "REPLICAOF 10.0.0.1 6379"} }, "replicaof"},
+		{"owned config dir", func(r *Redis) { r.Spec.Redis.CustomConfig = []string{"dir /tmp"} }, "dir"},
+		{"encode type", func(r *Redis) { r.Spec.Auth.Password.EncodeType = "md5" }, "EncodeType"},
+		{"check", func(r *Redis) { r.Spec.Redis.HostNetwork = true }, "StaticResources"},
+		{"switchover", func(r *Redis) {
+			r.Annotations = map[string]string{SwitchoverAnnotation: "redis-redis-sample-1-0"}
+		}, ""},
+		{"switchover in cluster mode", func(r *Redis) {
+			r.Spec.Mode = ClusterMode
+			r.Spec.Cluster.Shards = 3
+			r.Annotations = map[string]string{SwitchoverAnnotation: "redis-redis-sample-shard-0-1-0"}
+		}, "cluster mode"},
+		{"acl users", func(r *Redis) { r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app")} }, ""},
+		{"acl users redis 6", func(r *Redis) {
+			r.Spec.Redis.Image = "registry.local:5000/redis:6.2-alpine"
+			r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app")}
+		}, ""},
+		{"acl users redis 5", func(r *Redis) {
+			r.Spec.Redis.Image = DefaultRedisImage
+			r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app")}
+		}, "Spec.Auth.Users requires redis 6.2"},
+		{"acl users redis 6.0", func(r *Redis) {
+			r.Spec.Redis.Image = "redis:6.0.16-alpine"
+			r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app")}
+		}, "Spec.Auth.Users requires redis 6.2"},
+		{"acl users redis 6 without a minor", func(r *Redis) {
+			r.Spec.Redis.Image = "redis:6-alpine"
+			r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app")}
+		}, ""},
+		{"acl users redis 7.0", func(r *Redis) {
+			r.Spec.Redis.Image = "redis:7.0"
+			r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app")}
+		}, ""},
+		{"acl user default", func(r *Redis) { r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("default")} }, "managed by the operator"},
+		{"acl operator user", func(r *Redis) { r.Spec.Auth.Users = []ACLUser{newWebhookACLUser(OperatorACLUser)} }, "managed by the operator"},
+		{"duplicate acl users", func(r *Redis) {
+			r.Spec.Auth.Users = []ACLUser{newWebhookACLUser("app"), newWebhookACLUser("app")}
+		}, "duplicate"},
+		{"acl user without secret", func(r *Redis) {
+			user := newWebhookACLUser("app")
+			user.PasswordSecret.Name = ""
+			r.Spec.Auth.Users = []ACLUser{user}
+		}, "passwordSecret"},
+		{"acl command rule", func(r *Redis) {
+			user := newWebhookACLUser("app")
+			user.Commands = []string{"get"}
+			r.Spec.Auth.Users = []ACLUser{user}
+		}, "must start with + or -"},
+		{"acl key pattern", func(r *Redis) {
+			user := newWebhookACLUser("app")
+			user.Keys = []string{"cache:* ~*"}
+			r.Spec.Auth.Users = []ACLUser{user}
+		}, "pattern"},
+		{"owned config masteruser", func(r *Redis) { r.Spec.Redis.CustomConfig = []string{"masteruser app"} }, "masteruser"},
+		{"tls", func(r *Redis) { r.Spec.TLS = TLSSettings{Enabled: true, SecretName: "redis-tls"} }, ""},
+		{"tls sentinel redis 5", func(r *Redis) {
+			r.Spec.Redis.Image = "redis:7.0"
+			r.Spec.Sentinel.Image = "redis:5.0.14"
+			r.Spec.TLS = TLSSettings{Enabled: true, SecretName: "redis-tls"}
+		}, "Spec.Sentinel.Image"},
+		{"tls without secret", func(r *Redis) { r.Spec.TLS.Enabled = true }, "Spec.TLS.SecretName"},
+		{"owned config tls", func(r *Redis) { r.Spec.Redis.CustomConfig = []string{"tls-replication no"} }, "tls-replication"},
+		{"split brain", func(r *Redis) { r.Spec.SplitBrain.Policy = SplitBrainAuto }, ""},
+		{"split brain policy", func(r *Redis) { r.Spec.SplitBrain.Policy = "vote" }, "Spec.SplitBrain.Policy"},
+		{"replica priorities", func(r *Redis) {
+			r.Spec.Redis.ReplicaPriorities = []ReplicaPriority{{Index: 1, Priority: 0}, {Index: 2, Priority: 10}}
+		}, ""},
+		{"duplicate replica priorities", func(r *Redis) {
+			r.Spec.Redis.ReplicaPriorities = []ReplicaPriority{{Index: 1, Priority: 0}, {Index: 1, Priority: 10}}
+		}, "duplicate"},
+		{"negative replica priority", func(r *Redis) {
+			r.Spec.Redis.ReplicaPriorities = []ReplicaPriority{{Index: 1, Priority: -1}}
+		}, "negative"},
+		{"pod disruption budget", func(r *Redis) {
+			minAvailable := intstr.FromString("50%")
+			r.Spec.Redis.PodDisruptionBudget = PodDisruptionBudgetSettings{Enabled: true, MinAvailable: &minAvailable}
+		}, ""},
+		{"pod disruption budget min and max", func(r *Redis) {
+			minAvailable, maxUnavailable := intstr.FromInt(2), intstr.FromInt(1)
+			r.Spec.Sentinel.PodDisruptionBudget = PodDisruptionBudgetSettings{Enabled: true, MinAvailable: &minAvailable, MaxUnavailable: &maxUnavailable}
+		}, "Spec.Sentinel.PodDisruptionBudget"},
+		{"data and log storage", func(r *Redis) {
+			r.Spec.Redis.Storage.PersistentVolumeClaim = newWebhookPvc("1Gi")
+			r.Spec.Redis.StorageLog.PersistentVolumeClaim = newWebhookPvc("1Gi")
+			r.Spec.Redis.StorageLog.PersistentVolumeClaim.Name = "redis-log"
+		}, ""},
+		{"data and log storage of the same name", func(r *Redis) {
+			r.Spec.Redis.Storage.PersistentVolumeClaim = newWebhookPvc("1Gi")
+			r.Spec.Redis.StorageLog.PersistentVolumeClaim = newWebhookPvc("1Gi")
+		}, "the same PersistentVolumeClaim name"},
+		{"extra volumes", func(r *Redis) {
+			r.Spec.Redis.ExtraVolumes = []corev1.Volume{{Name: "modules"}}
+			r.Spec.Redis.ExtraVolumeMounts = []corev1.VolumeMount{{Name: "modules", MountPath: "/modules"}}
+		}, ""},
+		{"extra volume managed by the operator", func(r *Redis) {
+			r.Spec.Redis.ExtraVolumes = []corev1.Volume{{Name: "redis-config"}}
+		}, "Spec.Redis.ExtraVolumes"},
+		{"extra volume mount of an unknown volume", func(r *Redis) {
+			r.Spec.Redis.ExtraVolumeMounts = []corev1.VolumeMount{{Name: "modules", MountPath: "/modules"}}
+		}, "Spec.Redis.ExtraVolumeMounts"},
+		{"pod template", func(r *Redis) {
+			r.Spec.Redis.PodTemplate = PodTemplateSettings{
+				Labels:     map[string]string{"team": "cache"},
+				Containers: []corev1.Container{{Name: "log-shipper", Image: "fluent-bit"}},
+			}
+		}, ""},
+		{"pod template role label", func(r *Redis) {
+			r.Spec.Redis.PodTemplate.Labels = map[string]string{"redis.component.zhizuqiu/role": "master"}
+		}, "Spec.Redis.PodTemplate.Labels"},
+		{"pod template container managed by the operator", func(r *Redis) {
+			r.Spec.Sentinel.PodTemplate.InitContainers = []corev1.Container{{Name: "sentinel-config-copy", Image: "busybox"}}
+		}, "Spec.Sentinel.PodTemplate"},
+	}
+	for _, tt := range tests {
+		r := newWebhookRedis()
+		tt.change(r)
+		err := r.ValidateCreate()
+		if tt.message == "" && err != nil {
+			t.Errorf("%s: err = %v; expected nil", tt.name, err)
+		}
+		if tt.message != "" && (err == nil || !strings.Contains(err.Error(), tt.message)) {
+			t.Errorf("%s: err = %v; expected %q", tt.name, err, tt.message)
+		}
+	}
+}
+
+func TestRedisValidateUpdate(t *testing.T) {
+	old := newWebhookRedis()
+
+	r := newWebhookRedis()
+	r.Spec.Redis.Replicas = 3
+	r.Spec.Mode = SentinelMode
+	if err := r.ValidateUpdate(old); err != nil {
+		t.Errorf("scale up: err = %v; expected nil", err)
+	}
+
+	r = newWebhookRedis()
+	r.Spec.Redis.HostNetwork = true
+	r.Spec.Redis.StaticResources = []StaticResource{{Host: "node01", Port: 6389}, {Host: "node02", Port: 6389}}
+	if err := r.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "Spec.Redis.HostNetwork is immutable") {
+		t.Errorf("toggle Spec.Redis.HostNetwork: err = %v", err)
+	}
+
+	r = newWebhookRedis()
+	r.Spec.Mode = ClusterMode
+	r.Spec.Cluster.Shards = 3
+	if err := r.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "Spec.Mode is immutable") {
+		t.Errorf("change Spec.Mode: err = %v", err)
+	}
+
+	oldCluster := newWebhookRedis()
+	oldCluster.Spec.Mode = ClusterMode
+	oldCluster.Spec.Cluster.Shards = 3
+	r = oldCluster.DeepCopy()
+	r.Spec.Cluster.ReplicasPerShard = 2
+	if err := r.ValidateUpdate(oldCluster); err != nil {
+		t.Errorf("change Spec.Cluster.ReplicasPerShard: err = %v; expected nil", err)
+	}
+	r.Spec.Cluster.Shards = 4
+	if err := r.ValidateUpdate(oldCluster); err == nil || !strings.Contains(err.Error(), "Spec.Cluster.Shards is immutable") {
+		t.Errorf("change Spec.Cluster.Shards: err = %v", err)
+	}
+
+	r = newWebhookRedis()
+	r.Spec.TLS = TLSSettings{Enabled: true, SecretName: "redis-tls"}
+	if err := r.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "Spec.TLS.Enabled is immutable") {
+		t.Errorf("enable Spec.TLS: err = %v", err)
+	}
+
+	old.Spec.Redis.Storage.PersistentVolumeClaim = newWebhookPvc("1Gi")
+	r = newWebhookRedis()
+	r.Spec.Redis.Storage.PersistentVolumeClaim = newWebhookPvc("2Gi")
+	if err := r.ValidateUpdate(old); err != nil {
+		t.Errorf("expand Spec.Redis.Storage: err = %v; expected nil", err)
+	}
+	r.Spec.Redis.Storage.PersistentVolumeClaim = newWebhookPvc("512Mi")
+	if err := r.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "Spec.Redis.Storage can not be decreased") {
+		t.Errorf("shrink Spec.Redis.Storage: err = %v", err)
+	}
+}
+
+func TestRedisValidateUpdateLegacy(t *testing.T) {
+	// an instance created before the admission rules, with an even number of sentinels and an owned config
+	old := newWebhookRedis()
+	old.Spec.Sentinel.Replicas = 2
+	old.Spec.Redis.CustomConfig = []string{"dir /data"}
+	old.Finalizers = []string{"redis.component.zhizuqiu/finalizer"}
+
+	r := old.DeepCopy()
+	r.Finalizers = nil
+	if err := r.ValidateUpdate(old); err != nil {
+		t.Errorf("remove the finalizer: err = %v; expected nil", err)
+	}
+
+	deleting := old.DeepCopy()
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	r = deleting.DeepCopy()
+	r.Finalizers = nil
+	if err := r.ValidateUpdate(deleting); err != nil {
+		t.Errorf("remove the finalizer of a deleted instance: err = %v; expected nil", err)
+	}
+
+	r = old.DeepCopy()
+	r.Spec.Redis.Replicas = 3
+	if err := r.ValidateUpdate(old); err != nil {
+		t.Errorf("scale up the redis: err = %v; expected nil", err)
+	}
+
+	r = old.DeepCopy()
+	r.Spec.Sentinel.Replicas = 4
+	if err := r.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "must be odd") {
+		t.Errorf("scale up the sentinels: err = %v", err)
+	}
+
+	r = old.DeepCopy()
+	r.Spec.Redis.CustomConfig = append(r.Spec.Redis.CustomConfig, "maxmemory 1gb")
+	if err := r.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "can not set dir") {
+		t.Errorf("change Spec.Redis.CustomConfig: err = %v", err)
+	}
+}
+
+func newWebhookPvc(storage string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Name = "redis-data"
+	pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)}
+	return pvc
+}
+
+func newWebhookACLUser(name string) ACLUser {
+	user := ACLUser{
+		Name:     name,
+		Commands: []string{"+@read", "-keys"},
+		Keys:     []string{"cache:*"},
+		Channels: []string{"notifications:*"},
+	}
+	user.PasswordSecret.Name = "app-password"
+	user.PasswordSecret.Key = "password"
+	return user
+}
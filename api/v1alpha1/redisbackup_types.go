@@ -0,0 +1,156 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedisBackupSpec defines the desired state of RedisBackup
+type RedisBackupSpec struct {
+	// RedisName is the name of the Redis in the same namespace to back up
+	RedisName string `json:"redisName"`
+	// Schedule is a cron expression, the backup runs only once when it is empty
+	Schedule string `json:"schedule,omitempty"`
+	// Suspend stops the following runs, a running backup is not interrupted
+	Suspend bool `json:"suspend,omitempty"`
+	// PodName is the redis pod to back up, a replica is chosen when it is empty
+	PodName string `json:"podName,omitempty"`
+	// AllowMaster allows the backup to run on the master, when no replica is running or PodName is the master
+	AllowMaster bool `json:"allowMaster,omitempty"`
+	// Type is the file to back up, rdb (default) runs BGSAVE and aof runs BGREWRITEAOF
+	// +kubebuilder:validation:Enum=rdb;aof
+	Type      BackupType      `json:"type,omitempty"`
+	Storage   BackupStorage   `json:"storage"`
+	Retention BackupRetention `json:"retention,omitempty"`
+}
+
+type BackupType string
+
+var (
+	RDBBackup BackupType = "rdb"
+	AOFBackup BackupType = "aof"
+)
+
+// BackupStorage is where the backup files are uploaded to
+type BackupStorage struct {
+	S3 S3Storage `json:"s3"`
+}
+
+// S3Storage is an S3 compatible endpoint, the objects are addressed with the path style
+// so that MinIO and the other compatible stores work without DNS for every bucket
+type S3Storage struct {
+	// Endpoint is the url of the store, e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`
+	// SecretName is a secret in the same namespace with the accessKey and secretKey fields
+	SecretName string `json:"secretName"`
+}
+
+// BackupRetention defines how many backup files are kept in the bucket
+type BackupRetention struct {
+	// Keep is the number of the newest backups to keep, 0 keeps all of them
+	Keep int32 `json:"keep,omitempty"`
+}
+
+// RedisBackupStatus defines the observed state of RedisBackup
+type RedisBackupStatus struct {
+	Phase              BackupPhase  `json:"phase,omitempty"`
+	Message            string       `json:"message,omitempty"`
+	LastScheduleTime   *metav1.Time `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// Backups are the latest successful backups, the newest first
+	Backups []BackupRecord `json:"backups,omitempty"`
+}
+
+type BackupPhase string
+
+var (
+	BackupRunning   BackupPhase = "Running"
+	BackupCompleted BackupPhase = "Completed"
+	BackupFailed    BackupPhase = "Failed"
+)
+
+// BackupRecord is a backup file uploaded to the storage
+type BackupRecord struct {
+	Key            string          `json:"key"`
+	Pod            string          `json:"pod,omitempty"`
+	Size           int64           `json:"size,omitempty"`
+	Checksum       string          `json:"checksum,omitempty"`
+	Duration       metav1.Duration `json:"duration,omitempty"`
+	StartTime      *metav1.Time    `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time    `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Redis",type="string",JSONPath=".spec.redisName",description="Redis to back up"
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="Cron schedule of the backup"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Phase of the last backup"
+// +kubebuilder:printcolumn:name="Last_Successful",type="date",JSONPath=".status.lastSuccessfulTime",description="Completion time of the last successful backup"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+
+// RedisBackup is the Schema for the redisbackups API
+type RedisBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedisBackupSpec   `json:"spec,omitempty"`
+	Status RedisBackupStatus `json:"status,omitempty"`
+}
+
+func (b *RedisBackup) GetType() BackupType {
+	if b.Spec.Type == "" {
+		return RDBBackup
+	}
+	return b.Spec.Type
+}
+
+func (b *RedisBackup) Check() error {
+	if b.Spec.RedisName == "" {
+		return errors.New("Spec.RedisName is empty")
+	}
+	if b.GetType() != RDBBackup && b.GetType() != AOFBackup {
+		return errors.New("Spec.Type must be rdb or aof")
+	}
+	if b.Spec.Storage.S3.Endpoint == "" || b.Spec.Storage.S3.Bucket == "" {
+		return errors.New("Spec.Storage.S3.Endpoint and Spec.Storage.S3.Bucket are required")
+	}
+	if b.Spec.Storage.S3.SecretName == "" {
+		return errors.New("Spec.Storage.S3.SecretName is empty")
+	}
+	if b.Spec.Retention.Keep < 0 {
+		return errors.New("Spec.Retention.Keep < 0")
+	}
+	return nil
+}
+
+// +kubebuilder:object:root=true
+
+// RedisBackupList contains a list of RedisBackup
+type RedisBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RedisBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RedisBackup{}, &RedisBackupList{})
+}
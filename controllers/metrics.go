@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/check"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+)
+
+const metricsNamespace = "redis_operator"
+
+// the phases of a reconcile in reconcilePhaseDuration
+const (
+	phaseEnsure       = "ensure"
+	phaseCheckAndHeal = "check_and_heal"
+	phaseCheckCluster = "check_cluster"
+)
+
+// the types of failover in failoversTotal
+const (
+	failoverMakeMaster       = "make_master"
+	failoverOldestAsMaster   = "oldest_as_master"
+	failoverSentinelFailover = "sentinel_failover"
+	failoverSwitchover       = "switchover"
+	failoverSplitBrain       = "split_brain"
+)
+
+// the kinds of rollout in rolloutsTotal
+const (
+	rolloutRedisConfig      = "redis_config"
+	rolloutSentinelConfig   = "sentinel_config"
+	rolloutRedisPassword    = "redis_password"
+	rolloutSentinelPassword = "sentinel_password"
+	rolloutACLUsers         = "acl_users"
+	rolloutTLSCerts         = "tls_certs"
+
+	rolloutSuccess = "success"
+	rolloutFailure = "failure"
+)
+
+var (
+	mastersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "masters",
+		Help:      "Number of masters seen by the last check of the instance.",
+	}, []string{"namespace", "name"})
+
+	failoversTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "failovers_total",
+		Help:      "Number of failovers performed by the operator.",
+	}, []string{"namespace", "name", "type"})
+
+	sentinelResetsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "sentinel_resets_total",
+		Help:      "Number of SENTINEL RESET performed by the operator.",
+	}, []string{"namespace", "name"})
+
+	rolloutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rollouts_total",
+		Help:      "Number of custom config, password, ACL users and TLS certificates rollouts, by kind and result.",
+	}, []string{"namespace", "name", "kind", "result"})
+
+	replicationLagBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "replication_lag_bytes",
+		Help:      "Bytes the offset of a slave is behind the one of its master, seen by the last check of the instance.",
+	}, []string{"namespace", "name", "pod"})
+
+	replicationLastIOSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "replication_last_io_seconds",
+		Help:      "master_last_io_seconds_ago of a slave whose link to the master is up.",
+	}, []string{"namespace", "name", "pod"})
+
+	replicationLinkDownSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "replication_link_down_seconds",
+		Help:      "master_link_down_since_seconds of a slave whose link to the master is down.",
+	}, []string{"namespace", "name", "pod"})
+
+	redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Latency of the commands sent to redis and sentinel, by RedisApi method.",
+		Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method"})
+
+	redisCommandFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "redis_command_failures_total",
+		Help:      "Number of commands sent to redis and sentinel that failed, by RedisApi method.",
+	}, []string{"method"})
+
+	reconcilePhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_phase_duration_seconds",
+		Help:      "Duration of the phases of a Redis reconcile.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"phase"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		mastersGauge,
+		failoversTotal,
+		sentinelResetsTotal,
+		rolloutsTotal,
+		replicationLagBytes,
+		replicationLastIOSeconds,
+		replicationLinkDownSeconds,
+		redisCommandDuration,
+		redisCommandFailuresTotal,
+		reconcilePhaseDuration,
+	)
+}
+
+// ObserveRedisCommand is the redis_client.CommandObserver of the RedisApi
+func ObserveRedisCommand(method string, duration time.Duration, err error) {
+	redisCommandDuration.WithLabelValues(method).Observe(duration.Seconds())
+	if err != nil {
+		redisCommandFailuresTotal.WithLabelValues(method).Inc()
+	}
+}
+
+func observeReconcilePhase(phase string, start time.Time) {
+	reconcilePhaseDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+}
+
+func setMasters(rf *roav1.Redis, nMasters int) {
+	mastersGauge.WithLabelValues(rf.Namespace, rf.Name).Set(float64(nMasters))
+}
+
+func incFailovers(rf *roav1.Redis, failoverType string) {
+	failoversTotal.WithLabelValues(rf.Namespace, rf.Name, failoverType).Inc()
+}
+
+func incSentinelResets(rf *roav1.Redis) {
+	sentinelResetsTotal.WithLabelValues(rf.Namespace, rf.Name).Inc()
+}
+
+func incRollouts(rf *roav1.Redis, kind string, err error) {
+	result := rolloutSuccess
+	if err != nil {
+		result = rolloutFailure
+	}
+	rolloutsTotal.WithLabelValues(rf.Namespace, rf.Name, kind, result).Inc()
+}
+
+// setReplication publishes the counters of the replication of a slave
+func setReplication(rf *roav1.Redis, pod string, replication check.SlaveReplication) {
+	replicationLagBytes.WithLabelValues(rf.Namespace, rf.Name, pod).Set(float64(replication.Lag))
+	replicationLastIOSeconds.WithLabelValues(rf.Namespace, rf.Name, pod).Set(float64(replication.LastIOSecondsAgo))
+	replicationLinkDownSeconds.WithLabelValues(rf.Namespace, rf.Name, pod).Set(float64(replication.LinkDownSinceSeconds))
+}
+
+// deleteReplication removes the series of a pod which is not a slave anymore
+func deleteReplication(rf *roav1.Redis, pod string) {
+	replicationLagBytes.DeleteLabelValues(rf.Namespace, rf.Name, pod)
+	replicationLastIOSeconds.DeleteLabelValues(rf.Namespace, rf.Name, pod)
+	replicationLinkDownSeconds.DeleteLabelValues(rf.Namespace, rf.Name, pod)
+}
+
+// deleteInstanceMetrics removes the series of a deleted instance
+func deleteInstanceMetrics(rf *roav1.Redis) {
+	mastersGauge.DeleteLabelValues(rf.Namespace, rf.Name)
+	for pod := range rf.Status.State.Pods {
+		deleteReplication(rf, pod)
+	}
+	sentinelResetsTotal.DeleteLabelValues(rf.Namespace, rf.Name)
+	for _, failoverType := range []string{failoverMakeMaster, failoverOldestAsMaster, failoverSentinelFailover, failoverSwitchover, failoverSplitBrain} {
+		failoversTotal.DeleteLabelValues(rf.Namespace, rf.Name, failoverType)
+	}
+	for _, kind := range []string{rolloutRedisConfig, rolloutSentinelConfig, rolloutRedisPassword, rolloutSentinelPassword, rolloutACLUsers, rolloutTLSCerts} {
+		for _, result := range []string{rolloutSuccess, rolloutFailure} {
+			rolloutsTotal.DeleteLabelValues(rf.Namespace, rf.Name, kind, result)
+		}
+	}
+}
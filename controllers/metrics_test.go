@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/check"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestObserveRedisCommand(t *testing.T) {
+	ObserveRedisCommand("info", 10*time.Millisecond, nil)
+	ObserveRedisCommand("sentinelReset", 20*time.Millisecond, errors.New("connection refused"))
+
+	if actual := testutil.ToFloat64(redisCommandFailuresTotal.WithLabelValues("sentinelReset")); actual != 1 {
+		t.Fatalf("sentinelReset failures = %v; expected 1", actual)
+	}
+	if actual := testutil.ToFloat64(redisCommandFailuresTotal.WithLabelValues("info")); actual != 0 {
+		t.Fatalf("info failures = %v; expected 0", actual)
+	}
+}
+
+func TestDeleteInstanceMetrics(t *testing.T) {
+	rf := &componentv1.Redis{ObjectMeta: metav1.ObjectMeta{Namespace: "redis-system", Name: "redis-metrics"}}
+
+	setMasters(rf, 1)
+	incFailovers(rf, failoverOldestAsMaster)
+	incSentinelResets(rf)
+	incRollouts(rf, rolloutRedisPassword, nil)
+	incRollouts(rf, rolloutRedisPassword, errors.New("timeout"))
+	rf.Status.State.Pods = map[string]componentv1.PodState{"redis-redis-metrics-1-0": {}}
+	setReplication(rf, "redis-redis-metrics-1-0", check.SlaveReplication{Lag: 2048})
+
+	if actual := testutil.ToFloat64(rolloutsTotal.WithLabelValues(rf.Namespace, rf.Name, rolloutRedisPassword, rolloutFailure)); actual != 1 {
+		t.Fatalf("failed rollouts = %v; expected 1", actual)
+	}
+
+	deleteInstanceMetrics(rf)
+	if actual := testutil.CollectAndCount(failoversTotal); actual != 0 {
+		t.Fatalf("failovers series = %d; expected 0", actual)
+	}
+	if actual := testutil.CollectAndCount(rolloutsTotal); actual != 0 {
+		t.Fatalf("rollouts series = %d; expected 0", actual)
+	}
+	if actual := testutil.CollectAndCount(replicationLagBytes); actual != 0 {
+		t.Fatalf("replication lag series = %d; expected 0", actual)
+	}
+	if actual := testutil.CollectAndCount(mastersGauge); actual != 0 {
+		t.Fatalf("masters series = %d; expected 0", actual)
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"reflect"
+	"strings"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+// --- CheckAndHealACLUsers ---
+// applies Spec.Auth.Users and the OperatorACLUser to the redis pods which miss them or have an older
+// version of them, e.g. a pod restarted without its redis.conf, deletes the users removed from the spec
+// and records the md5 of each user in Status.Redis.ACLUsers
+func (r *RedisReconciler) CheckAndHealACLUsers(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "CheckAndHealACLUsers")
+
+	previousStatus := el.Redis.Status.Redis.ACLUsers
+	if len(el.Redis.Spec.Auth.Users) == 0 && len(previousStatus) == 0 {
+		return el, nil
+	}
+	// the sentinels are promoting the target of the switchover, masteruser is changed afterwards
+	if el.Redis.Status.Switchover.Phase == componentv1.SwitchoverFailingOver {
+		return el, nil
+	}
+
+	users, err := r.getACLUserRules(el)
+	if err != nil {
+		return el, err
+	}
+	currentStatus := make([]componentv1.ACLUserState, 0)
+	changed := make(map[string]bool)
+	for _, user := range users {
+		md5 := util.GetACLUserMd5(user)
+		currentStatus = append(currentStatus, componentv1.ACLUserState{Name: user.Name, Md5: md5})
+		changed[user.Name] = getACLUserMd5(previousStatus, user.Name) != md5
+	}
+	deleted := make([]string, 0)
+	for _, state := range previousStatus {
+		if _, ok := changed[state.Name]; !ok {
+			deleted = append(deleted, state.Name)
+		}
+	}
+
+	redisPods, err := r.getACLRedisPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, redisPod := range redisPods {
+		existing, err := r.RedisHandler.Checker.GetRedisACLUsers(redisPod)
+		if err != nil {
+			return el, err
+		}
+		setUsers := make([]util.ACLUserRules, 0)
+		names := make([]string, 0)
+		for _, user := range users {
+			if changed[user.Name] || !containsString(existing, user.Name) {
+				setUsers = append(setUsers, user)
+				names = append(names, user.Name)
+			}
+		}
+		delUsers := make([]string, 0)
+		for _, user := range deleted {
+			if containsString(existing, user) {
+				delUsers = append(delUsers, user)
+			}
+		}
+		if len(setUsers) == 0 && len(delUsers) == 0 {
+			continue
+		}
+
+		Info(log, "applying the ACL users to "+redisPod.Name, el.Redis)
+		err = r.RedisHandler.Healer.SetRedisACLUsers(redisPod, setUsers, delUsers, el.Redis)
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonACLUsersApplied, "set the ACL users ["+strings.Join(names, " ")+"] and deleted ["+strings.Join(delUsers, " ")+"] on "+podDesc(redisPod.Name, redisPod.Ip), err)
+		if err != nil {
+			incRollouts(el.Redis, rolloutACLUsers, err)
+			return el, err
+		}
+	}
+
+	if reflect.DeepEqual(previousStatus, currentStatus) {
+		Info(log, "ACLUsers Status equal", el.Redis)
+		return el, nil
+	}
+
+	Info(log, "ACLUsers Status not equal", el.Redis)
+	if !el.Redis.IsClusterMode() {
+		sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
+		if err != nil {
+			return el, err
+		}
+		for _, sentinel := range sentinels {
+			if err := r.RedisHandler.Healer.SetSentinelAuthUser(sentinel, el.Redis); err != nil {
+				r.RedisHandler.RecordEvent(el.Redis, EventReasonACLUsersApplied, "set the auth-user on "+podDesc(sentinel.Name, sentinel.Ip), err)
+				incRollouts(el.Redis, rolloutACLUsers, err)
+				return el, err
+			}
+		}
+	}
+	incRollouts(el.Redis, rolloutACLUsers, nil)
+	el.Redis.Status.Redis.ACLUsers = currentStatus
+	return el, nil
+}
+
+// getACLUserRules returns the users of the spec with the OperatorACLUser first, none if the spec has no user
+func (r *RedisReconciler) getACLUserRules(el element.Element) ([]util.ACLUserRules, error) {
+	users := make([]util.ACLUserRules, 0)
+	if len(el.Redis.Spec.Auth.Users) == 0 {
+		return users, nil
+	}
+	password, err := k8s.GetSpecRedisPassword(r.RedisHandler.K8sServices, el.Redis)
+	if err != nil {
+		return nil, err
+	}
+	users = append(users, util.GetOperatorACLUserRules(password))
+	for _, user := range el.Redis.Spec.Auth.Users {
+		userPassword, err := k8s.GetACLUserPassword(r.RedisHandler.K8sServices, el.Redis, user)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, util.GetACLUserRules(user, userPassword))
+	}
+	return users, nil
+}
+
+// getACLRedisPods returns the redis pods with the master first, so that the slaves switching to the
+// OperatorACLUser find it on the master
+func (r *RedisReconciler) getACLRedisPods(el element.Element) ([]redis_client.RedisParam, error) {
+	redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return nil, err
+	}
+	if el.Redis.IsClusterMode() {
+		return redisPods, nil
+	}
+	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
+	if err != nil {
+		return redisPods, nil
+	}
+	ordered := []redis_client.RedisParam{masterPod}
+	for _, redisPod := range redisPods {
+		if redisPod.Name != masterPod.Name {
+			ordered = append(ordered, redisPod)
+		}
+	}
+	return ordered, nil
+}
+
+func getACLUserMd5(states []componentv1.ACLUserState, name string) string {
+	for _, state := range states {
+		if state.Name == name {
+			return state.Md5
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -3,13 +3,19 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/check"
 	"github.com/zhizuqiu/redis-operator/controllers/service/element"
 	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
 	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
 	"github.com/zhizuqiu/redis-operator/controllers/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 )
 
 const (
@@ -20,14 +26,9 @@ const (
 func (r *RedisReconciler) CheckAndHeal(el element.Element) (element.Element, error) {
 	log := r.Log.WithValues("controller", "CheckAndHeal")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
+	if el.Redis.IsClusterMode() {
+		return r.CheckAndHealCluster(el)
 	}
-	el.NeedReLoad = false
 
 	// Number of redis is equal as the set on the RF spec
 	// Number of sentinel is equal as the set on the RF spec
@@ -44,6 +45,16 @@ func (r *RedisReconciler) CheckAndHeal(el element.Element) (element.Element, err
 		return el, err
 	}
 
+	if el.Redis.Status.Restore.Phase == componentv1.Restoring {
+		return r.checkRestore(el)
+	}
+
+	// the sentinels are promoting the target of the switchover, the master may change at any time
+	if el.Redis.Status.Switchover.Phase == componentv1.SwitchoverFailingOver {
+		Info(log, "switchover in progress, skip CheckAndHeal()", el.Redis)
+		return el, nil
+	}
+
 	el, err, needCheckAndHealCustomConfig := r.needCheckAndHealCustomConfig(el)
 	if err != nil {
 		return el, err
@@ -54,6 +65,13 @@ func (r *RedisReconciler) CheckAndHeal(el element.Element) (element.Element, err
 		return el, err
 	}
 
+	if !needCheckAndHealCustomConfig {
+		el.SetCondition(componentv1.ConditionConfigApplied, metav1.ConditionTrue, util.ReasonApplied, "the custom config is applied")
+	}
+	if !needCheckAndHealPassword {
+		el.SetCondition(componentv1.ConditionPasswordApplied, metav1.ConditionTrue, util.ReasonApplied, "the password is applied")
+	}
+
 	if !needCheckAndHealCustomConfig && !needCheckAndHealPassword && el.Redis.Status.State.Cluster {
 		Info(log, "cluster = true, skip CheckAndHeal()", el.Redis)
 		return el, nil
@@ -101,53 +119,102 @@ func (r *RedisReconciler) CheckAndHeal(el element.Element) (element.Element, err
 
 // --- checkState ---
 func (r *RedisReconciler) checkState(el element.Element) (element.Element, error) {
-	// log := r.Log.WithValues("controller", "checkState")
-
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
+	log := r.Log.WithValues("controller", "checkState")
 
 	previousStatus := el.Redis.Status.State
 	currentStatus := *el.Redis.Status.State.DeepCopy()
 
-	podList, err := r.RedisHandler.K8sServices.ListPods(el.Redis.Namespace, util.GetInstanceLabels(el.Redis.Name))
-	if err != nil {
-		return el, err
-	}
+	podList := el.Snapshot.ListPods(util.GetInstanceLabels(el.Redis.Name))
 	currentStatus.Pods = r.RedisHandler.getPodStates(podList)
 	currentStatus.Phase = util.GetGlobalPhase(el.Redis, podList)
 	currentStatus.Ready = util.GetGlobalReady(el.Redis, podList)
 
+	// the replication is only known for the slaves that answer
+	states, err := r.RedisHandler.Checker.GetReplicationStates(el)
+	if err != nil {
+		Info(log, "can not get the replication, skip it: "+err.Error(), el.Redis)
+	} else {
+		degraded := make([]string, 0)
+		for name := range previousStatus.Pods {
+			if _, ok := currentStatus.Pods[name]; !ok {
+				deleteReplication(el.Redis, name)
+			}
+		}
+		for name, podState := range currentStatus.Pods {
+			replication, ok := states[name]
+			if !ok {
+				deleteReplication(el.Redis, name)
+				continue
+			}
+			setReplication(el.Redis, name, replication)
+			state := check.StatusReplicationState(previousStatus.Pods[name].Replication, replication)
+			podState.Replication = &state
+			currentStatus.Pods[name] = podState
+			if state.Degraded {
+				degraded = append(degraded, name)
+			}
+		}
+		if len(degraded) > 0 {
+			sort.Strings(degraded)
+			el.SetCondition(componentv1.ConditionReplicasSynced, metav1.ConditionFalse, util.ReasonReplicasDegraded, "the link to the master is down or the lag is more than spec.redis.maxReplicationLag: "+strings.Join(degraded, ", "))
+		} else {
+			el.SetCondition(componentv1.ConditionReplicasSynced, metav1.ConditionTrue, util.ReasonSynced, "all the slaves are in sync")
+		}
+	}
+
 	if !reflect.DeepEqual(previousStatus, currentStatus) {
 		Info(r.Log, "State Status not equal", el.Redis)
-		if err := r.RedisHandler.Healer.UpdatePodStatus(el.Redis, currentStatus); err != nil {
-			return el, err
-		}
-		el.NeedReLoad = true
+		el.Redis.Status.State = currentStatus
 	} else {
 		Info(r.Log, "State Status equal", el.Redis)
 	}
 	return el, nil
 }
 
+// replicationRequeueAfter polls the Redis while a slave is degraded, a slave catching up does not trigger a
+// reconcile
+func replicationRequeueAfter(rf *componentv1.Redis) time.Duration {
+	for _, podState := range rf.Status.State.Pods {
+		if podState.Replication != nil && podState.Replication.Degraded {
+			return NormalRequeueAfter
+		}
+	}
+	return 0
+}
+
+// volumeExpansionRequeueAfter polls the PersistentVolumeClaims being expanded and the StatefulSets being recreated, they are not watched
+func volumeExpansionRequeueAfter(rf *componentv1.Redis) time.Duration {
+	if len(rf.Status.VolumeExpansions) > 0 || len(rf.Status.RecreatingStatefulSets) > 0 {
+		return NormalRequeueAfter
+	}
+	return 0
+}
+
+// --- checkRestore ---
+// the sentinels are created after the master has loaded the backup, before that they may fail over to an empty slave
+func (r *RedisReconciler) checkRestore(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkRestore")
+
+	if err := r.RedisHandler.Checker.CheckRestoreLoaded(el); err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, err)
+		Info(log, err.Error(), el.Redis)
+		return el, nil
+	}
+
+	currentStatus := *el.Redis.Status.Restore.DeepCopy()
+	currentStatus.Phase = componentv1.RestoreComplete
+	currentStatus.CompletionTime = &metav1.Time{Time: time.Now()}
+	Info(log, "restore completed", el.Redis)
+	el.Redis.Status.Restore = currentStatus
+	// the next reconcile creates the sentinels
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New("restore completed, wait for the sentinels"))
+	return el, nil
+}
+
 // --- checkNumber ---
 func (r *RedisReconciler) checkNumber(el element.Element) error {
 	log := r.Log.WithValues("controller", "checkNumber")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	err = r.RedisHandler.Checker.CheckRedisNumber(el)
 	if err != nil {
@@ -166,30 +233,30 @@ func (r *RedisReconciler) checkNumber(el element.Element) error {
 func (r *RedisReconciler) checkMaster(el element.Element) (element.Element, error) {
 	log := r.Log.WithValues("controller", "checkMaster")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	nMasters, err := r.RedisHandler.Checker.GetNumberMasters(el)
 	if err != nil {
 		return el, err
 	}
 	Info(log, "Master Number:"+strconv.Itoa(nMasters), el.Redis)
+	setMasters(el.Redis, nMasters)
 
 	switch nMasters {
 	case 0:
 		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("Master Number = 0"))
+		el.SetCondition(componentv1.ConditionMasterElected, metav1.ConditionFalse, util.ReasonNoMaster, "no master found")
 		redisePods, err := r.RedisHandler.Checker.GetRedisPods(el)
 		if err != nil {
 			return el, err
 		}
 		if len(redisePods) == 1 {
-			if err = r.RedisHandler.Healer.MakeMaster(redisePods[0], el.Redis); err != nil {
+			err = r.RedisHandler.Healer.MakeMaster(redisePods[0], el.Redis)
+			el.Snapshot.ForgetTopology()
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonMasterElected, "no master found, made the only redis "+podDesc(redisePods[0].Name, redisePods[0].Ip)+" the master", err)
+			if err != nil {
+				return el, err
+			}
+			incFailovers(el.Redis, failoverMakeMaster)
+			if err = r.labelRedisRoles(el, redisePods[0]); err != nil {
 				return el, err
 			}
 			break
@@ -203,40 +270,43 @@ func (r *RedisReconciler) checkMaster(el element.Element) (element.Element, erro
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("time "+util.Floadt64ToString(minTime.Round(time.Second).Seconds())+" more than expected. Not even one master, fixing..."))
 			Info(log, "time "+util.Floadt64ToString(minTime.Round(time.Second).Seconds())+" more than expected. Not even one master, fixing...", el.Redis)
 			// We can consider there's an error
-			if err2 = r.RedisHandler.Healer.SetOldestAsMaster(el.Redis); err2 != nil {
+			newMaster, err2 := r.RedisHandler.Healer.SetOldestAsMaster(el.Redis)
+			el.Snapshot.ForgetTopology()
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonMasterElected, "no master found for "+util.Floadt64ToString(minTime.Round(time.Second).Seconds())+"s, made the redis with the lowest lag "+podDesc(newMaster.Name, newMaster.Ip)+" the master", err2)
+			if err2 != nil {
+				return el, err2
+			}
+			incFailovers(el.Redis, failoverOldestAsMaster)
+			if err2 = r.labelRedisRoles(el, newMaster); err2 != nil {
 				return el, err2
 			}
 		} else {
 			// We'll wait until failover is done
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("No master found, wait until failover"))
 			Info(log, "No master found, wait until failover", el.Redis)
+			r.RedisHandler.RecordWarning(el.Redis, EventReasonNoMaster, "no master found, wait until failover")
 			return el, nil
 		}
 	case 1:
-		break
+		el.SetCondition(componentv1.ConditionMasterElected, metav1.ConditionTrue, util.ReasonMasterElected, "one master found")
 	default:
-		Info(log, "More than one master, fix manually", el.Redis)
-		return el, nil
+		return r.resolveSplitBrain(el, nMasters)
 	}
 	return el, nil
 }
 
 // --- checkAndHeal ---
 func (r *RedisReconciler) checkAndHeal(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
 	if err != nil {
 		return el, err
 	}
 
+	// e.g. the sentinels have failed over the master
+	if err = r.labelRedisRoles(el, masterPod); err != nil {
+		return el, err
+	}
+
 	el, err = r.checkAndHealRedis(el, masterPod)
 	if err != nil {
 		return el, err
@@ -253,18 +323,12 @@ func (r *RedisReconciler) checkAndHeal(el element.Element) (element.Element, err
 func (r *RedisReconciler) checkAndHealRedis(el element.Element, masterPod redis_client.RedisParam) (element.Element, error) {
 	log := r.Log.WithValues("controller", "checkAndHealRedis")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	if err2 := r.RedisHandler.Checker.CheckAllSlavesFromMaster(masterPod, el); err2 != nil {
 		Info(log, "Not all slaves have the same master", el.Redis)
-		if err3 := r.RedisHandler.Healer.SetMasterOnAll(masterPod.Ip, el.Redis); err3 != nil {
+		err3 := r.RedisHandler.Healer.SetMasterOnAll(masterPod.Ip, el.Redis)
+		el.Snapshot.ForgetTopology()
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonSlavesReplicated, "not all slaves replicate the master "+podDesc(masterPod.Name, masterPod.Ip)+", made them slaves of it: "+err2.Error(), err3)
+		if err3 != nil {
 			return el, err3
 		}
 	}
@@ -274,25 +338,20 @@ func (r *RedisReconciler) checkAndHealRedis(el element.Element, masterPod redis_
 func (r *RedisReconciler) checkAndHealSentinels(el element.Element, masterPod redis_client.RedisParam) (element.Element, error) {
 	log := r.Log.WithValues("controller", "checkAndHealSentinels")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
 	if err != nil {
 		return el, err
 	}
 
+	healed := len(el.NeedReCheckError)
 	for _, sip := range sentinels {
-		if err = r.RedisHandler.Checker.CheckSentinelMonitor(sip, masterPod.Ip); err != nil {
+		if err = r.RedisHandler.Checker.CheckSentinelMonitor(el, sip, masterPod.Ip); err != nil {
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("Sentinel is not monitoring the correct master"))
 			Info(log, "Sentinel is not monitoring the correct master", el.Redis)
-			if err = r.RedisHandler.Healer.NewSentinelMonitor(sip, masterPod.Ip, el.Redis); err != nil {
+			err = r.RedisHandler.Healer.NewSentinelMonitor(sip, masterPod.Ip, el.Redis)
+			el.Snapshot.ForgetTopology()
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelMonitored, "sentinel "+podDesc(sip.Name, sip.Ip)+" is not monitoring the master, made it monitor "+podDesc(masterPod.Name, masterPod.Ip), err)
+			if err != nil {
 				return el, err
 			}
 		}
@@ -302,36 +361,41 @@ func (r *RedisReconciler) checkAndHealSentinels(el element.Element, masterPod re
 		if err := r.RedisHandler.Checker.CheckSentinelNumberInMemory(sip, el); err != nil {
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New(sip.Name+": Sentinel has more sentinel in memory than spected"))
 			Error(log, err, sip.Name+": Sentinel has more sentinel in memory than spected", el.Redis)
-			if err = r.RedisHandler.Healer.RestoreSentinel(sip); err != nil {
+			err = r.RedisHandler.Healer.RestoreSentinel(sip)
+			el.Snapshot.ForgetTopology()
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelReset, "sentinel "+podDesc(sip.Name, sip.Ip)+" knows more sentinels than expected, reset it", err)
+			if err != nil {
 				return el, err
 			}
+			incSentinelResets(el.Redis)
 		}
 	}
 	for _, sip := range sentinels {
 		if err := r.RedisHandler.Checker.CheckSentinelSlavesNumberInMemory(sip, el); err != nil {
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New(sip.Name+": Sentinel has more slaves in memory than spected"))
 			Error(log, err, sip.Name+": Sentinel has more slaves in memory than spected", el.Redis)
-			if err = r.RedisHandler.Healer.RestoreSentinel(sip); err != nil {
+			err = r.RedisHandler.Healer.RestoreSentinel(sip)
+			el.Snapshot.ForgetTopology()
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelReset, "sentinel "+podDesc(sip.Name, sip.Ip)+" knows more slaves than expected, reset it", err)
+			if err != nil {
 				return el, err
 			}
+			incSentinelResets(el.Redis)
 		}
 	}
 
+	if len(el.NeedReCheckError) > healed {
+		el.SetCondition(componentv1.ConditionSentinelsConsistent, metav1.ConditionFalse, util.ReasonHealing, el.NeedReCheckError[healed].Error())
+	} else {
+		el.SetCondition(componentv1.ConditionSentinelsConsistent, metav1.ConditionTrue, util.ReasonConsistent, "all the sentinels monitor the master")
+	}
+
 	return el, nil
 }
 
 // --- checkAndHealCustomConfig ---
 func (r *RedisReconciler) checkAndHealCustomConfig(el element.Element) (element.Element, error) {
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	el, err = r.checkAndHealRedisCustomConfig(el)
 	if err != nil {
@@ -343,21 +407,17 @@ func (r *RedisReconciler) checkAndHealCustomConfig(el element.Element) (element.
 		return el, err
 	}
 
+	el, err = r.checkAndHealReplicaPriorities(el)
+	if err != nil {
+		return el, err
+	}
+
 	return el, nil
 }
 
 func (r *RedisReconciler) checkAndHealRedisCustomConfig(el element.Element) (element.Element, error) {
 	log := r.Log.WithValues("controller", "checkAndHealRedisCustomConfig")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	if el.Redis.Spec.Redis.CustomConfig != nil {
 		previousStatus := el.Redis.Status.Redis.RedisCustomConfig
 		currentStatus := *el.Redis.Status.Redis.RedisCustomConfig.DeepCopy()
@@ -370,14 +430,15 @@ func (r *RedisReconciler) checkAndHealRedisCustomConfig(el element.Element) (ele
 
 		if !reflect.DeepEqual(previousStatus, currentStatus) {
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("RedisCustomConfig Status not equal"))
+			el.SetCondition(componentv1.ConditionConfigApplied, metav1.ConditionFalse, util.ReasonApplying, "RedisCustomConfig is being applied")
 			Info(log, "RedisCustomConfig Status not equal", el.Redis)
-			if err = r.applyRedisCustomConfig(el); err != nil {
-				return el, err
-			}
-			if err = r.RedisHandler.Healer.UpdateRedisConfigStatus(el.Redis, currentStatus); err != nil {
+			err = r.applyRedisCustomConfig(el)
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonRedisConfigApplied, "applied Spec.Redis.CustomConfig to the redis", err)
+			incRollouts(el.Redis, rolloutRedisConfig, err)
+			if err != nil {
 				return el, err
 			}
-			el.NeedReLoad = true
+			el.Redis.Status.Redis.RedisCustomConfig = currentStatus
 		} else {
 			Info(log, "RedisCustomConfig Status equal", el.Redis)
 		}
@@ -387,15 +448,6 @@ func (r *RedisReconciler) checkAndHealRedisCustomConfig(el element.Element) (ele
 
 func (r *RedisReconciler) applyRedisCustomConfig(el element.Element) error {
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	redises, err := r.RedisHandler.Checker.GetRedisPods(el)
 	if err != nil {
 		return err
@@ -408,17 +460,54 @@ func (r *RedisReconciler) applyRedisCustomConfig(el element.Element) error {
 	return nil
 }
 
-func (r *RedisReconciler) checkAndHealSentinelCustomConfig(el element.Element) (element.Element, error) {
-	log := r.Log.WithValues("controller", "checkAndHealSentinelCustomConfig")
+// checkAndHealReplicaPriorities sets the replica priority of Spec.Redis.ReplicaPriorities on the running redis,
+// the ConfigMaps have it for the restarted ones
+func (r *RedisReconciler) checkAndHealReplicaPriorities(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkAndHealReplicaPriorities")
+
+	md5 := getReplicaPrioritiesMd5(el.Redis)
+	if md5 == el.Redis.Status.Redis.ReplicaPriorities.Md5 {
+		Info(log, "ReplicaPriorities Status equal", el.Redis)
+		return el, nil
+	}
+	// a switchover sets the priorities itself and restores them when it finishes
+	if el.Redis.Status.Switchover.IsSwitchingOver() {
+		return el, nil
+	}
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New("ReplicaPriorities Status not equal"))
+	el.SetCondition(componentv1.ConditionConfigApplied, metav1.ConditionFalse, util.ReasonApplying, "Spec.Redis.ReplicaPriorities is being applied")
+	Info(log, "ReplicaPriorities Status not equal", el.Redis)
+	redises, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, rip := range redises {
+		err = r.RedisHandler.Healer.SetRedisSlavePriority(rip, el.Redis)
 		if err != nil {
-			return el, err
+			break
 		}
-		el.Redis = redisNew
 	}
-	el.NeedReLoad = false
+	r.RedisHandler.RecordEvent(el.Redis, EventReasonRedisConfigApplied, "applied Spec.Redis.ReplicaPriorities to the redis", err)
+	incRollouts(el.Redis, rolloutRedisConfig, err)
+	if err != nil {
+		return el, err
+	}
+	el.Redis.Status.Redis.ReplicaPriorities.Md5 = md5
+	return el, nil
+}
+
+// getReplicaPrioritiesMd5 is empty when no priority is set, so that the existing instances do not apply them
+func getReplicaPrioritiesMd5(rf *componentv1.Redis) string {
+	if len(rf.Spec.Redis.ReplicaPriorities) == 0 {
+		return ""
+	}
+	priorities, _ := json.Marshal(rf.Spec.Redis.ReplicaPriorities)
+	return util.MD5(string(priorities))
+}
+
+func (r *RedisReconciler) checkAndHealSentinelCustomConfig(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkAndHealSentinelCustomConfig")
 
 	if el.Redis.Spec.Sentinel.CustomConfig != nil {
 		previousStatus := el.Redis.Status.Sentinel.SentinelCustomConfig
@@ -432,6 +521,7 @@ func (r *RedisReconciler) checkAndHealSentinelCustomConfig(el element.Element) (
 
 		if !reflect.DeepEqual(previousStatus, currentStatus) {
 			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("SentinelCustomConfig Status not equal"))
+			el.SetCondition(componentv1.ConditionConfigApplied, metav1.ConditionFalse, util.ReasonApplying, "SentinelCustomConfig is being applied")
 			Info(log, "SentinelCustomConfig Status not equal", el.Redis)
 
 			sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
@@ -439,14 +529,16 @@ func (r *RedisReconciler) checkAndHealSentinelCustomConfig(el element.Element) (
 				return el, err
 			}
 			for _, sip := range sentinels {
-				if err = r.RedisHandler.Healer.SetSentinelCustomConfig(sip, el.Redis); err != nil {
+				err = r.RedisHandler.Healer.SetSentinelCustomConfig(sip, el.Redis)
+				if err != nil {
+					r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelConfigApplied, "apply Spec.Sentinel.CustomConfig to sentinel "+podDesc(sip.Name, sip.Ip), err)
+					incRollouts(el.Redis, rolloutSentinelConfig, err)
 					return el, err
 				}
 			}
-			if err = r.RedisHandler.Healer.UpdateSentinelConfigStatus(el.Redis, currentStatus); err != nil {
-				return el, err
-			}
-			el.NeedReLoad = true
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelConfigApplied, "applied Spec.Sentinel.CustomConfig to the sentinels", nil)
+			incRollouts(el.Redis, rolloutSentinelConfig, nil)
+			el.Redis.Status.Sentinel.SentinelCustomConfig = currentStatus
 		} else {
 			Info(log, "CustomConfig Status equal", el.Redis)
 		}
@@ -457,15 +549,6 @@ func (r *RedisReconciler) checkAndHealSentinelCustomConfig(el element.Element) (
 
 // --- needCheckAndHealCustomConfig ---
 func (r *RedisReconciler) needCheckAndHealCustomConfig(el element.Element) (element.Element, error, bool) {
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err, false
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	el, err, need := r.needCheckAndHealRedisCustomConfig(el)
 	if err != nil {
 		return el, err, need
@@ -482,21 +565,16 @@ func (r *RedisReconciler) needCheckAndHealCustomConfig(el element.Element) (elem
 		return el, nil, true
 	}
 
+	if getReplicaPrioritiesMd5(el.Redis) != el.Redis.Status.Redis.ReplicaPriorities.Md5 {
+		return el, nil, true
+	}
+
 	return el, nil, false
 }
 
 func (r *RedisReconciler) needCheckAndHealRedisCustomConfig(el element.Element) (element.Element, error, bool) {
 	log := r.Log.WithValues("controller", "needCheckAndHealRedisCustomConfig")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err, false
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	if el.Redis.Spec.Redis.CustomConfig != nil {
 		previousStatus := el.Redis.Status.Redis.RedisCustomConfig
 		currentStatus := *el.Redis.Status.Redis.RedisCustomConfig.DeepCopy()
@@ -522,15 +600,6 @@ func (r *RedisReconciler) needCheckAndHealRedisCustomConfig(el element.Element)
 func (r *RedisReconciler) needCheckAndHealSentinelCustomConfig(el element.Element) (element.Element, error, bool) {
 	log := r.Log.WithValues("controller", "needCheckAndHealSentinelCustomConfig")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err, false
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	if el.Redis.Spec.Sentinel.CustomConfig != nil {
 		previousStatus := el.Redis.Status.Sentinel.SentinelCustomConfig
 		currentStatus := *el.Redis.Status.Sentinel.SentinelCustomConfig.DeepCopy()
@@ -555,15 +624,6 @@ func (r *RedisReconciler) needCheckAndHealSentinelCustomConfig(el element.Elemen
 
 // --- checkAndHealPassword ---
 func (r *RedisReconciler) checkAndHealPassword(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	el, err = r.checkAndHealSentinelPassword(el)
 	if err != nil {
@@ -581,15 +641,6 @@ func (r *RedisReconciler) checkAndHealPassword(el element.Element) (element.Elem
 func (r *RedisReconciler) checkAndHealRedisPassword(el element.Element) (element.Element, error) {
 	log := r.Log.WithValues("controller", "checkAndHealRedisPassword")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	previousStatus := el.Redis.Status.Redis.RedisPassword
 	currentStatus := *el.Redis.Status.Redis.RedisPassword.DeepCopy()
 
@@ -601,14 +652,15 @@ func (r *RedisReconciler) checkAndHealRedisPassword(el element.Element) (element
 
 	if !reflect.DeepEqual(previousStatus, currentStatus) {
 		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("RedisPassword Status not equal"))
+		el.SetCondition(componentv1.ConditionPasswordApplied, metav1.ConditionFalse, util.ReasonApplying, "RedisPassword is being applied")
 		Info(log, "RedisPassword Status not equal", el.Redis)
-		if err = r.applyRedisPassword(el); err != nil {
-			return el, err
-		}
-		if err := r.RedisHandler.Healer.UpdateRedisPasswordStatus(el.Redis, currentStatus); err != nil {
+		err = r.applyRedisPassword(el)
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonRedisPasswordApplied, "applied the new password to the redis", err)
+		incRollouts(el.Redis, rolloutRedisPassword, err)
+		if err != nil {
 			return el, err
 		}
-		el.NeedReLoad = true
+		el.Redis.Status.Redis.RedisPassword = currentStatus
 	} else {
 		Info(log, "RedisPassword Status equal", el.Redis)
 	}
@@ -618,14 +670,9 @@ func (r *RedisReconciler) checkAndHealRedisPassword(el element.Element) (element
 func (r *RedisReconciler) applyRedisPassword(el element.Element) error {
 	log := r.Log.WithValues("controller", "applyRedisPassword")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return err
-		}
-		el.Redis = redisNew
+	if el.Redis.IsClusterMode() {
+		return r.applyClusterRedisPassword(el)
 	}
-	el.NeedReLoad = false
 
 	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
 	if err != nil {
@@ -664,15 +711,6 @@ func (r *RedisReconciler) applyRedisPassword(el element.Element) error {
 func (r *RedisReconciler) checkAndHealSentinelPassword(el element.Element) (element.Element, error) {
 	log := r.Log.WithValues("controller", "checkAndHealSentinelPassword")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	previousStatus := el.Redis.Status.Sentinel.SentinelPassword
 	currentStatus := *el.Redis.Status.Sentinel.SentinelPassword.DeepCopy()
 	password, err := k8s.GetSpecRedisPassword(r.RedisHandler.K8sServices, el.Redis)
@@ -683,15 +721,16 @@ func (r *RedisReconciler) checkAndHealSentinelPassword(el element.Element) (elem
 
 	if !reflect.DeepEqual(previousStatus, currentStatus) {
 		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("SentinelPassword Status not equal"))
+		el.SetCondition(componentv1.ConditionPasswordApplied, metav1.ConditionFalse, util.ReasonApplying, "SentinelPassword is being applied")
 		Info(log, "SentinelPassword Status not equal", el.Redis)
-		if err = r.applySentinelPassword(el); err != nil {
+		err = r.applySentinelPassword(el)
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelPasswordApplied, "applied the new password to the sentinels", err)
+		incRollouts(el.Redis, rolloutSentinelPassword, err)
+		if err != nil {
 			return el, err
 		}
 
-		if err = r.RedisHandler.Healer.UpdateSentinelPasswordStatus(el.Redis, currentStatus); err != nil {
-			return el, err
-		}
-		el.NeedReLoad = true
+		el.Redis.Status.Sentinel.SentinelPassword = currentStatus
 	} else {
 		Info(log, "SentinelPassword Status equal", el.Redis)
 	}
@@ -701,15 +740,6 @@ func (r *RedisReconciler) checkAndHealSentinelPassword(el element.Element) (elem
 func (r *RedisReconciler) applySentinelPassword(el element.Element) error {
 	log := r.Log.WithValues("controller", "applySentinelPassword")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	redises, err := r.RedisHandler.Checker.GetSentinelsPods(el)
 	if err != nil {
 		return err
@@ -725,15 +755,6 @@ func (r *RedisReconciler) applySentinelPassword(el element.Element) error {
 
 // --- needCheckAndHealPassword ---
 func (r *RedisReconciler) needCheckAndHealPassword(el element.Element) (element.Element, error, bool) {
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err, false
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	el, err, need := r.needCheckAndHealRedisPassword(el)
 	if err != nil {
 		return el, err, need
@@ -756,15 +777,6 @@ func (r *RedisReconciler) needCheckAndHealPassword(el element.Element) (element.
 func (r *RedisReconciler) needCheckAndHealRedisPassword(el element.Element) (element.Element, error, bool) {
 	log := r.Log.WithValues("controller", "needCheckAndHealRedisPassword")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err, false
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	previousStatus := el.Redis.Status.Redis.RedisPassword
 	currentStatus := *el.Redis.Status.Redis.RedisPassword.DeepCopy()
 
@@ -786,15 +798,6 @@ func (r *RedisReconciler) needCheckAndHealRedisPassword(el element.Element) (ele
 func (r *RedisReconciler) needCheckAndHealSentinelPassword(el element.Element) (element.Element, error, bool) {
 	log := r.Log.WithValues("controller", "needCheckAndHealSentinelPassword")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err, false
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	previousStatus := el.Redis.Status.Sentinel.SentinelPassword
 	currentStatus := *el.Redis.Status.Sentinel.SentinelPassword.DeepCopy()
 	password, err := k8s.GetSpecRedisPassword(r.RedisHandler.K8sServices, el.Redis)
@@ -816,15 +819,6 @@ func (r *RedisReconciler) needCheckAndHealSentinelPassword(el element.Element) (
 func (r *RedisReconciler) CheckCluster(el element.Element, init bool) (element.Element, error) {
 	log := r.Log.WithValues("controller", "CheckCluster")
 
-	if el.NeedReLoad {
-		redisNew, err := r.RedisHandler.K8sServices.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	previousStatus := el.Redis.Status.State
 	currentStatus := *el.Redis.Status.State.DeepCopy()
 
@@ -832,10 +826,7 @@ func (r *RedisReconciler) CheckCluster(el element.Element, init bool) (element.E
 
 	if !reflect.DeepEqual(previousStatus, currentStatus) {
 		Info(log, "State Cluster Status not equal", el.Redis)
-		if err := r.RedisHandler.Healer.UpdatePodStatus(el.Redis, currentStatus); err != nil {
-			return el, err
-		}
-		el.NeedReLoad = true
+		el.Redis.Status.State = currentStatus
 	} else {
 		Info(log, "State Cluster Status equal", el.Redis)
 	}
@@ -0,0 +1,427 @@
+package controllers
+
+import (
+	"errors"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	clusterRoleMaster  = "master"
+	clusterRoleReplica = "replica"
+)
+
+// --- CheckAndHealCluster ---
+func (r *RedisReconciler) CheckAndHealCluster(el element.Element) (element.Element, error) {
+	// Number of shards and pods per shard is equal as the set on the RF spec
+	// All the pods know each other
+	// Every shard has one master serving its slots, the other pods of the shard replicate it
+	// Failed nodes without slots that are not a pod anymore are forgotten
+	// All the slots are served
+
+	err := util.NilError()
+	el, err = r.checkClusterState(el)
+	if err != nil {
+		return el, err
+	}
+
+	err = r.checkClusterNumber(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, shards, err := r.getClusterShardPods(el)
+	if err != nil {
+		return el, err
+	}
+	if len(el.NeedReCheckError) > 0 {
+		return el, nil
+	}
+
+	el, err = r.checkAndHealClusterMeet(el, shards)
+	if err != nil {
+		return el, err
+	}
+	if len(el.NeedReCheckError) > 0 {
+		return el, nil
+	}
+
+	el, err = r.checkAndHealClusterShards(el, shards)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.checkAndHealClusterForget(el, shards)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.checkClusterSlots(el, shards)
+	if err != nil {
+		return el, err
+	}
+
+	// checkAndHealRedisCustomConfig and checkAndHealRedisPassword set them to false while applying
+	el.SetCondition(roav1.ConditionConfigApplied, metav1.ConditionTrue, util.ReasonApplied, "the custom config is applied")
+	el.SetCondition(roav1.ConditionPasswordApplied, metav1.ConditionTrue, util.ReasonApplied, "the password is applied")
+
+	el, err = r.checkAndHealRedisCustomConfig(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.checkAndHealRedisPassword(el)
+	if err != nil {
+		return el, err
+	}
+
+	return el, nil
+}
+
+// --- checkClusterState ---
+func (r *RedisReconciler) checkClusterState(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkClusterState")
+
+	previousStatus := el.Redis.Status.State
+	currentStatus := *el.Redis.Status.State.DeepCopy()
+
+	podList := el.Snapshot.ListPods(util.GetInstanceLabels(el.Redis.Name))
+	currentStatus.Pods = r.RedisHandler.getPodStates(podList)
+	currentStatus.Phase = util.GetGlobalPhase(el.Redis, podList)
+	currentStatus.Ready = util.GetGlobalReady(el.Redis, podList)
+
+	// the roles are only known once a pod answers, keep the previous ones until then
+	nodes, err := r.getClusterView(el)
+	if err != nil {
+		Info(log, "can not get the cluster nodes, skip the cluster roles: "+err.Error(), el.Redis)
+	} else {
+		podNames := make(map[string]string)
+		for name, podState := range currentStatus.Pods {
+			if podState.PodIP == "" {
+				continue
+			}
+			podNames[podState.PodIP] = name
+			if node, ok := nodes[podState.PodIP]; ok {
+				if node.IsMaster() {
+					podState.ClusterRole = clusterRoleMaster
+				} else {
+					podState.ClusterRole = clusterRoleReplica
+				}
+				currentStatus.Pods[name] = podState
+			}
+		}
+		currentStatus.Shards = getShardStates(el.Redis, nodes, podNames)
+	}
+
+	if !reflect.DeepEqual(previousStatus, currentStatus) {
+		Info(r.Log, "State Status not equal", el.Redis)
+		el.Redis.Status.State = currentStatus
+	} else {
+		Info(r.Log, "State Status equal", el.Redis)
+	}
+	return el, nil
+}
+
+func getShardStates(rf *roav1.Redis, nodes map[string]redis_client.ClusterNode, podNames map[string]string) []roav1.ShardState {
+	podNodes := make(map[string]redis_client.ClusterNode)
+	for ip, node := range nodes {
+		if name, ok := podNames[ip]; ok {
+			podNodes[name] = node
+		}
+	}
+
+	shards := make([]roav1.ShardState, 0)
+	for i := 0; i < int(rf.Spec.Cluster.Shards); i++ {
+		shard := roav1.ShardState{
+			Name: util.GetRedisClusterShardNameByIndex(rf, i),
+		}
+		for _, podName := range util.GetRedisClusterShardPodNames(rf, i) {
+			node, ok := podNodes[podName]
+			if !ok {
+				continue
+			}
+			if node.IsMaster() {
+				shard.Master = podName
+				shard.Slots = node.Slots
+			} else {
+				shard.Replicas = append(shard.Replicas, podName)
+			}
+		}
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+// getClusterView returns the nodes known by the first running redis pod, by ip
+func (r *RedisReconciler) getClusterView(el element.Element) (map[string]redis_client.ClusterNode, error) {
+	redises, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return nil, err
+	}
+	if len(redises) == 0 {
+		return nil, errors.New("number of running redis pods is 0")
+	}
+	return r.getClusterViewFrom(redises[0])
+}
+
+func (r *RedisReconciler) getClusterViewFrom(redisPod redis_client.RedisParam) (map[string]redis_client.ClusterNode, error) {
+	nodes, err := r.RedisHandler.Checker.GetClusterNodes(redisPod)
+	if err != nil {
+		return nil, err
+	}
+	view := make(map[string]redis_client.ClusterNode)
+	for _, node := range nodes {
+		// a node that has never met another one does not know its own ip
+		if node.IsMyself() && node.Ip == "" {
+			node.Ip = redisPod.Ip
+		}
+		if node.Ip != "" {
+			view[node.Ip] = node
+		}
+	}
+	return view, nil
+}
+
+// --- checkClusterNumber ---
+func (r *RedisReconciler) checkClusterNumber(el element.Element) error {
+	log := r.Log.WithValues("controller", "checkClusterNumber")
+
+	if err := r.RedisHandler.Checker.CheckRedisClusterShardNumber(el); err != nil {
+		Error(log, err, "Number of redis cluster shards mismatch, this could be for a change on the statefulset", el.Redis)
+		return err
+	}
+
+	return nil
+}
+
+// getClusterShardPods returns the running pods of every shard, and waits until all of them are running
+func (r *RedisReconciler) getClusterShardPods(el element.Element) (element.Element, [][]redis_client.RedisParam, error) {
+	log := r.Log.WithValues("controller", "getClusterShardPods")
+
+	shards := make([][]redis_client.RedisParam, 0)
+	for i := 0; i < int(el.Redis.Spec.Cluster.Shards); i++ {
+		redises, err := r.RedisHandler.Checker.GetRedisClusterShardPods(el, i)
+		if err != nil {
+			return el, nil, err
+		}
+		if len(redises) != int(util.GetRedisClusterShardReplicas(el.Redis)) {
+			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("not all pods of shard "+strconv.Itoa(i)+" are running"))
+			Info(log, "not all pods of shard "+strconv.Itoa(i)+" are running, wait", el.Redis)
+		}
+		shards = append(shards, redises)
+	}
+	return el, shards, nil
+}
+
+// --- checkAndHealClusterMeet ---
+func (r *RedisReconciler) checkAndHealClusterMeet(el element.Element, shards [][]redis_client.RedisParam) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkAndHealClusterMeet")
+
+	first := shards[0][0]
+	view, err := r.getClusterViewFrom(first)
+	if err != nil {
+		return el, err
+	}
+
+	for _, redises := range shards {
+		for _, redisPod := range redises {
+			if _, ok := view[redisPod.Ip]; ok {
+				continue
+			}
+			el.NeedReCheckError = append(el.NeedReCheckError, errors.New(redisPod.Name+" is not known by the cluster"))
+			Info(log, redisPod.Name+" is not known by the cluster", el.Redis)
+			err := r.RedisHandler.Healer.ClusterMeet(first, redisPod, el.Redis)
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonClusterMeet, podDesc(redisPod.Name, redisPod.Ip)+" is not known by the cluster, made "+first.Name+" meet it", err)
+			if err != nil {
+				return el, err
+			}
+		}
+	}
+	return el, nil
+}
+
+// --- checkAndHealClusterShards ---
+func (r *RedisReconciler) checkAndHealClusterShards(el element.Element, shards [][]redis_client.RedisParam) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkAndHealClusterShards")
+
+	view, err := r.getClusterViewFrom(shards[0][0])
+	if err != nil {
+		return el, err
+	}
+
+	assigned := make([]bool, util.ClusterSlots)
+	for _, node := range view {
+		for _, slot := range redis_client.ExpandSlots(node.Slots) {
+			if slot >= 0 && slot < util.ClusterSlots {
+				assigned[slot] = true
+			}
+		}
+	}
+
+	noMaster := make([]string, 0)
+	multipleMasters := make([]string, 0)
+	for index, redises := range shards {
+		shardName := util.GetRedisClusterShardNameByIndex(el.Redis, index)
+
+		masters := make([]redis_client.ClusterNode, 0)
+		for _, redisPod := range redises {
+			node := view[redisPod.Ip]
+			if node.IsMaster() && len(node.Slots) > 0 && !node.IsFailed() {
+				masters = append(masters, node)
+			}
+		}
+
+		var master redis_client.ClusterNode
+		switch len(masters) {
+		case 0:
+			// a new shard, its first pod serves the slots of the shard that nobody serves
+			start, end := util.GetClusterSlotRangeByIndex(el.Redis, index)
+			slots := make([]int, 0)
+			for slot := start; slot <= end; slot++ {
+				if !assigned[slot] {
+					slots = append(slots, slot)
+				}
+			}
+			noMaster = append(noMaster, shardName)
+			if len(slots) == 0 {
+				Info(log, "No master in shard "+shardName+" and its slots are served by other shards, fix manually", el.Redis)
+				continue
+			}
+			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("No master in shard "+shardName))
+			Info(log, "No master in shard "+shardName+", adding its slots to "+redises[0].Name, el.Redis)
+			err := r.RedisHandler.Healer.ClusterAddSlots(redises[0], slots, el.Redis)
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonClusterAddSlots, "no master in shard "+shardName+", added its "+strconv.Itoa(len(slots))+" slots to "+podDesc(redises[0].Name, redises[0].Ip), err)
+			if err != nil {
+				return el, err
+			}
+			master = view[redises[0].Ip]
+		case 1:
+			master = masters[0]
+		default:
+			Info(log, "More than one master in shard "+shardName+", fix manually", el.Redis)
+			multipleMasters = append(multipleMasters, shardName)
+			continue
+		}
+
+		for _, redisPod := range redises {
+			node := view[redisPod.Ip]
+			if node.ID == master.ID || node.MasterID == master.ID {
+				continue
+			}
+			el.NeedReCheckError = append(el.NeedReCheckError, errors.New(redisPod.Name+" does not replicate the master of shard "+shardName))
+			Info(log, redisPod.Name+" does not replicate the master of shard "+shardName, el.Redis)
+			err := r.RedisHandler.Healer.ClusterReplicate(redisPod, master.ID, el.Redis)
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonClusterReplicate, podDesc(redisPod.Name, redisPod.Ip)+" does not replicate the master of shard "+shardName+", made it replicate node "+podDesc(master.ID, master.Ip), err)
+			if err != nil {
+				return el, err
+			}
+		}
+	}
+
+	switch {
+	case len(multipleMasters) > 0:
+		el.SetCondition(roav1.ConditionMasterElected, metav1.ConditionFalse, util.ReasonMultipleMasters, "more than one master in shards "+strings.Join(multipleMasters, ",")+", fix manually")
+	case len(noMaster) > 0:
+		el.SetCondition(roav1.ConditionMasterElected, metav1.ConditionFalse, util.ReasonNoMaster, "no master in shards "+strings.Join(noMaster, ","))
+	default:
+		el.SetCondition(roav1.ConditionMasterElected, metav1.ConditionTrue, util.ReasonMasterElected, "one master found in every shard")
+	}
+	return el, nil
+}
+
+// --- checkAndHealClusterForget ---
+func (r *RedisReconciler) checkAndHealClusterForget(el element.Element, shards [][]redis_client.RedisParam) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkAndHealClusterForget")
+
+	view, err := r.getClusterViewFrom(shards[0][0])
+	if err != nil {
+		return el, err
+	}
+
+	podIps := make(map[string]bool)
+	for _, redises := range shards {
+		for _, redisPod := range redises {
+			podIps[redisPod.Ip] = true
+		}
+	}
+
+	for ip, node := range view {
+		if !node.IsFailed() || len(node.Slots) > 0 || podIps[ip] {
+			continue
+		}
+		// a node can not forget its master
+		hasReplicas := false
+		for _, other := range view {
+			if other.MasterID == node.ID {
+				hasReplicas = true
+			}
+		}
+		if hasReplicas {
+			continue
+		}
+
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("failed node "+node.ID+" is still known by the cluster"))
+		Info(log, "failed node "+node.ID+" with ip "+ip+" is still known by the cluster", el.Redis)
+		message := "failed node " + podDesc(node.ID, ip) + " is still known by the cluster, made all the pods forget it"
+		for _, redises := range shards {
+			for _, redisPod := range redises {
+				if err := r.RedisHandler.Healer.ClusterForget(redisPod, node.ID, el.Redis); err != nil {
+					r.RedisHandler.RecordEvent(el.Redis, EventReasonClusterForget, message, err)
+					return el, err
+				}
+			}
+		}
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonClusterForget, message, nil)
+	}
+	return el, nil
+}
+
+// --- checkClusterSlots ---
+func (r *RedisReconciler) checkClusterSlots(el element.Element, shards [][]redis_client.RedisParam) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkClusterSlots")
+
+	if err := r.RedisHandler.Checker.CheckClusterSlots(shards[0][0]); err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, err)
+		Info(log, "Not all slots are served: "+err.Error(), el.Redis)
+	}
+	return el, nil
+}
+
+// --- applyClusterRedisPassword ---
+func (r *RedisReconciler) applyClusterRedisPassword(el element.Element) error {
+	log := r.Log.WithValues("controller", "applyClusterRedisPassword")
+
+	redises, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return err
+	}
+	view, err := r.getClusterView(el)
+	if err != nil {
+		return err
+	}
+
+	// the masters first, so that the replicas authenticate with the new password once they have it
+	for _, redisPod := range redises {
+		if node, ok := view[redisPod.Ip]; ok && node.IsMaster() {
+			Info(log, "starting set redis master "+redisPod.Name+" new password...", el.Redis)
+			if err := r.RedisHandler.Healer.SetRedisPassword(redisPod, el.Redis); err != nil {
+				return err
+			}
+		}
+	}
+	for _, redisPod := range redises {
+		if node, ok := view[redisPod.Ip]; !ok || !node.IsMaster() {
+			Info(log, "starting set redis replica "+redisPod.Name+" new password...", el.Redis)
+			if err := r.RedisHandler.Healer.SetRedisPassword(redisPod, el.Redis); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -24,13 +24,12 @@ import (
 	"github.com/zhizuqiu/redis-operator/controllers/util"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
 	runt "runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"time"
-
-	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 )
 
 // RedisReconciler reconciles a Redis object
@@ -41,9 +40,15 @@ type RedisReconciler struct {
 	RedisHandler *RedisHandler
 }
 
+// the Redis is reconciled when it, a resource it owns, one of its pods or a Secret it references changes,
+// it is only requeued to retry, to wait for a step in progress, or to poll while the certificates reload, a slave
+// is degraded or a volume is expanded. In sentinel mode it is also requeued every RoleRequeueAfter, the sentinels
+// fail over a master whose pod stays ready without any change the operator watches, so the role labels lag behind
+// such a failover by up to RoleRequeueAfter, at the cost of polling the idle instances. 0 disables it
 var (
 	ErrorRequeueAfter  = 10 * time.Second
 	NormalRequeueAfter = 30 * time.Second
+	RoleRequeueAfter   = 60 * time.Second
 )
 
 // +kubebuilder:rbac:groups=component.zhizuqiu,resources=redis,verbs=get;list;watch;create;update;patch;delete
@@ -63,6 +68,8 @@ var (
 // +kubebuilder:rbac:groups="",resources=secrets/status,verbs=get
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims/status,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 func (r *RedisReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
 
@@ -93,10 +100,10 @@ func (r *RedisReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.R
 	oRefs := r.RedisHandler.createOwnerReferences(redis)
 
 	el := element.Element{
-		NeedReLoad: false,
-		Req:        req,
-		Redis:      redis,
-		OwnerRefs:  oRefs,
+		Req:            req,
+		Redis:          redis,
+		ObservedStatus: *redis.Status.DeepCopy(),
+		OwnerRefs:      oRefs,
 	}
 
 	isRedisMarkedToBeDeleted := el.Redis.GetDeletionTimestamp() != nil
@@ -119,7 +126,7 @@ func (r *RedisReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.R
 				return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
 			}
 		}
-		return ctrl.Result{RequeueAfter: NormalRequeueAfter}, nil
+		return ctrl.Result{}, nil
 	}
 
 	// Add finalizer for this CR
@@ -132,47 +139,157 @@ func (r *RedisReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.R
 		}
 	}
 
+	// the generation this reconcile works on, the spec may be updated during it
+	generation := el.Redis.Generation
+
 	err = el.Redis.Check()
 	if err != nil {
 		Error(r.Log, err, "Redis.Check error!", redis)
+		r.updateStatus(el, generation, util.ReasonInvalidSpec, err)
 		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
 	}
 
+	ensureStart := time.Now()
 	el, err = r.Ensure(el)
+	observeReconcilePhase(phaseEnsure, ensureStart)
 	if err != nil {
 		Error(r.Log, err, "Ensure error!", redis)
+		r.updateStatus(el, generation, util.ReasonEnsureFailed, err)
 		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
 	}
 
-	fmt.Println("after ensure needReLoad:")
-	fmt.Println(el.NeedReLoad)
+	el.Snapshot, err = r.RedisHandler.Checker.Observe(el)
+	if err != nil {
+		Error(r.Log, err, "Observe error!", redis)
+		r.updateStatus(el, generation, util.ReasonObserveFailed, err)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+
+	el, err = r.ScaleDown(el)
+	if err != nil {
+		Error(r.Log, err, "ScaleDown error!", redis)
+		r.updateStatus(el, generation, util.ReasonScaleDownFailed, err)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+	if len(el.NeedReCheckError) > 0 {
+		Error(r.Log, err, "len(el.NeedReCheckError) > 0, wait next reconcile", redis)
+		r.updateStatus(el, generation, util.ReasonHealing, nil)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
 
+	checkAndHealStart := time.Now()
 	el, err = r.CheckAndHeal(el)
+	observeReconcilePhase(phaseCheckAndHeal, checkAndHealStart)
 	if err != nil {
 		Error(r.Log, err, "CheckAndHeal error!", redis)
+		r.updateStatus(el, generation, util.ReasonCheckAndHealFailed, err)
 		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
 	}
 	if len(el.NeedReCheckError) > 0 {
 		Error(r.Log, err, "len(el.NeedReCheckError) > 0, wait next reconcile", redis)
+		r.updateStatus(el, generation, util.ReasonHealing, nil)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+
+	el, err = r.CheckAndReloadTLS(el)
+	if err != nil {
+		Error(r.Log, err, "CheckAndReloadTLS error!", redis)
+		r.updateStatus(el, generation, util.ReasonTLSReloadFailed, err)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+
+	el, err = r.CheckAndHealACLUsers(el)
+	if err != nil {
+		Error(r.Log, err, "CheckAndHealACLUsers error!", redis)
+		r.updateStatus(el, generation, util.ReasonACLUsersFailed, err)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+
+	el, err = r.Switchover(el)
+	if err != nil {
+		Error(r.Log, err, "Switchover error!", redis)
+		r.updateStatus(el, generation, util.ReasonSwitchoverFailed, err)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+	if len(el.NeedReCheckError) > 0 {
+		Info(r.Log, "switchover in progress, wait next reconcile", redis)
+		r.updateStatus(el, generation, util.ReasonSwitchingOver, nil)
 		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
 	}
 
-	_, err = r.CheckCluster(el, true)
+	el, err = r.RollingUpdate(el)
+	if err != nil {
+		Error(r.Log, err, "RollingUpdate error!", redis)
+		r.updateStatus(el, generation, util.ReasonRollingUpdateFailed, err)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+	if len(el.NeedReCheckError) > 0 {
+		Info(r.Log, "rolling update in progress, wait next reconcile", redis)
+		r.updateStatus(el, generation, util.ReasonRollingUpdate, nil)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+
+	checkClusterStart := time.Now()
+	el, err = r.CheckCluster(el, true)
+	observeReconcilePhase(phaseCheckCluster, checkClusterStart)
 	if err != nil {
 		Error(r.Log, err, "CheckCluster error!", redis)
+		r.updateStatus(el, generation, util.ReasonCheckClusterFailed, err)
 		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
 	}
 
+	r.updateStatus(el, generation, "", nil)
+
 	fmt.Println("Reconcile over")
 
-	return ctrl.Result{RequeueAfter: NormalRequeueAfter}, nil
+	requeueAfter := tlsReloadRequeueAfter(el.Redis)
+	if requeueAfter == 0 {
+		requeueAfter = replicationRequeueAfter(el.Redis)
+	}
+	if requeueAfter == 0 {
+		requeueAfter = volumeExpansionRequeueAfter(el.Redis)
+	}
+	if requeueAfter == 0 {
+		requeueAfter = roleRequeueAfter(el.Redis)
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// updateStatus computes the conditions observed by the reconcile and patches the status the steps changed,
+// err is of the step that failed, otherwise el.NeedReCheckError makes it Degraded. An error here is only
+// logged, the next reconcile does the steps again
+func (r *RedisReconciler) updateStatus(el element.Element, generation int64, failedReason string, err error) {
+	errs := el.NeedReCheckError
+	if err != nil {
+		errs = []error{err}
+	}
+	el.Redis.Status.Conditions = util.MergeConditions(el.Redis, el.Conditions, generation, failedReason, errs)
+	el.Redis.Status.ObservedGeneration = generation
+	if reflect.DeepEqual(el.ObservedStatus, el.Redis.Status) {
+		return
+	}
+	if updateErr := r.RedisHandler.K8sServices.PatchStatus(el.Redis, el.ObservedStatus); updateErr != nil {
+		Error(r.Log, updateErr, "patch status error!", el.Redis)
+	}
+}
+
+// updateRedis updates the metadata or the spec of the Redis, keeping the status changed by the reconcile,
+// which the update returns as it is stored
+func (r *RedisReconciler) updateRedis(el element.Element) error {
+	status := el.Redis.Status.DeepCopy()
+	if err := r.RedisHandler.K8sServices.Update(context.Background(), el.Redis); err != nil {
+		return err
+	}
+	el.Redis.Status = *status
+	return nil
 }
 
 func (r *RedisReconciler) finalizeRedis(reqLogger logr.Logger, el element.Element) error {
-	// needs to do before the CR can be deleted. Examples
-	// of finalizers include performing backups and deleting
+	// needs to do before the CR can be deleted, e.g. deleting
 	// resources that are not owned by this CR, like a PVC.
+	// The backups are taken by RedisBackup, they are kept in the storage.
 
+	deleteInstanceMetrics(el.Redis)
 	el, err := r.DeleteEnsure(el)
 	if err != nil {
 		return err
@@ -181,9 +298,3 @@ func (r *RedisReconciler) finalizeRedis(reqLogger logr.Logger, el element.Elemen
 	reqLogger.Info("Successfully finalized redis")
 	return nil
 }
-
-func (r *RedisReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&componentv1.Redis{}).
-		Complete(r)
-}
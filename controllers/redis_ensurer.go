@@ -3,11 +3,17 @@ package controllers
 import (
 	"github.com/zhizuqiu/redis-operator/controllers/service/element"
 	"github.com/zhizuqiu/redis-operator/controllers/util"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 )
 
 func (r *RedisReconciler) Ensure(el element.Element) (element.Element, error) {
 	err := util.NilError()
 
+	if el.Redis.IsClusterMode() {
+		return r.ensureCluster(el)
+	}
+
 	el, err = r.RedisHandler.Ensurer.EnsureSentinelConfigMaps(el)
 	if err != nil {
 		return el, err
@@ -28,12 +34,25 @@ func (r *RedisReconciler) Ensure(el element.Element) (element.Element, error) {
 		return el, err
 	}
 
+	el, err = r.RedisHandler.Ensurer.EnsureRedisRestoreSecret(el)
+	if err != nil {
+		return el, err
+	}
+
 	el, err = r.RedisHandler.Ensurer.EnsureRedisStatefulSets(el)
 	if err != nil {
 		return el, err
 	}
 
-	el, err = r.RedisHandler.Ensurer.EnsureSentinelStatefulSets(el)
+	// the sentinels start monitoring the master after it has loaded the backup
+	if el.Redis.Status.Restore.Phase != componentv1.Restoring {
+		el, err = r.RedisHandler.Ensurer.EnsureSentinelStatefulSets(el)
+		if err != nil {
+			return el, err
+		}
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureVolumeExpansion(el)
 	if err != nil {
 		return el, err
 	}
@@ -59,6 +78,21 @@ func (r *RedisReconciler) Ensure(el element.Element) (element.Element, error) {
 		}
 	}
 
+	el, err = r.RedisHandler.Ensurer.EnsureRedisRoleServices(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureRedisPodDisruptionBudget(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureSentinelPodDisruptionBudget(el)
+	if err != nil {
+		return el, err
+	}
+
 	if el.Redis.Spec.Exporter.Enabled {
 		el, err = r.RedisHandler.Ensurer.EnsureExporterDeployment(el)
 		if err != nil {
@@ -69,6 +103,37 @@ func (r *RedisReconciler) Ensure(el element.Element) (element.Element, error) {
 	return el, nil
 }
 
+func (r *RedisReconciler) ensureCluster(el element.Element) (element.Element, error) {
+	err := util.NilError()
+
+	el, err = r.RedisHandler.Ensurer.EnsureRedisReadinessConfigMap(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureRedisClusterConfigMap(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureRedisClusterStatefulSets(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureVolumeExpansion(el)
+	if err != nil {
+		return el, err
+	}
+
+	el, err = r.RedisHandler.Ensurer.EnsureRedisClusterHeadlessServices(el)
+	if err != nil {
+		return el, err
+	}
+
+	return el, nil
+}
+
 func (r *RedisReconciler) DeleteEnsure(el element.Element) (element.Element, error) {
 
 	err := util.NilError()
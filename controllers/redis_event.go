@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// the reasons of the events on Redis, a failed action is a Warning event with the reason suffixed by Failed
+const (
+	EventReasonNoMaster                = "NoMaster"
+	EventReasonMultipleMasters         = "MultipleMasters"
+	EventReasonMasterElected           = "MasterElected"
+	EventReasonSlavesReplicated        = "SlavesReplicated"
+	EventReasonSentinelMonitored       = "SentinelMonitored"
+	EventReasonSentinelReset           = "SentinelReset"
+	EventReasonFailover                = "Failover"
+	EventReasonPromotionDisabled       = "PromotionDisabled"
+	EventReasonPromotionEnabled        = "PromotionEnabled"
+	EventReasonRedisConfigApplied      = "RedisConfigApplied"
+	EventReasonSentinelConfigApplied   = "SentinelConfigApplied"
+	EventReasonRedisPasswordApplied    = "RedisPasswordApplied"
+	EventReasonSentinelPasswordApplied = "SentinelPasswordApplied"
+	EventReasonClusterMeet             = "ClusterMeet"
+	EventReasonClusterAddSlots         = "ClusterAddSlots"
+	EventReasonClusterReplicate        = "ClusterReplicate"
+	EventReasonClusterForget           = "ClusterForget"
+	EventReasonSwitchoverStarted       = "SwitchoverStarted"
+	EventReasonSwitchover              = "Switchover"
+	EventReasonSwitchoverCompleted     = "SwitchoverCompleted"
+	EventReasonSwitchoverFailed        = "SwitchoverFailed"
+	EventReasonRollingUpdate           = "RollingUpdate"
+	EventReasonACLUsersApplied         = "ACLUsersApplied"
+	EventReasonTLSCertRotated          = "TLSCertRotated"
+	EventReasonTLSCertReloaded         = "TLSCertReloaded"
+	EventReasonMasterDemoted           = "MasterDemoted"
+	EventReasonRoleLabelsUpdated       = "RoleLabelsUpdated"
+	eventReasonFailedSuffix            = "Failed"
+)
+
+// RecordEvent emits a Normal event of the action on the Redis, or a Warning one with the error if it failed
+func (r *RedisHandler) RecordEvent(rf *roav1.Redis, reason string, message string, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	if err != nil {
+		r.Recorder.Event(rf, v1.EventTypeWarning, reason+eventReasonFailedSuffix, message+": "+err.Error())
+		return
+	}
+	r.Recorder.Event(rf, v1.EventTypeNormal, reason, message)
+}
+
+// RecordWarning emits a Warning event of a problem found on the Redis
+func (r *RedisHandler) RecordWarning(rf *roav1.Redis, reason string, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(rf, v1.EventTypeWarning, reason, message)
+}
+
+// podDesc is the pod name and ip in the messages of the events
+func podDesc(name, ip string) string {
+	return name + " (" + ip + ")"
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"errors"
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"k8s.io/client-go/tools/record"
+	"testing"
+)
+
+func TestRecordEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	handler := &RedisHandler{Recorder: recorder}
+	rf := &componentv1.Redis{}
+
+	handler.RecordEvent(rf, EventReasonMasterElected, "made the oldest redis "+podDesc("redis-redis-sample-0-0", "10.0.0.1")+" the master", nil)
+	handler.RecordEvent(rf, EventReasonSentinelReset, "reset sentinel "+podDesc("sentinel-redis-sample-0-0", "10.0.0.2"), errors.New("connection refused"))
+	handler.RecordWarning(rf, EventReasonMultipleMasters, "2 masters found, fix manually")
+
+	expected := []string{
+		"Normal MasterElected made the oldest redis redis-redis-sample-0-0 (10.0.0.1) the master",
+		"Warning SentinelResetFailed reset sentinel sentinel-redis-sample-0-0 (10.0.0.2): connection refused",
+		"Warning MultipleMasters 2 masters found, fix manually",
+	}
+	for _, e := range expected {
+		if actual := <-recorder.Events; actual != e {
+			t.Fatalf("actual = %s; expected = %s", actual, e)
+		}
+	}
+
+	// the handler may be built without a recorder
+	(&RedisHandler{}).RecordEvent(rf, EventReasonFailover, "failed over", nil)
+}
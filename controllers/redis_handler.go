@@ -10,6 +10,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"time"
 )
@@ -48,10 +49,11 @@ type RedisHandler struct {
 	DeleteChecker check.RedisDeleteChecke
 	Healer        check.RedisHeal
 	K8sServices   k8s.Services
+	Recorder      record.EventRecorder
 	Log           logr.Logger
 }
 
-func NewRedisHandler(ensurer ensure.RedisEnsure, deleteEnsurer ensure.RedisDeleteEnsure, checker check.RedisCheck, deleteChecker check.RedisDeleteChecke, healer check.RedisHeal, k8sServices k8s.Services, log logr.Logger) *RedisHandler {
+func NewRedisHandler(ensurer ensure.RedisEnsure, deleteEnsurer ensure.RedisDeleteEnsure, checker check.RedisCheck, deleteChecker check.RedisDeleteChecke, healer check.RedisHeal, k8sServices k8s.Services, recorder record.EventRecorder, log logr.Logger) *RedisHandler {
 	return &RedisHandler{
 		Ensurer:       ensurer,
 		DeleteEnsurer: deleteEnsurer,
@@ -59,6 +61,7 @@ func NewRedisHandler(ensurer ensure.RedisEnsure, deleteEnsurer ensure.RedisDelet
 		DeleteChecker: deleteChecker,
 		Healer:        healer,
 		K8sServices:   k8sServices,
+		Recorder:      recorder,
 		Log:           log,
 	}
 }
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"strings"
+	"time"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+// --- labelRedisRoles ---
+// labels the redis pods with the role they have now that masterPod is the master, so that the master and
+// replicas Services select them at once after a failover
+func (r *RedisReconciler) labelRedisRoles(el element.Element, masterPod redis_client.RedisParam) error {
+	log := r.Log.WithValues("controller", "labelRedisRoles")
+
+	relabelled, err := r.RedisHandler.Checker.LabelRedisRoles(el, masterPod)
+	if len(relabelled) == 0 && err == nil {
+		return nil
+	}
+	message := "the master is " + podDesc(masterPod.Name, masterPod.Ip) + ", relabelled the roles of " + strings.Join(relabelled, ", ")
+	Info(log, message, el.Redis)
+	r.RedisHandler.RecordEvent(el.Redis, EventReasonRoleLabelsUpdated, message, err)
+	return err
+}
+
+// roleRequeueAfter polls a Redis in sentinel mode with slaves, so that the labels follow a failover the sentinels
+// do on their own within RoleRequeueAfter
+func roleRequeueAfter(rf *componentv1.Redis) time.Duration {
+	if RoleRequeueAfter <= 0 || rf.IsClusterMode() || rf.Spec.Redis.Replicas < 2 {
+		return 0
+	}
+	return RoleRequeueAfter
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"testing"
+	"time"
+)
+
+func TestRoleRequeueAfter(t *testing.T) {
+	rf := &componentv1.Redis{}
+	rf.Spec.Redis.Replicas = 2
+	if requeueAfter := roleRequeueAfter(rf); requeueAfter != RoleRequeueAfter {
+		t.Fatalf("sentinel mode: requeueAfter = %s; expected %s", requeueAfter, RoleRequeueAfter)
+	}
+
+	rf.Spec.Redis.Replicas = 1
+	if requeueAfter := roleRequeueAfter(rf); requeueAfter != 0 {
+		t.Fatalf("single redis: requeueAfter = %s; expected 0", requeueAfter)
+	}
+
+	rf.Spec.Mode = componentv1.ClusterMode
+	rf.Spec.Redis.Replicas = 2
+	if requeueAfter := roleRequeueAfter(rf); requeueAfter != 0 {
+		t.Fatalf("cluster mode: requeueAfter = %s; expected 0", requeueAfter)
+	}
+
+	// --role-requeue-after=0
+	defer func(requeueAfter time.Duration) {
+		RoleRequeueAfter = requeueAfter
+	}(RoleRequeueAfter)
+	RoleRequeueAfter = 0
+	rf.Spec.Mode = ""
+	if requeueAfter := roleRequeueAfter(rf); requeueAfter != 0 {
+		t.Fatalf("disabled: requeueAfter = %s; expected 0", requeueAfter)
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+// --- RollingUpdate ---
+// rolls the redis StatefulSets whose pod template differs from the spec one index at a time: the slaves
+// first, each one once the other redis pods are ready and in sync with the master, then the master after
+// a switchover to an updated slave
+func (r *RedisReconciler) RollingUpdate(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "RollingUpdate")
+
+	if el.Redis.IsClusterMode() {
+		// the shards are rolled by EnsureRedisClusterStatefulSets
+		return el, nil
+	}
+	if el.Redis.Status.Switchover.IsSwitchingOver() || el.Redis.Annotations[componentv1.SwitchoverAnnotation] != "" {
+		return el, nil
+	}
+
+	indexes, rolling, err := r.RedisHandler.Checker.GetRedisRollingIndexes(el)
+	if err != nil {
+		return el, err
+	}
+	if len(indexes) == 0 {
+		return el, nil
+	}
+	if rolling {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("wait for the redis pods to be ready before rolling the next one"))
+		return el, nil
+	}
+
+	redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, redisPod := range redisPods {
+		if err := r.RedisHandler.Checker.CheckRedisInSync(redisPod); err != nil {
+			Info(log, err.Error()+", wait", el.Redis)
+			el.NeedReCheckError = append(el.NeedReCheckError, err)
+			return el, nil
+		}
+	}
+	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
+	if err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("no single master, wait before the rolling update: "+err.Error()))
+		return el, nil
+	}
+
+	masterIndex := -1
+	for _, index := range indexes {
+		if getRedisPodNameByIndex(el, index) == masterPod.Name {
+			masterIndex = index
+			continue
+		}
+		return r.rollRedisStatefulSet(el, index, "slave")
+	}
+
+	// only the master is left, move it to a slave that runs the new template
+	target := r.getRollingSwitchoverTarget(el, redisPods, masterPod)
+	if target == "" {
+		// e.g. a single redis, there is no slave to switch over to, or only slaves with replica priority 0
+		return r.rollRedisStatefulSet(el, masterIndex, "master")
+	}
+	Info(log, "switchover to "+target+" before rolling the master "+masterPod.Name, el.Redis)
+	if el.Redis.Annotations == nil {
+		el.Redis.Annotations = map[string]string{}
+	}
+	el.Redis.Annotations[componentv1.SwitchoverAnnotation] = target
+	if err := r.updateRedis(el); err != nil {
+		return el, err
+	}
+	r.RedisHandler.RecordEvent(el.Redis, EventReasonRollingUpdate, "switching over the master "+podDesc(masterPod.Name, masterPod.Ip)+" to "+target+" before rolling it", nil)
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New("switching over the master "+masterPod.Name+" to "+target+" before rolling it"))
+	return el, nil
+}
+
+func (r *RedisReconciler) rollRedisStatefulSet(el element.Element, index int, role string) (element.Element, error) {
+	statefulSetName := util.GetRedisNameByIndex(el.Redis, index)
+	el, err := r.RedisHandler.Ensurer.UpdateRedisStatefulSetByIndex(el, index)
+	r.RedisHandler.RecordEvent(el.Redis, EventReasonRollingUpdate, "rolling the "+role+" StatefulSet "+statefulSetName, err)
+	if err != nil {
+		return el, err
+	}
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New("rolling the "+role+" StatefulSet "+statefulSetName))
+	return el, nil
+}
+
+// getRollingSwitchoverTarget returns the first running slave, all of them run the new template
+// once only the master is left. The slaves with replica priority 0 are skipped, Switchover rejects them
+func (r *RedisReconciler) getRollingSwitchoverTarget(el element.Element, redisPods []redis_client.RedisParam, masterPod redis_client.RedisParam) string {
+	for i := 0; i < int(el.Redis.Spec.Redis.Replicas); i++ {
+		name := getRedisPodNameByIndex(el, i)
+		if name == masterPod.Name || util.GetRedisSlavePriorityByIndex(el.Redis, i) == 0 {
+			continue
+		}
+		for _, redisPod := range redisPods {
+			if redisPod.Name == name {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func getRedisPodNameByIndex(el element.Element, index int) string {
+	return util.GetRedisNameByIndex(el.Redis, index) + "-0"
+}
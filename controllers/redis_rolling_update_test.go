@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestGetRollingSwitchoverTarget(t *testing.T) {
+	rf := &componentv1.Redis{ObjectMeta: metav1.ObjectMeta{Namespace: "redis-system", Name: "redis-sample"}}
+	rf.Spec.Redis.Replicas = 3
+	el := element.Element{Redis: rf}
+	redisPods := []redis_client.RedisParam{
+		{Name: "redis-redis-sample-0-0"},
+		{Name: "redis-redis-sample-1-0"},
+		{Name: "redis-redis-sample-2-0"},
+	}
+	master := redisPods[0]
+	r := &RedisReconciler{}
+
+	if target := r.getRollingSwitchoverTarget(el, redisPods, master); target != "redis-redis-sample-1-0" {
+		t.Fatalf("target = %q; expected redis-redis-sample-1-0", target)
+	}
+
+	// the sentinels never promote a redis with priority 0
+	rf.Spec.Redis.ReplicaPriorities = []componentv1.ReplicaPriority{{Index: 1, Priority: 0}}
+	if target := r.getRollingSwitchoverTarget(el, redisPods, master); target != "redis-redis-sample-2-0" {
+		t.Fatalf("target = %q; expected redis-redis-sample-2-0", target)
+	}
+
+	rf.Spec.Redis.ReplicaPriorities = append(rf.Spec.Redis.ReplicaPriorities, componentv1.ReplicaPriority{Index: 2, Priority: 0})
+	if target := r.getRollingSwitchoverTarget(el, redisPods, master); target != "" {
+		t.Fatalf("target = %q; expected none", target)
+	}
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+)
+
+// --- ScaleDown ---
+func (r *RedisReconciler) ScaleDown(el element.Element) (element.Element, error) {
+	if el.Redis.IsClusterMode() {
+		return el, nil
+	}
+
+	// The master is never removed, sentinel fails over to a redis that stays first
+	// The StatefulSets beyond the spec are deleted, and we wait until their pods are gone
+	// The sentinels are reset so that they forget the removed pods
+	// The ConfigMap, headless Service and PVC of the removed indexes are deleted
+
+	err := util.NilError()
+	el, err = r.scaleDownRedis(el)
+	if err != nil {
+		return el, err
+	}
+	if len(el.NeedReCheckError) > 0 {
+		return el, nil
+	}
+
+	el, err = r.scaleDownSentinel(el)
+	if err != nil {
+		return el, err
+	}
+
+	return el, nil
+}
+
+func (r *RedisReconciler) scaleDownRedis(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "scaleDownRedis")
+
+	indexes, err := r.RedisHandler.Checker.GetRedisScaleDownIndexes(el)
+	if err != nil {
+		return el, err
+	}
+	if len(indexes) == 0 {
+		return el, nil
+	}
+	Info(log, fmt.Sprintf("redis scale down, indexes to remove: %v", indexes), el.Redis)
+
+	removedPods := make(map[string]bool)
+	for _, index := range indexes {
+		removedPods[util.GetRedisNameByIndex(el.Redis, index)+"-0"] = true
+	}
+
+	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
+	if err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("no single master, wait before removing redis: "+err.Error()))
+		Info(log, "no single master, wait before removing redis", el.Redis)
+		return el, nil
+	}
+
+	if removedPods[masterPod.Name] {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("master "+masterPod.Name+" is going to be removed, failover"))
+		Info(log, "master "+masterPod.Name+" is going to be removed, failover", el.Redis)
+
+		// the new master must be one of the redis that stay
+		redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+		if err != nil {
+			return el, err
+		}
+		for _, redisPod := range redisPods {
+			if removedPods[redisPod.Name] && redisPod.Name != masterPod.Name {
+				err := r.RedisHandler.Healer.DisableRedisPromotion(redisPod, el.Redis)
+				r.RedisHandler.RecordEvent(el.Redis, EventReasonPromotionDisabled, "redis "+podDesc(redisPod.Name, redisPod.Ip)+" is going to be removed, disabled its promotion", err)
+				if err != nil {
+					return el, err
+				}
+			}
+		}
+
+		sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
+		if err != nil {
+			return el, err
+		}
+		if len(sentinels) == 0 {
+			return el, errors.New("no running sentinel to fail over the master " + masterPod.Name)
+		}
+		err = r.RedisHandler.Healer.SentinelFailover(sentinels[0], el.Redis)
+		el.Snapshot.ForgetTopology()
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonFailover, "master "+podDesc(masterPod.Name, masterPod.Ip)+" is going to be removed, failed over through sentinel "+podDesc(sentinels[0].Name, sentinels[0].Ip), err)
+		if err != nil {
+			return el, err
+		}
+		incFailovers(el.Redis, failoverSentinelFailover)
+		return el, nil
+	}
+
+	deletedIndexes := make([]int, 0)
+	for _, index := range indexes {
+		el, err = r.RedisHandler.DeleteEnsurer.DeleteEnsureRedisStatefulSetByIndex(el, index)
+		if err != nil {
+			return el, err
+		}
+		labels := util.GetRedisLabelsWithName(el.Redis, util.GetRedisNameByIndex(el.Redis, index))
+		if err := r.RedisHandler.Checker.CheckScaleDownPodsDeleted(el, labels); err != nil {
+			el.NeedReCheckError = append(el.NeedReCheckError, err)
+			Info(log, err.Error()+", wait", el.Redis)
+			continue
+		}
+		deletedIndexes = append(deletedIndexes, index)
+	}
+	if len(deletedIndexes) == 0 {
+		return el, nil
+	}
+
+	el, err = r.resetSentinels(el)
+	if err != nil {
+		return el, err
+	}
+
+	for _, index := range deletedIndexes {
+		el, err = r.RedisHandler.DeleteEnsurer.DeleteEnsureRedisResourcesByIndex(el, index)
+		if err != nil {
+			return el, err
+		}
+	}
+
+	return el, nil
+}
+
+func (r *RedisReconciler) scaleDownSentinel(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "scaleDownSentinel")
+
+	indexes, err := r.RedisHandler.Checker.GetSentinelScaleDownIndexes(el)
+	if err != nil {
+		return el, err
+	}
+	if len(indexes) == 0 {
+		return el, nil
+	}
+	Info(log, fmt.Sprintf("sentinel scale down, indexes to remove: %v", indexes), el.Redis)
+
+	deletedIndexes := make([]int, 0)
+	for _, index := range indexes {
+		el, err = r.RedisHandler.DeleteEnsurer.DeleteEnsureSentinelStatefulSetByIndex(el, index)
+		if err != nil {
+			return el, err
+		}
+		labels := util.GetSentinelLabelsWithName(el.Redis, util.GetSentinelNameByIndex(el.Redis, index))
+		if err := r.RedisHandler.Checker.CheckScaleDownPodsDeleted(el, labels); err != nil {
+			el.NeedReCheckError = append(el.NeedReCheckError, err)
+			Info(log, err.Error()+", wait", el.Redis)
+			continue
+		}
+		deletedIndexes = append(deletedIndexes, index)
+	}
+	if len(deletedIndexes) == 0 {
+		return el, nil
+	}
+
+	el, err = r.resetSentinels(el)
+	if err != nil {
+		return el, err
+	}
+
+	for _, index := range deletedIndexes {
+		el, err = r.RedisHandler.DeleteEnsurer.DeleteEnsureSentinelResourcesByIndex(el, index)
+		if err != nil {
+			return el, err
+		}
+	}
+
+	return el, nil
+}
+
+// resetSentinels makes every sentinel forget the redis and sentinels it knows,
+// they discover the ones that are still there again from the master
+func (r *RedisReconciler) resetSentinels(el element.Element) (element.Element, error) {
+	sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, sip := range sentinels {
+		err := r.RedisHandler.Healer.RestoreSentinel(sip)
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonSentinelReset, "scaled down, reset sentinel "+podDesc(sip.Name, sip.Ip), err)
+		if err != nil {
+			return el, err
+		}
+		incSentinelResets(el.Redis)
+	}
+	return el, nil
+}
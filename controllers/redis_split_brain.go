@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strconv"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+// --- resolveSplitBrain ---
+// more than one redis is a master. With componentv1.SplitBrainAuto the master chosen by the checker is kept
+// and the others are made its slaves, otherwise they are only reported
+func (r *RedisReconciler) resolveSplitBrain(el element.Element, nMasters int) (element.Element, error) {
+	log := r.Log.WithValues("controller", "resolveSplitBrain")
+
+	if el.Redis.Spec.SplitBrain.Policy != componentv1.SplitBrainAuto {
+		Info(log, "More than one master, fix manually", el.Redis)
+		r.RedisHandler.RecordWarning(el.Redis, EventReasonMultipleMasters, strconv.Itoa(nMasters)+" masters found, fix manually")
+		el.SetCondition(componentv1.ConditionMasterElected, metav1.ConditionFalse, util.ReasonMultipleMasters, strconv.Itoa(nMasters)+" masters found, fix manually")
+		return el, nil
+	}
+	// the old master is still a master for a while after the sentinels promote the target
+	if el.Redis.Status.Switchover.IsSwitchingOver() {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New(strconv.Itoa(nMasters)+" masters found during the switchover, wait"))
+		el.SetCondition(componentv1.ConditionMasterElected, metav1.ConditionFalse, util.ReasonMultipleMasters, strconv.Itoa(nMasters)+" masters found during the switchover")
+		return el, nil
+	}
+
+	splitBrain, err := r.RedisHandler.Checker.GetSplitBrain(el)
+	if err != nil {
+		return el, err
+	}
+	message := strconv.Itoa(nMasters) + " masters found, keep " + podDesc(splitBrain.Master.Name, splitBrain.Master.Ip) + " " + splitBrain.Reason
+	Info(log, message, el.Redis)
+	r.RedisHandler.RecordWarning(el.Redis, EventReasonMultipleMasters, message)
+	el.SetCondition(componentv1.ConditionMasterElected, metav1.ConditionFalse, util.ReasonSplitBrainResolved, message)
+
+	for _, demoted := range splitBrain.Demoted {
+		snapshot, err := r.RedisHandler.Healer.DemoteMaster(demoted, splitBrain.Master.Ip, el.Redis)
+		el.Snapshot.ForgetTopology()
+		eventMessage := "made the master " + podDesc(demoted.Name, demoted.Ip) + " a slave of " + podDesc(splitBrain.Master.Name, splitBrain.Master.Ip)
+		if snapshot != "" {
+			eventMessage += ", its dataset is saved to " + snapshot
+		}
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonMasterDemoted, eventMessage, err)
+		if err != nil {
+			return el, err
+		}
+		incFailovers(el.Redis, failoverSplitBrain)
+	}
+	if err := r.labelRedisRoles(el, splitBrain.Master); err != nil {
+		return el, err
+	}
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New(message))
+	return el, nil
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+const (
+	// switchoverTimeout is how long a switchover waits for the target to catch up and for the sentinels to promote it
+	switchoverTimeout = 5 * time.Minute
+)
+
+// --- Switchover ---
+// a planned switchover of the master to the redis pod of componentv1.SwitchoverAnnotation. It waits for the
+// target to catch up with the master, disables the promotion of the other slaves so that SENTINEL FAILOVER
+// promotes the target, and completes when the slaves replicate it and all the sentinels monitor it
+func (r *RedisReconciler) Switchover(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "Switchover")
+
+	currentStatus := *el.Redis.Status.Switchover.DeepCopy()
+	if !currentStatus.IsSwitchingOver() {
+		if err := r.restoreRedisPromotion(el); err != nil {
+			return el, err
+		}
+		target := el.Redis.Annotations[componentv1.SwitchoverAnnotation]
+		if target == "" {
+			return el, nil
+		}
+		Info(log, "switchover to "+target+" requested", el.Redis)
+		return r.startSwitchover(el, target)
+	}
+
+	if currentStatus.StartTime != nil && time.Since(currentStatus.StartTime.Time) > switchoverTimeout {
+		return r.finishSwitchover(el, componentv1.SwitchoverFailed, "timeout after "+switchoverTimeout.String()+" in phase "+string(currentStatus.Phase))
+	}
+	if currentStatus.Phase == componentv1.SwitchoverPending {
+		return r.failoverToSwitchoverTarget(el)
+	}
+	return r.checkSwitchoverCompleted(el)
+}
+
+func (r *RedisReconciler) startSwitchover(el element.Element, target string) (element.Element, error) {
+	currentStatus := componentv1.SwitchoverState{
+		Target:    target,
+		StartTime: &metav1.Time{Time: time.Now()},
+	}
+
+	if el.Redis.IsClusterMode() {
+		el.Redis.Status.Switchover = currentStatus
+		return r.finishSwitchover(el, componentv1.SwitchoverFailed, "switchover is not supported in cluster mode")
+	}
+
+	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
+	if err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("no single master, wait before switching over: "+err.Error()))
+		return el, nil
+	}
+	currentStatus.PreviousMaster = masterPod.Name
+
+	el.Redis.Status.Switchover = currentStatus
+	if masterPod.Name == target {
+		return r.finishSwitchover(el, componentv1.SwitchoverCompleted, target+" is already the master")
+	}
+	targetPod, err := r.getSwitchoverTargetPod(el, target)
+	if err != nil {
+		return el, err
+	}
+	if targetPod == nil {
+		return r.finishSwitchover(el, componentv1.SwitchoverFailed, target+" is not a running redis pod of the instance")
+	}
+	if index, ok := util.GetRedisIndexFromPodName(el.Redis, target); ok && util.GetRedisSlavePriorityByIndex(el.Redis, index) == 0 {
+		return r.finishSwitchover(el, componentv1.SwitchoverFailed, target+" has the replica priority 0 of Spec.Redis.ReplicaPriorities, the sentinels never promote it")
+	}
+
+	currentStatus.Phase = componentv1.SwitchoverPending
+	currentStatus.Message = "waiting for " + target + " to catch up with the master " + masterPod.Name
+	el.Redis.Status.Switchover = currentStatus
+	r.RedisHandler.RecordEvent(el.Redis, EventReasonSwitchoverStarted, "switching over the master from "+podDesc(masterPod.Name, masterPod.Ip)+" to "+podDesc(targetPod.Name, targetPod.Ip), nil)
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New(currentStatus.Message))
+	return el, nil
+}
+
+// failoverToSwitchoverTarget fails over through a sentinel once the target has caught up with the master
+func (r *RedisReconciler) failoverToSwitchoverTarget(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "failoverToSwitchoverTarget")
+
+	currentStatus := *el.Redis.Status.Switchover.DeepCopy()
+
+	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
+	if err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("no single master, wait before switching over: "+err.Error()))
+		return el, nil
+	}
+	if masterPod.Name == currentStatus.Target {
+		// e.g. an automatic failover has promoted it
+		return r.checkSwitchoverCompleted(el)
+	}
+	targetPod, err := r.getSwitchoverTargetPod(el, currentStatus.Target)
+	if err != nil {
+		return el, err
+	}
+	if targetPod == nil {
+		return r.finishSwitchover(el, componentv1.SwitchoverFailed, currentStatus.Target+" is not a running redis pod of the instance")
+	}
+
+	if err := r.RedisHandler.Checker.CheckSwitchoverTarget(masterPod, *targetPod); err != nil {
+		Info(log, err.Error()+", wait", el.Redis)
+		el.NeedReCheckError = append(el.NeedReCheckError, err)
+		if currentStatus.Message != err.Error() {
+			currentStatus.Message = err.Error()
+			el.Redis.Status.Switchover = currentStatus
+		}
+		return el, nil
+	}
+
+	// only the target may be promoted
+	redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, redisPod := range redisPods {
+		if redisPod.Name == masterPod.Name || redisPod.Name == targetPod.Name {
+			continue
+		}
+		if err := r.RedisHandler.Healer.DisableRedisPromotion(redisPod, el.Redis); err != nil {
+			return el, err
+		}
+	}
+	if err := r.RedisHandler.Healer.EnableRedisPromotion(*targetPod, el.Redis); err != nil {
+		return el, err
+	}
+
+	sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
+	if err != nil {
+		return el, err
+	}
+	if len(sentinels) == 0 {
+		return el, errors.New("no running sentinel to fail over the master " + masterPod.Name)
+	}
+	err = r.RedisHandler.Healer.SentinelFailover(sentinels[0], el.Redis)
+	el.Snapshot.ForgetTopology()
+	r.RedisHandler.RecordEvent(el.Redis, EventReasonSwitchover, targetPod.Name+" has caught up, failed over the master "+podDesc(masterPod.Name, masterPod.Ip)+" to "+podDesc(targetPod.Name, targetPod.Ip)+" through sentinel "+podDesc(sentinels[0].Name, sentinels[0].Ip), err)
+	if err != nil {
+		return el, err
+	}
+	incFailovers(el.Redis, failoverSwitchover)
+
+	currentStatus.Phase = componentv1.SwitchoverFailingOver
+	currentStatus.Message = "waiting for the sentinels to promote " + targetPod.Name
+	el.Redis.Status.Switchover = currentStatus
+	el.NeedReCheckError = append(el.NeedReCheckError, errors.New(currentStatus.Message))
+	return el, nil
+}
+
+// checkSwitchoverCompleted completes the switchover when the target is the only master, the slaves replicate it
+// and all the sentinels monitor it
+func (r *RedisReconciler) checkSwitchoverCompleted(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "checkSwitchoverCompleted")
+
+	target := el.Redis.Status.Switchover.Target
+
+	masterPod, err := r.RedisHandler.Checker.GetMasterPod(el)
+	if err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("no single master during the switchover: "+err.Error()))
+		Info(log, "no single master during the switchover, wait", el.Redis)
+		return el, nil
+	}
+	if masterPod.Name != target {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("the master is still "+masterPod.Name+", wait for "+target))
+		Info(log, "the master is still "+masterPod.Name+", wait for "+target, el.Redis)
+		return el, nil
+	}
+	if err := r.RedisHandler.Checker.CheckAllSlavesFromMaster(masterPod, el); err != nil {
+		el.NeedReCheckError = append(el.NeedReCheckError, errors.New("not all slaves replicate "+target+" yet"))
+		Info(log, "not all slaves replicate "+target+" yet, wait", el.Redis)
+		return el, nil
+	}
+
+	sentinels, err := r.RedisHandler.Checker.GetSentinelsPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, sip := range sentinels {
+		if err := r.RedisHandler.Checker.CheckSentinelMonitor(el, sip, masterPod.Ip); err != nil {
+			el.NeedReCheckError = append(el.NeedReCheckError, errors.New("sentinel "+sip.Name+" does not monitor "+target+" yet"))
+			Info(log, "sentinel "+sip.Name+" does not monitor "+target+" yet, wait", el.Redis)
+			return el, nil
+		}
+	}
+
+	return r.finishSwitchover(el, componentv1.SwitchoverCompleted, target+" is the master, all the sentinels monitor it")
+}
+
+// finishSwitchover restores the promotion of the slaves, records the result and removes the annotation
+func (r *RedisReconciler) finishSwitchover(el element.Element, phase componentv1.SwitchoverPhase, message string) (element.Element, error) {
+	currentStatus := *el.Redis.Status.Switchover.DeepCopy()
+
+	if currentStatus.IsSwitchingOver() {
+		redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+		if err != nil {
+			return el, err
+		}
+		for _, redisPod := range redisPods {
+			if err := r.RedisHandler.Healer.EnableRedisPromotion(redisPod, el.Redis); err != nil {
+				return el, err
+			}
+		}
+	}
+
+	currentStatus.Phase = phase
+	currentStatus.Message = message
+	currentStatus.CompletionTime = &metav1.Time{Time: time.Now()}
+	el.Redis.Status.Switchover = currentStatus
+	if phase == componentv1.SwitchoverCompleted {
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonSwitchoverCompleted, message, nil)
+	} else {
+		r.RedisHandler.RecordWarning(el.Redis, EventReasonSwitchoverFailed, message)
+	}
+
+	if el.Redis.Annotations[componentv1.SwitchoverAnnotation] == currentStatus.Target {
+		delete(el.Redis.Annotations, componentv1.SwitchoverAnnotation)
+		if err := r.updateRedis(el); err != nil {
+			return el, err
+		}
+	}
+	return el, nil
+}
+
+// restoreRedisPromotion sets the replica priority of the spec back on the slaves which have another one while
+// no switchover is in progress, e.g. the priority 0 of a switchover whose phase was lost by a failed status patch
+func (r *RedisReconciler) restoreRedisPromotion(el element.Element) error {
+	if el.Redis.IsClusterMode() {
+		return nil
+	}
+	topology, err := r.RedisHandler.Checker.GetTopology(el)
+	if err != nil {
+		return err
+	}
+	for _, redisPod := range getPromotionsToRestore(el.Redis, topology) {
+		err := r.RedisHandler.Healer.EnableRedisPromotion(redisPod, el.Redis)
+		r.RedisHandler.RecordEvent(el.Redis, EventReasonPromotionEnabled, "redis "+podDesc(redisPod.Name, redisPod.Ip)+" has another replica priority than the spec, restored it", err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPromotionsToRestore returns the slaves whose replica priority is not the one of the spec, except the ones
+// beyond Spec.Redis.Replicas, ScaleDown disables their promotion before removing them
+func getPromotionsToRestore(rf *componentv1.Redis, topology *element.Topology) []redis_client.RedisParam {
+	redisPods := make([]redis_client.RedisParam, 0)
+	for _, node := range topology.Redises {
+		if node.Err != nil || node.IsMaster() || node.SlavePriority < 0 {
+			continue
+		}
+		index, ok := util.GetRedisIndexFromPodName(rf, node.Pod.Name)
+		if !ok || index >= int(rf.Spec.Redis.Replicas) {
+			continue
+		}
+		if int(node.SlavePriority) != util.GetRedisSlavePriorityByIndex(rf, index) {
+			redisPods = append(redisPods, node.Pod)
+		}
+	}
+	return redisPods
+}
+
+// getSwitchoverTargetPod returns nil if the target is not a running redis pod of the instance
+func (r *RedisReconciler) getSwitchoverTargetPod(el element.Element, target string) (*redis_client.RedisParam, error) {
+	redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return nil, err
+	}
+	for _, redisPod := range redisPods {
+		if redisPod.Name == target {
+			return &redisPod, nil
+		}
+	}
+	return nil, nil
+}
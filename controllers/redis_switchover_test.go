@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestGetPromotionsToRestore(t *testing.T) {
+	rf := &componentv1.Redis{ObjectMeta: metav1.ObjectMeta{Namespace: "redis-system", Name: "redis-sample"}}
+	rf.Spec.Redis.Replicas = 3
+	rf.Spec.Redis.ReplicaPriorities = []componentv1.ReplicaPriority{{Index: 2, Priority: 0}}
+	node := func(name, role string, priority int64) element.RedisNode {
+		return element.RedisNode{Pod: redis_client.RedisParam{Name: name}, Role: role, SlavePriority: priority}
+	}
+	topology := &element.Topology{Redises: []element.RedisNode{
+		node("redis-redis-sample-0-0", "master", -1),
+		// left by a switchover whose phase was not persisted
+		node("redis-redis-sample-1-0", "slave", 0),
+		// the priority 0 of Spec.Redis.ReplicaPriorities
+		node("redis-redis-sample-2-0", "slave", 0),
+		// going to be removed by ScaleDown
+		node("redis-redis-sample-3-0", "slave", 0),
+	}}
+
+	redisPods := getPromotionsToRestore(rf, topology)
+	if len(redisPods) != 1 || redisPods[0].Name != "redis-redis-sample-1-0" {
+		t.Fatalf("redisPods = %+v; expected redis-redis-sample-1-0", redisPods)
+	}
+
+	topology.Redises[1].SlavePriority = 50
+	if redisPods := getPromotionsToRestore(rf, topology); len(redisPods) != 0 {
+		t.Fatalf("redisPods = %+v; expected none once the priority is restored", redisPods)
+	}
+}
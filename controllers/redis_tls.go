@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+// tlsReloadWindow is how long the redis pods reload the certificates after the Secret of Spec.TLS changed,
+// longer than the kubelet takes to update a mounted Secret, its sync period plus its cache ttl
+const tlsReloadWindow = 3 * time.Minute
+
+// --- CheckAndReloadTLS ---
+// records the md5 of the Secret of Spec.TLS in Status.TLS, when it changes the redis pods reload the
+// certificates at every reconcile during tlsReloadWindow, the sentinels, which can not reload them, are
+// rolled by the Ensure through util.TLSRotatedAtAnnotation
+func (r *RedisReconciler) CheckAndReloadTLS(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("controller", "CheckAndReloadTLS")
+
+	if !el.Redis.IsTLSEnabled() {
+		return el, nil
+	}
+
+	secret, err := k8s.GetTLSSecret(r.RedisHandler.K8sServices, el.Redis)
+	if err != nil {
+		return el, err
+	}
+	certMd5 := util.GetTLSCertMd5(secret)
+
+	previousStatus := el.Redis.Status.TLS
+	if previousStatus.CertMd5 != certMd5 {
+		currentStatus := componentv1.TLSState{
+			CertMd5:      certMd5,
+			RotationTime: previousStatus.RotationTime,
+		}
+		// the pods loaded the certificates of the first md5 when they started
+		if previousStatus.CertMd5 != "" {
+			now := metav1.Now()
+			currentStatus.RotationTime = &now
+			Info(log, "the certificates of "+el.Redis.Spec.TLS.SecretName+" changed", el.Redis)
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonTLSCertRotated, "the certificates of secret "+el.Redis.Spec.TLS.SecretName+" changed, reloading them", nil)
+		}
+		el.Redis.Status.TLS = currentStatus
+		if currentStatus.RotationTime == nil {
+			return el, nil
+		}
+		previousStatus = currentStatus
+	}
+
+	if previousStatus.RotationTime == nil || time.Since(previousStatus.RotationTime.Time) > tlsReloadWindow {
+		return el, nil
+	}
+
+	redisPods, err := r.RedisHandler.Checker.GetRedisPods(el)
+	if err != nil {
+		return el, err
+	}
+	for _, redisPod := range redisPods {
+		if err := r.RedisHandler.Healer.ReloadRedisTLS(redisPod, el.Redis); err != nil {
+			r.RedisHandler.RecordEvent(el.Redis, EventReasonTLSCertReloaded, "reload the certificates on "+podDesc(redisPod.Name, redisPod.Ip), err)
+			incRollouts(el.Redis, rolloutTLSCerts, err)
+			return el, err
+		}
+	}
+	incRollouts(el.Redis, rolloutTLSCerts, nil)
+	return el, nil
+}
+
+// tlsReloadRequeueAfter polls the Redis while the certificates are reloaded, the kubelet updating the
+// mounted Secret does not trigger a reconcile
+func tlsReloadRequeueAfter(rf *componentv1.Redis) time.Duration {
+	if !rf.IsTLSEnabled() || rf.Status.TLS.RotationTime == nil || time.Since(rf.Status.TLS.RotationTime.Time) > tlsReloadWindow {
+		return 0
+	}
+	return NormalRequeueAfter
+}
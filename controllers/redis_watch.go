@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+// secretIndex indexes the Redis by the names of the Secrets they reference: spec.auth.secretPath, the
+// keySecret of spec.auth.password, the secrets of the ACL users and the one of spec.tls
+const secretIndex = "spec.secretNames"
+
+func (r *RedisReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &componentv1.Redis{}, secretIndex, redisSecretNames); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		// the status patched by the reconcile, e.g. the conditions or the bucketed replication lag of the pods in
+		// status.state, does not trigger another one
+		For(&componentv1.Redis{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Service{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(mapPodToRedis)).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToRedis)).
+		Complete(r)
+}
+
+// redisSecretNames returns the values of secretIndex of a Redis
+func redisSecretNames(obj client.Object) []string {
+	rf, ok := obj.(*componentv1.Redis)
+	if !ok {
+		return nil
+	}
+	var names []string
+	if rf.Spec.Auth.SecretPath != "" {
+		names = append(names, rf.Spec.Auth.SecretPath)
+	}
+	if keySecret := rf.Spec.Auth.Password.KeySecret; keySecret != nil && keySecret.Name != "" {
+		names = append(names, keySecret.Name)
+	}
+	for _, user := range rf.Spec.Auth.Users {
+		if user.PasswordSecret.Name != "" {
+			names = append(names, user.PasswordSecret.Name)
+		}
+	}
+	if rf.IsTLSEnabled() && rf.Spec.TLS.SecretName != "" {
+		names = append(names, rf.Spec.TLS.SecretName)
+	}
+	return names
+}
+
+// mapPodToRedis reconciles the Redis of a redis, sentinel or exporter pod, e.g. when a master dies, by the
+// labels util.GenerateSelectorLabels sets
+func mapPodToRedis(obj client.Object) []reconcile.Request {
+	name, ok := util.GetRedisNameByLabels(obj.GetLabels())
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}},
+	}
+}
+
+// mapSecretToRedis reconciles the Redis referencing a Secret, so that a rotated password is applied at once
+func (r *RedisReconciler) mapSecretToRedis(obj client.Object) []reconcile.Request {
+	redisList := &componentv1.RedisList{}
+	if err := r.List(context.Background(), redisList, client.InNamespace(obj.GetNamespace()), client.MatchingFields{secretIndex: obj.GetName()}); err != nil {
+		r.Log.Error(err, "list the Redis of secret "+obj.GetNamespace()+"/"+obj.GetName()+" error!")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(redisList.Items))
+	for _, rf := range redisList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: rf.Namespace, Name: rf.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestRedisSecretNames(t *testing.T) {
+	rf := &componentv1.Redis{}
+	if names := redisSecretNames(rf); len(names) != 0 {
+		t.Fatalf("redisSecretNames = %v; expected none", names)
+	}
+
+	rf.Spec.Auth.SecretPath = "redis-auth"
+	rf.Spec.Auth.Password.KeySecret = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "redis-keys"}, Key: "aes-gcm"}
+	rf.Spec.Auth.Users = []componentv1.ACLUser{
+		{Name: "app", PasswordSecret: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "redis-users"}, Key: "app"}},
+	}
+	rf.Spec.TLS = componentv1.TLSSettings{Enabled: true, SecretName: "redis-tls"}
+
+	expected := []string{"redis-auth", "redis-keys", "redis-users", "redis-tls"}
+	if names := redisSecretNames(rf); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("redisSecretNames = %v; expected %v", names, expected)
+	}
+}
+
+func TestMapPodToRedis(t *testing.T) {
+	rf := &componentv1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "redis-sample", Namespace: "redis-system"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "redis-redis-sample-0-0",
+		Namespace: "redis-system",
+		Labels:    util.GenerateSelectorLabels("redis", rf),
+	}}
+
+	requests := mapPodToRedis(pod)
+	if len(requests) != 1 || requests[0].Namespace != "redis-system" || requests[0].Name != "redis-sample" {
+		t.Fatalf("mapPodToRedis = %v; expected redis-system/redis-sample", requests)
+	}
+
+	pod.Labels = map[string]string{"app.kubernetes.io/name": "redis-sample"}
+	if requests := mapPodToRedis(pod); len(requests) != 0 {
+		t.Fatalf("mapPodToRedis of a pod of another app = %v; expected none", requests)
+	}
+}
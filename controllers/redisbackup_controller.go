@@ -0,0 +1,279 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	"github.com/zhizuqiu/redis-operator/controllers/service/backup"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sync"
+	"time"
+
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+)
+
+const (
+	// maxBackupRecords is the number of the records kept in the status when Spec.Retention.Keep is 0
+	maxBackupRecords = 10
+	// maxMissedSchedules stops counting the missed schedules, e.g. after the operator was down for a long time
+	maxMissedSchedules = 100
+)
+
+// BackupPollInterval is how often a backup running in the background is polled
+var BackupPollInterval = 10 * time.Second
+
+// backupRun is a backup running in the background, record and err are set when done is closed, finished when
+// they are written to the status
+type backupRun struct {
+	done     chan struct{}
+	record   componentv1.BackupRecord
+	err      error
+	finished bool
+}
+
+// RedisBackupReconciler reconciles a RedisBackup object
+type RedisBackupReconciler struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	Backuper    backup.Backup
+	K8sServices k8s.Services
+	Now         func() time.Time
+
+	// runs are the backups running in the background by RedisBackup, a run is removed once its result is
+	// in the status
+	runsMu sync.Mutex
+	runs   map[types.NamespacedName]*backupRun
+}
+
+// +kubebuilder:rbac:groups=component.zhizuqiu,resources=redisbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=component.zhizuqiu,resources=redisbackups/status,verbs=get;update;patch
+
+func (r *RedisBackupReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("controller", "RedisBackup")
+	Info2(log, "----------------------", req)
+
+	redisBackup := &componentv1.RedisBackup{}
+	if err := r.Get(context.Background(), req.NamespacedName, redisBackup); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.deleteRun(req.NamespacedName)
+			log.Info("RedisBackup resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get RedisBackup.")
+		return ctrl.Result{}, err
+	}
+
+	if run := r.getRun(req.NamespacedName); run != nil {
+		select {
+		case <-run.done:
+		default:
+			return ctrl.Result{RequeueAfter: BackupPollInterval}, nil
+		}
+		if redisBackup.Status.Phase == componentv1.BackupRunning {
+			// the run is kept until the cache has the result, a one-shot backup still Running would start again
+			if !run.finished {
+				if err := r.finish(log, redisBackup, run); err != nil {
+					log.Error(err, "RedisBackup update status error!", "nameSpace", req.Namespace, "name", req.Name)
+					return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+				}
+				run.finished = true
+			}
+			return ctrl.Result{RequeueAfter: BackupPollInterval}, nil
+		}
+		r.deleteRun(req.NamespacedName)
+	}
+
+	if err := redisBackup.Check(); err != nil {
+		// wait for the next update of the spec
+		return ctrl.Result{}, r.updateFailed(redisBackup, err)
+	}
+
+	now := r.Now()
+	scheduledTime := now
+	var nextTime time.Time
+	if redisBackup.Spec.Schedule == "" {
+		// a one-shot backup runs again only if the operator stopped while it was running
+		if redisBackup.Status.Phase != "" && redisBackup.Status.Phase != componentv1.BackupRunning {
+			return ctrl.Result{}, nil
+		}
+	} else {
+		schedule, err := cron.ParseStandard(redisBackup.Spec.Schedule)
+		if err != nil {
+			return ctrl.Result{}, r.updateFailed(redisBackup, err)
+		}
+		var missedTime time.Time
+		missedTime, nextTime = getBackupScheduleTimes(redisBackup, schedule, now)
+		if missedTime.IsZero() {
+			return ctrl.Result{RequeueAfter: nextTime.Sub(now)}, nil
+		}
+		scheduledTime = missedTime
+	}
+
+	if redisBackup.Spec.Suspend {
+		log.Info("RedisBackup is suspended", "nameSpace", req.Namespace, "name", req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.start(log, redisBackup, scheduledTime); err != nil {
+		log.Error(err, "RedisBackup update status error!", "nameSpace", req.Namespace, "name", req.Name)
+		return ctrl.Result{RequeueAfter: ErrorRequeueAfter}, nil
+	}
+	return ctrl.Result{RequeueAfter: BackupPollInterval}, nil
+}
+
+// start sets the status to Running and backs up the Redis in the background, the BGSAVE, the copy and the upload
+// take minutes and would block the worker. The error returned is the one of updating the status
+func (r *RedisBackupReconciler) start(log logr.Logger, redisBackup *componentv1.RedisBackup, scheduledTime time.Time) error {
+	err := r.updateStatus(redisBackup, func(status *componentv1.RedisBackupStatus) {
+		status.Phase = componentv1.BackupRunning
+		status.Message = ""
+		status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+	})
+	if err != nil {
+		return err
+	}
+
+	run := &backupRun{done: make(chan struct{})}
+	r.runsMu.Lock()
+	if r.runs == nil {
+		r.runs = make(map[types.NamespacedName]*backupRun)
+	}
+	r.runs[types.NamespacedName{Namespace: redisBackup.Namespace, Name: redisBackup.Name}] = run
+	r.runsMu.Unlock()
+
+	go func() {
+		defer close(run.done)
+		run.record, run.err = r.backup(log, redisBackup.DeepCopy())
+	}()
+	return nil
+}
+
+// finish records the result of the run in the status, the error of the backup is in Status.Message. The error
+// returned is the one of updating the status
+func (r *RedisBackupReconciler) finish(log logr.Logger, redisBackup *componentv1.RedisBackup, run *backupRun) error {
+	if run.err != nil {
+		log.Error(run.err, "RedisBackup error!", "nameSpace", redisBackup.Namespace, "name", redisBackup.Name)
+		return r.updateFailed(redisBackup, run.err)
+	}
+	record := run.record
+
+	maxRecords := maxBackupRecords
+	if redisBackup.Spec.Retention.Keep > 0 {
+		maxRecords = int(redisBackup.Spec.Retention.Keep)
+	}
+	return r.updateStatus(redisBackup, func(status *componentv1.RedisBackupStatus) {
+		status.Phase = componentv1.BackupCompleted
+		status.Message = ""
+		status.LastSuccessfulTime = record.CompletionTime
+		status.Backups = append([]componentv1.BackupRecord{record}, status.Backups...)
+		if len(status.Backups) > maxRecords {
+			status.Backups = status.Backups[:maxRecords]
+		}
+	})
+}
+
+func (r *RedisBackupReconciler) backup(log logr.Logger, redisBackup *componentv1.RedisBackup) (componentv1.BackupRecord, error) {
+	redis, err := r.K8sServices.Get(ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: redisBackup.Namespace,
+			Name:      redisBackup.Spec.RedisName,
+		},
+	})
+	if err != nil {
+		return componentv1.BackupRecord{}, err
+	}
+
+	redisPod, err := r.Backuper.SelectPod(redisBackup, redis)
+	if err != nil {
+		return componentv1.BackupRecord{}, err
+	}
+
+	log.Info("start backup", "nameSpace", redisBackup.Namespace, "name", redisBackup.Name, "pod", redisPod.Name)
+	return r.Backuper.Run(redisBackup, redisPod)
+}
+
+func (r *RedisBackupReconciler) getRun(name types.NamespacedName) *backupRun {
+	r.runsMu.Lock()
+	defer r.runsMu.Unlock()
+	return r.runs[name]
+}
+
+func (r *RedisBackupReconciler) deleteRun(name types.NamespacedName) {
+	r.runsMu.Lock()
+	defer r.runsMu.Unlock()
+	delete(r.runs, name)
+}
+
+func (r *RedisBackupReconciler) updateFailed(redisBackup *componentv1.RedisBackup, err error) error {
+	return r.updateStatus(redisBackup, func(status *componentv1.RedisBackupStatus) {
+		status.Phase = componentv1.BackupFailed
+		status.Message = err.Error()
+	})
+}
+
+// updateStatus applies the change to the latest RedisBackup, the spec may have been updated during a backup
+func (r *RedisBackupReconciler) updateStatus(redisBackup *componentv1.RedisBackup, change func(status *componentv1.RedisBackupStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &componentv1.RedisBackup{}
+		if err := r.Get(context.Background(), types.NamespacedName{Namespace: redisBackup.Namespace, Name: redisBackup.Name}, latest); err != nil {
+			return err
+		}
+		change(&latest.Status)
+		return r.Status().Update(context.Background(), latest)
+	})
+}
+
+// getBackupScheduleTimes returns the latest schedule time missed since the last run, zero if there
+// is none, and the next schedule time after now
+func getBackupScheduleTimes(redisBackup *componentv1.RedisBackup, schedule cron.Schedule, now time.Time) (time.Time, time.Time) {
+	earliestTime := redisBackup.CreationTimestamp.Time
+	if redisBackup.Status.LastScheduleTime != nil {
+		earliestTime = redisBackup.Status.LastScheduleTime.Time
+	}
+
+	var missedTime time.Time
+	t := schedule.Next(earliestTime)
+	for i := 0; !t.After(now); i++ {
+		missedTime = t
+		if i >= maxMissedSchedules {
+			// only the latest one runs, skip the others
+			missedTime = now
+			break
+		}
+		t = schedule.Next(t)
+	}
+	return missedTime, schedule.Next(now)
+}
+
+func (r *RedisBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Now == nil {
+		r.Now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&componentv1.RedisBackup{}).
+		Complete(r)
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	componentv1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+func TestGetBackupScheduleTimes(t *testing.T) {
+	schedule, err := cron.ParseStandard("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2021, 1, 1, 0, 30, 0, 0, time.UTC)
+	at := func(hour, min int) time.Time {
+		return time.Date(2021, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	var scheduleTests = []struct {
+		lastSchedule   *time.Time
+		now            time.Time
+		expectedMissed time.Time
+		expectedNext   time.Time
+	}{
+		// not yet the first schedule
+		{nil, at(0, 45), time.Time{}, at(1, 0)},
+		// the first schedule
+		{nil, at(1, 0), at(1, 0), at(2, 0)},
+		// the operator was down, only the latest missed one runs
+		{nil, at(3, 10), at(3, 0), at(4, 0)},
+		// already run
+		{timeP(at(3, 0)), at(3, 10), time.Time{}, at(4, 0)},
+	}
+
+	for _, tt := range scheduleTests {
+		redisBackup := &componentv1.RedisBackup{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: created}},
+		}
+		if tt.lastSchedule != nil {
+			redisBackup.Status.LastScheduleTime = &metav1.Time{Time: *tt.lastSchedule}
+		}
+		missed, next := getBackupScheduleTimes(redisBackup, schedule, tt.now)
+		if !missed.Equal(tt.expectedMissed) || !next.Equal(tt.expectedNext) {
+			t.Errorf("getBackupScheduleTimes(now=%s) = %s, %s; expected %s, %s", tt.now, missed, next, tt.expectedMissed, tt.expectedNext)
+		}
+	}
+
+	// too many missed schedules
+	redisBackup := &componentv1.RedisBackup{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: created}},
+	}
+	now := created.Add(1000 * time.Hour).Add(10 * time.Minute)
+	if missed, _ := getBackupScheduleTimes(redisBackup, schedule, now); !missed.Equal(now) {
+		t.Errorf("getBackupScheduleTimes() after %d missed schedules = %s; expected %s", 1000, missed, now)
+	}
+}
+
+func timeP(t time.Time) *time.Time {
+	return &t
+}
+
+type redisK8sService struct {
+	k8s.Services
+}
+
+func (s redisK8sService) Get(req ctrl.Request) (*componentv1.Redis, error) {
+	return &componentv1.Redis{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}, nil
+}
+
+// blockingBackuper runs until release is closed
+type blockingBackuper struct {
+	release chan struct{}
+	runs    int
+}
+
+func (b *blockingBackuper) SelectPod(backup *componentv1.RedisBackup, rf *componentv1.Redis) (redis_client.RedisParam, error) {
+	return redis_client.RedisParam{Name: "redis-redis-sample-1-0"}, nil
+}
+
+func (b *blockingBackuper) Run(backup *componentv1.RedisBackup, redisPod redis_client.RedisParam) (componentv1.BackupRecord, error) {
+	b.runs++
+	<-b.release
+	return componentv1.BackupRecord{Key: "redis-sample/dump.rdb", Pod: redisPod.Name}, nil
+}
+
+func TestRedisBackupReconcileInBackground(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := componentv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	redisBackup := &componentv1.RedisBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup-sample", Namespace: "default"}}
+	redisBackup.Spec.RedisName = "redis-sample"
+	redisBackup.Spec.Storage.S3 = componentv1.S3Storage{Endpoint: "http://minio:9000", Bucket: "backups", SecretName: "minio"}
+	backuper := &blockingBackuper{release: make(chan struct{})}
+	r := &RedisBackupReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).WithObjects(redisBackup).Build(),
+		Log:         logr.Discard(),
+		Backuper:    backuper,
+		K8sServices: redisK8sService{},
+		Now:         time.Now,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "backup-sample"}}
+	get := func() *componentv1.RedisBackup {
+		latest := &componentv1.RedisBackup{}
+		if err := r.Get(context.Background(), req.NamespacedName, latest); err != nil {
+			t.Fatal(err)
+		}
+		return latest
+	}
+
+	// the reconcile returns while the backup is running, and polls it
+	for i := 0; i < 2; i++ {
+		result, err := r.Reconcile(context.Background(), req)
+		if err != nil || result.RequeueAfter != BackupPollInterval {
+			t.Fatalf("Reconcile = %+v, %v; expected to poll the backup", result, err)
+		}
+		if phase := get().Status.Phase; phase != componentv1.BackupRunning {
+			t.Fatalf("phase = %s; expected Running", phase)
+		}
+	}
+
+	close(backuper.release)
+	<-r.getRun(req.NamespacedName).done
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	latest := get()
+	if latest.Status.Phase != componentv1.BackupCompleted || len(latest.Status.Backups) != 1 {
+		t.Fatalf("status = %+v; expected the record of the backup", latest.Status)
+	}
+
+	// the one-shot backup does not run again
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if backuper.runs != 1 || r.getRun(req.NamespacedName) != nil || len(get().Status.Backups) != 1 {
+		t.Fatalf("runs = %d; expected the backup to run once", backuper.runs)
+	}
+}
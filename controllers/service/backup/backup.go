@@ -0,0 +1,334 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-logr/logr"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/exec"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the files are written to the dir of redis.conf, see redisConfigTemplate
+	rdbFilePath = "/data/dump.rdb"
+	aofFilePath = "/data/appendonly.aof"
+
+	keyTimeFormat = "20060102T150405Z"
+
+	accessKeyField = "accessKey"
+	secretKeyField = "secretKey"
+)
+
+var (
+	PollInterval = 2 * time.Second
+	// PersistenceTimeout is how long BGSAVE or BGREWRITEAOF may run before the backup fails
+	PersistenceTimeout = 30 * time.Minute
+)
+
+type Backup interface {
+	SelectPod(backup *roav1.RedisBackup, rf *roav1.Redis) (redis_client.RedisParam, error)
+	Run(backup *roav1.RedisBackup, redisPod redis_client.RedisParam) (roav1.BackupRecord, error)
+}
+
+type RedisBackuper struct {
+	K8sService  k8s.Services
+	RedisClient redis_client.RedisClient
+	Execer      exec.IExec
+	Log         logr.Logger
+}
+
+func NewRedisBackuper(k8sService k8s.Services, redisClient redis_client.RedisClient, execer exec.IExec, log logr.Logger) *RedisBackuper {
+	log = log.WithValues("backup", "RedisBackuper")
+	return &RedisBackuper{
+		K8sService:  k8sService,
+		RedisClient: redisClient,
+		Execer:      execer,
+		Log:         log,
+	}
+}
+
+// SelectPod returns Spec.PodName, or the first running replica by name. The master is only
+// returned when Spec.AllowMaster is set, since the fork of BGSAVE may add latency to the writes
+func (b *RedisBackuper) SelectPod(backup *roav1.RedisBackup, rf *roav1.Redis) (redis_client.RedisParam, error) {
+	if rf.IsClusterMode() {
+		return redis_client.RedisParam{}, errors.New("backup of Spec.Mode=cluster is not supported")
+	}
+
+	podList, err := b.K8sService.ListPods(rf.Namespace, util.GetRedisLabels(rf))
+	if err != nil {
+		return redis_client.RedisParam{}, err
+	}
+	pods := make([]corev1.Pod, 0)
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	var master *redis_client.RedisParam
+	for _, pod := range pods {
+		if backup.Spec.PodName != "" && pod.Name != backup.Spec.PodName {
+			continue
+		}
+		redisPod := redis_client.RedisParam{
+			Ip:            pod.Status.PodIP,
+			NameSpace:     pod.Namespace,
+			Name:          pod.Name,
+			ContainerName: util.GetContainerNameFromLabel(pod),
+		}
+		password, err := b.RedisClient.GetRedisPassword(redisPod)
+		if err != nil {
+			return redis_client.RedisParam{}, err
+		}
+		isMaster, err := b.RedisClient.IsMaster(redisPod, password)
+		if err != nil {
+			return redis_client.RedisParam{}, err
+		}
+		if !isMaster {
+			return redisPod, nil
+		}
+		master = &redisPod
+	}
+
+	if master != nil {
+		if backup.Spec.AllowMaster {
+			return *master, nil
+		}
+		return redis_client.RedisParam{}, fmt.Errorf("%s is the master, set Spec.AllowMaster to back up the master", master.Name)
+	}
+	if backup.Spec.PodName != "" {
+		return redis_client.RedisParam{}, fmt.Errorf("pod %s is not a running redis pod of %s", backup.Spec.PodName, rf.Name)
+	}
+	return redis_client.RedisParam{}, errors.New("no running redis pod")
+}
+
+// Run persists the data on the pod, copies the file out of the pod and uploads it,
+// then deletes the oldest backups beyond Spec.Retention.Keep
+func (b *RedisBackuper) Run(backup *roav1.RedisBackup, redisPod redis_client.RedisParam) (roav1.BackupRecord, error) {
+	startTime := time.Now()
+
+	s3, err := NewS3ClientFromStorage(b.K8sService, backup.Namespace, backup.Spec.Storage.S3)
+	if err != nil {
+		return roav1.BackupRecord{}, err
+	}
+
+	password, err := b.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return roav1.BackupRecord{}, err
+	}
+
+	filePath := rdbFilePath
+	if backup.GetType() == roav1.AOFBackup {
+		filePath = aofFilePath
+		err = b.rewriteAof(redisPod, password)
+	} else {
+		err = b.bgSave(redisPod, password)
+	}
+	if err != nil {
+		return roav1.BackupRecord{}, err
+	}
+
+	file, err := ioutil.TempFile("", "redis-backup-")
+	if err != nil {
+		return roav1.BackupRecord{}, err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	size, checksum, err := b.copyFromPod(redisPod, filePath, file)
+	if err != nil {
+		return roav1.BackupRecord{}, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return roav1.BackupRecord{}, err
+	}
+
+	key := GetBackupKey(backup, redisPod.Name, startTime)
+	b.Log.Info("upload backup", "nameSpace", backup.Namespace, "name", backup.Name, "key", key, "size", size)
+	if err := s3.PutObject(key, file, size, checksum); err != nil {
+		return roav1.BackupRecord{}, err
+	}
+
+	if err := ApplyRetention(s3, GetBackupKeyPrefix(backup), backup.Spec.Retention.Keep); err != nil {
+		// the backup is uploaded, the oldest ones are deleted by the next run
+		b.Log.Error(err, "ApplyRetention error!", "nameSpace", backup.Namespace, "name", backup.Name)
+	}
+
+	completionTime := time.Now()
+	return roav1.BackupRecord{
+		Key:            key,
+		Pod:            redisPod.Name,
+		Size:           size,
+		Checksum:       "sha256:" + checksum,
+		Duration:       metav1.Duration{Duration: completionTime.Sub(startTime).Round(time.Millisecond)},
+		StartTime:      &metav1.Time{Time: startTime},
+		CompletionTime: &metav1.Time{Time: completionTime},
+	}, nil
+}
+
+// NewS3ClientFromStorage returns the client of the storage, the keys are read from the secret in the namespace
+func NewS3ClientFromStorage(k8sService k8s.Services, namespace string, storage roav1.S3Storage) (*S3Client, error) {
+	secret, err := k8sService.GetSecret(namespace, storage.SecretName)
+	if err != nil {
+		return nil, err
+	}
+	accessKey, ok := secret.Data[accessKeyField]
+	if !ok {
+		return nil, fmt.Errorf("secret \"%s\" does not have a %s field", storage.SecretName, accessKeyField)
+	}
+	secretKey, ok := secret.Data[secretKeyField]
+	if !ok {
+		return nil, fmt.Errorf("secret \"%s\" does not have a %s field", storage.SecretName, secretKeyField)
+	}
+	return NewS3Client(storage.Endpoint, storage.Region, storage.Bucket, string(accessKey), string(secretKey))
+}
+
+// bgSave runs BGSAVE and waits until rdb_bgsave_in_progress is 0. BGSAVE only replies "Background saving started"
+// when it forks the save, another one in progress is an error, so the first save finished after the reply is
+// the one started. rdb_last_save_time is in seconds, it does not change after a save in the same second as the
+// previous one, e.g. of a full sync
+func (b *RedisBackuper) bgSave(redisPod redis_client.RedisParam, password string) error {
+	if err := b.RedisClient.BgSave(redisPod, password); err != nil {
+		return err
+	}
+
+	return b.waitPersistence(redisPod, password, func(info map[string]string) (bool, error) {
+		if info["rdb_bgsave_in_progress"] != "0" {
+			return false, nil
+		}
+		if info["rdb_last_bgsave_status"] != "ok" {
+			return false, errors.New("BGSAVE failed, rdb_last_bgsave_status:" + info["rdb_last_bgsave_status"])
+		}
+		return true, nil
+	})
+}
+
+// rewriteAof runs BGREWRITEAOF and waits until the rewrite is neither in progress nor scheduled
+func (b *RedisBackuper) rewriteAof(redisPod redis_client.RedisParam, password string) error {
+	info, err := b.RedisClient.GetPersistenceInfo(redisPod, password)
+	if err != nil {
+		return err
+	}
+	if info["aof_enabled"] != "1" {
+		return errors.New("appendonly is not enabled on " + redisPod.Name)
+	}
+
+	if err := b.RedisClient.BgRewriteAof(redisPod, password); err != nil {
+		return err
+	}
+
+	return b.waitPersistence(redisPod, password, func(info map[string]string) (bool, error) {
+		if info["aof_rewrite_in_progress"] != "0" || info["aof_rewrite_scheduled"] != "0" {
+			return false, nil
+		}
+		if info["aof_last_bgrewrite_status"] != "ok" {
+			return false, errors.New("BGREWRITEAOF failed, aof_last_bgrewrite_status:" + info["aof_last_bgrewrite_status"])
+		}
+		return true, nil
+	})
+}
+
+func (b *RedisBackuper) waitPersistence(redisPod redis_client.RedisParam, password string, done func(info map[string]string) (bool, error)) error {
+	deadline := time.Now().Add(PersistenceTimeout)
+	for {
+		time.Sleep(PollInterval)
+
+		info, err := b.RedisClient.GetPersistenceInfo(redisPod, password)
+		if err != nil {
+			return err
+		}
+		ok, err := done(info)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout after %s waiting for the persistence of %s", PersistenceTimeout, redisPod.Name)
+		}
+	}
+}
+
+// copyFromPod streams the file out of the pod to w, it returns the size and the hex sha256 of the file
+func (b *RedisBackuper) copyFromPod(redisPod redis_client.RedisParam, filePath string, w io.Writer) (int64, string, error) {
+	hash := sha256.New()
+	counter := &countWriter{}
+	stderr, err := b.Execer.ExecCommandInContainerToWriter(redisPod.NameSpace, redisPod.Name, redisPod.ContainerName, io.MultiWriter(w, hash, counter), "cat", filePath)
+	if err != nil {
+		if stderr != "" {
+			return 0, "", fmt.Errorf("%s: %s", err.Error(), stderr)
+		}
+		return 0, "", err
+	}
+	if counter.n == 0 {
+		return 0, "", errors.New(filePath + " is empty on " + redisPod.Name)
+	}
+	return counter.n, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// GetBackupKeyPrefix returns the "directory" of the backups of a RedisBackup, <prefix>/<namespace>/<name>/
+func GetBackupKeyPrefix(backup *roav1.RedisBackup) string {
+	prefix := strings.Trim(backup.Spec.Storage.S3.Prefix, "/")
+	return path.Join(prefix, backup.Namespace, backup.Name) + "/"
+}
+
+// GetBackupKey returns the key of a backup file, the keys of a RedisBackup sort by time
+func GetBackupKey(backup *roav1.RedisBackup, podName string, t time.Time) string {
+	return GetBackupKeyPrefix(backup) + t.UTC().Format(keyTimeFormat) + "-" + podName + "." + string(backup.GetType())
+}
+
+// ApplyRetention keeps the newest keep objects under the prefix and deletes the others, keep 0 keeps all of them
+func ApplyRetention(s3 *S3Client, prefix string, keep int32) error {
+	if keep <= 0 {
+		return nil
+	}
+	objects, err := s3.ListObjects(prefix)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		// skip the objects in the sub directories, they are not written by this RedisBackup
+		if !strings.Contains(strings.TrimPrefix(object.Key, prefix), "/") {
+			keys = append(keys, object.Key)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	for i := int(keep); i < len(keys); i++ {
+		if err := s3.DeleteObject(keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"fmt"
+	"github.com/go-logr/logr"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+	"testing"
+	"time"
+)
+
+type podsK8sService struct {
+	k8s.Services
+	pods []corev1.Pod
+}
+
+func (s podsK8sService) ListPods(namespace string, labels map[string]string) (*corev1.PodList, error) {
+	return &corev1.PodList{Items: s.pods}, nil
+}
+
+// execRedisClient rejects an exec without a container name like the api server does for a pod with sidecars
+type execRedisClient struct {
+	redis_client.RedisClient
+	containers []string
+	master     string
+}
+
+func (c execRedisClient) exec(redisParam redis_client.RedisParam) error {
+	if redisParam.ContainerName == "" {
+		return fmt.Errorf("a container name must be specified for pod %s, choose one of: %v", redisParam.Name, c.containers)
+	}
+	return nil
+}
+
+func (c execRedisClient) GetRedisPassword(redisParam redis_client.RedisParam) (string, error) {
+	return "", c.exec(redisParam)
+}
+
+func (c execRedisClient) IsMaster(redisParam redis_client.RedisParam, password string) (bool, error) {
+	return redisParam.Name == c.master, c.exec(redisParam)
+}
+
+func TestSelectPodWithSidecar(t *testing.T) {
+	rf := &roav1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "redis-sample", Namespace: "default"}}
+	containers := []corev1.Container{{Name: "redis"}, {Name: "log-agent"}}
+	var pods []corev1.Pod
+	for _, name := range []string{"redis-redis-sample-0-0", "redis-redis-sample-1-0"} {
+		pods = append(pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rf.Namespace, Labels: util.GetRedisLabels(rf)},
+			Spec:       corev1.PodSpec{Containers: containers},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		})
+	}
+	b := NewRedisBackuper(
+		podsK8sService{pods: pods},
+		execRedisClient{containers: []string{"redis", "log-agent"}, master: "redis-redis-sample-0-0"},
+		nil,
+		logr.Discard(),
+	)
+
+	redisPod, err := b.SelectPod(&roav1.RedisBackup{}, rf)
+	if err != nil {
+		t.Fatalf("SelectPod error: %s", err)
+	}
+	if redisPod.Name != "redis-redis-sample-1-0" || redisPod.ContainerName != "redis" {
+		t.Fatalf("pod = %s, container = %s; expected the container redis of the replica", redisPod.Name, redisPod.ContainerName)
+	}
+}
+
+// persistenceRedisClient returns the INFO persistence in turn, the last one once they are all returned
+type persistenceRedisClient struct {
+	redis_client.RedisClient
+	infos []map[string]string
+	calls int
+}
+
+func (c *persistenceRedisClient) BgSave(redisParam redis_client.RedisParam, password string) error {
+	return nil
+}
+
+func (c *persistenceRedisClient) GetPersistenceInfo(redisParam redis_client.RedisParam, password string) (map[string]string, error) {
+	info := c.infos[c.calls]
+	if c.calls < len(c.infos)-1 {
+		c.calls++
+	}
+	return info, nil
+}
+
+func TestBgSave(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		PollInterval, PersistenceTimeout = interval, timeout
+	}(PollInterval, PersistenceTimeout)
+	PollInterval, PersistenceTimeout = time.Millisecond, 50*time.Millisecond
+
+	// the save finishes in the same second as the previous one, rdb_last_save_time does not change
+	redisClient := &persistenceRedisClient{infos: []map[string]string{
+		{"rdb_bgsave_in_progress": "1", "rdb_last_save_time": "1700000000", "rdb_last_bgsave_status": "ok"},
+		{"rdb_bgsave_in_progress": "0", "rdb_last_save_time": "1700000000", "rdb_last_bgsave_status": "ok"},
+	}}
+	b := NewRedisBackuper(nil, redisClient, nil, logr.Discard())
+	if err := b.bgSave(redis_client.RedisParam{Name: "redis-redis-sample-1-0"}, ""); err != nil {
+		t.Fatalf("bgSave error: %s", err)
+	}
+
+	redisClient = &persistenceRedisClient{infos: []map[string]string{
+		{"rdb_bgsave_in_progress": "0", "rdb_last_bgsave_status": "err"},
+	}}
+	b = NewRedisBackuper(nil, redisClient, nil, logr.Discard())
+	if err := b.bgSave(redis_client.RedisParam{Name: "redis-redis-sample-1-0"}, ""); err == nil || !strings.Contains(err.Error(), "rdb_last_bgsave_status:err") {
+		t.Fatalf("bgSave err = %v; expected the failed save", err)
+	}
+}
@@ -0,0 +1,312 @@
+package backup
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3AmzDateFormat = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	// s3EmptySha256 is the sha256 of an empty body, used by the requests without payload
+	s3EmptySha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// s3UnsignedPayload is the payload hash of the presigned urls
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3Object is an object returned by ListObjects
+type S3Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// S3Client is a minimal client of the S3 API, it signs the requests with the signature version 4
+// and addresses the objects with the path style (<endpoint>/<bucket>/<key>), which is what MinIO
+// and the other S3 compatible stores support without extra configuration
+type S3Client struct {
+	Endpoint   *url.URL
+	Region     string
+	Bucket     string
+	AccessKey  string
+	SecretKey  string
+	HTTPClient *http.Client
+	Now        func() time.Time
+}
+
+func NewS3Client(endpoint, region, bucket, accessKey, secretKey string) (*S3Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("s3 endpoint %q must start with http:// or https://", endpoint)
+	}
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	return &S3Client{
+		Endpoint:   u,
+		Region:     region,
+		Bucket:     bucket,
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		HTTPClient: http.DefaultClient,
+		Now:        time.Now,
+	}, nil
+}
+
+// PutObject uploads the body with its size and the hex sha256, which S3 checks against the payload
+func (c *S3Client) PutObject(key string, body io.Reader, size int64, sha256Hex string) error {
+	req, err := c.newRequest(http.MethodPut, key, nil, body, sha256Hex)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	_, err = c.do(req)
+	return err
+}
+
+func (c *S3Client) DeleteObject(key string) error {
+	req, err := c.newRequest(http.MethodDelete, key, nil, nil, s3EmptySha256)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req)
+	return err
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// ListObjects returns all the objects with the prefix, it follows the continuation token of ListObjectsV2
+func (c *S3Client) ListObjects(prefix string) ([]S3Object, error) {
+	objects := make([]S3Object, 0)
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := c.newRequest(http.MethodGet, "", query, nil, s3EmptySha256)
+		if err != nil {
+			return nil, err
+		}
+		body, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+		result := listBucketResult{}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, S3Object{
+				Key:          content.Key,
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *S3Client) newRequest(method, key string, query url.Values, body io.Reader, sha256Hex string) (*http.Request, error) {
+	u := *c.Endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	c.sign(req, sha256Hex)
+	return req, nil
+}
+
+func (c *S3Client) do(req *http.Request) ([]byte, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, parseS3Error(req, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func parseS3Error(req *http.Request, statusCode int, body []byte) error {
+	e := s3Error{}
+	if err := xml.Unmarshal(body, &e); err != nil || e.Code == "" {
+		return fmt.Errorf("s3 %s %s: status %d", req.Method, req.URL.Path, statusCode)
+	}
+	return fmt.Errorf("s3 %s %s: status %d, %s: %s", req.Method, req.URL.Path, statusCode, e.Code, e.Message)
+}
+
+// sign adds the Authorization header of the signature version 4, the signed headers are
+// host, x-amz-content-sha256 and x-amz-date
+func (c *S3Client) sign(req *http.Request, sha256Hex string) {
+	amzDate := c.Now().UTC().Format(s3AmzDateFormat)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", sha256Hex)
+
+	signedHeaders, canonicalRequest := s3CanonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.Query(), map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": sha256Hex,
+		"x-amz-date":           amzDate,
+	}, sha256Hex)
+	signature := s3Signature(c.SecretKey, c.Region, amzDate, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, c.AccessKey, s3Scope(amzDate, c.Region), signedHeaders, signature))
+}
+
+// PresignGetObject returns an url to download the object without credentials until it expires,
+// S3 accepts at most 7 days
+func (c *S3Client) PresignGetObject(key string, expires time.Duration) string {
+	amzDate := c.Now().UTC().Format(s3AmzDateFormat)
+
+	u := *c.Endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.Bucket + "/" + key
+	u.RawPath = s3EscapePath(u.Path)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", c.AccessKey+"/"+s3Scope(amzDate, c.Region))
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	_, canonicalRequest := s3CanonicalRequest(http.MethodGet, u.EscapedPath(), query, map[string]string{
+		"host": u.Host,
+	}, s3UnsignedPayload)
+	query.Set("X-Amz-Signature", s3Signature(c.SecretKey, c.Region, amzDate, canonicalRequest))
+
+	u.RawQuery = s3CanonicalQuery(query)
+	return u.String()
+}
+
+// s3CanonicalRequest returns the signed headers and the canonical request, the names of the headers are lower case
+func s3CanonicalRequest(method, escapedPath string, query url.Values, headers map[string]string, payloadHash string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		escapedPath,
+		s3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	return signedHeaders, canonicalRequest
+}
+
+func s3Scope(amzDate, region string) string {
+	return strings.Join([]string{amzDate[:len(s3DateFormat)], region, s3Service, "aws4_request"}, "/")
+}
+
+func s3Signature(secretKey, region, amzDate, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, s3Scope(amzDate, region), hex.EncodeToString(hash[:])}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+secretKey), amzDate[:len(s3DateFormat)])
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, s3Service)
+	key = hmacSha256(key, "aws4_request")
+	return hex.EncodeToString(hmacSha256(key, stringToSign))
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape encodes every byte except the unreserved characters of RFC 3986, '/' is kept when encodeSlash is false
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(strconv.FormatInt(int64(ch)|0x100, 16)[1:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,300 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3Server is a stand-in of MinIO, it serves the path style PutObject, GetObject, DeleteObject and
+// ListObjectsV2 of one bucket and rejects the requests whose signature version 4 does not match
+type fakeS3Server struct {
+	server    *httptest.Server
+	bucket    string
+	accessKey string
+	secretKey string
+	// pageSize is the max keys of a ListObjectsV2 page
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3Server(t *testing.T, bucket, accessKey, secretKey string) *fakeS3Server {
+	s := &fakeS3Server{
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pageSize:  1000,
+		objects:   make(map[string][]byte),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *fakeS3Server) client(t *testing.T, secretKey string) *S3Client {
+	c, err := NewS3Client(s.server.URL, "", s.bucket, s.accessKey, secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func (s *fakeS3Server) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *fakeS3Server) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.WriteHeader(status)
+	w.Write([]byte("<Error><Code>" + code + "</Code><Message>" + message + "</Message></Error>"))
+}
+
+func (s *fakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	presigned := r.URL.Query().Get("X-Amz-Signature") != ""
+	if presigned && !s.checkPresignedSignature(r) || !presigned && !s.checkSignature(r) {
+		s.writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.")
+		return
+	}
+	hash := sha256.Sum256(body)
+	if !presigned && r.Header.Get("x-amz-content-sha256") != hex.EncodeToString(hash[:]) {
+		s.writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.")
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != s.bucket {
+		s.writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPut && key != "":
+		s.objects[key] = body
+	case r.Method == http.MethodDelete && key != "":
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && key != "":
+		object, ok := s.objects[key]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Write(object)
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		s.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("continuation-token"))
+	default:
+		s.writeError(w, http.StatusNotImplemented, "NotImplemented", r.Method+" "+r.URL.String())
+	}
+}
+
+func (s *fakeS3Server) list(w http.ResponseWriter, prefix, token string) {
+	keys := make([]string, 0)
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) && k > token {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("<ListBucketResult>")
+	if len(keys) > s.pageSize {
+		keys = keys[:s.pageSize]
+		b.WriteString("<IsTruncated>true</IsTruncated><NextContinuationToken>" + keys[len(keys)-1] + "</NextContinuationToken>")
+	} else {
+		b.WriteString("<IsTruncated>false</IsTruncated>")
+	}
+	for _, k := range keys {
+		b.WriteString("<Contents><Key>")
+		xml.EscapeText(&b, []byte(k))
+		b.WriteString("</Key><Size>" + strconv.Itoa(len(s.objects[k])) + "</Size>")
+		b.WriteString("<LastModified>" + time.Now().UTC().Format(time.RFC3339) + "</LastModified></Contents>")
+	}
+	b.WriteString("</ListBucketResult>")
+	w.Write([]byte(b.String()))
+}
+
+func (s *fakeS3Server) checkSignature(r *http.Request) bool {
+	amzDate := r.Header.Get("x-amz-date")
+	if len(amzDate) != len(s3AmzDateFormat) {
+		return false
+	}
+	credential := s.accessKey + "/" + amzDate[:len(s3DateFormat)] + "/" + s3DefaultRegion + "/" + s3Service + "/aws4_request"
+	signedHeaders, canonicalRequest := s3CanonicalRequest(r.Method, r.URL.EscapedPath(), r.URL.Query(), map[string]string{
+		"host":                 r.Host,
+		"x-amz-content-sha256": r.Header.Get("x-amz-content-sha256"),
+		"x-amz-date":           amzDate,
+	}, r.Header.Get("x-amz-content-sha256"))
+	signature := s3Signature(s.secretKey, s3DefaultRegion, amzDate, canonicalRequest)
+	expected := s3Algorithm + " Credential=" + credential + ", SignedHeaders=" + signedHeaders + ", Signature=" + signature
+	return r.Header.Get("Authorization") == expected
+}
+
+// checkPresignedSignature checks the signature in the query and that the url has not expired
+func (s *fakeS3Server) checkPresignedSignature(r *http.Request) bool {
+	query := r.URL.Query()
+	amzDate := query.Get("X-Amz-Date")
+	date, err := time.Parse(s3AmzDateFormat, amzDate)
+	if err != nil || query.Get("X-Amz-Credential") != s.accessKey+"/"+s3Scope(amzDate, s3DefaultRegion) {
+		return false
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil || time.Now().After(date.Add(time.Duration(expires)*time.Second)) {
+		return false
+	}
+	signature := query.Get("X-Amz-Signature")
+	query.Del("X-Amz-Signature")
+	_, canonicalRequest := s3CanonicalRequest(r.Method, r.URL.EscapedPath(), query, map[string]string{"host": r.Host}, s3UnsignedPayload)
+	return signature == s3Signature(s.secretKey, s3DefaultRegion, amzDate, canonicalRequest)
+}
+
+func put(t *testing.T, c *S3Client, key, content string) {
+	hash := sha256.Sum256([]byte(content))
+	if err := c.PutObject(key, strings.NewReader(content), int64(len(content)), hex.EncodeToString(hash[:])); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3Client(t *testing.T) {
+	server := newFakeS3Server(t, "backup", "minio", "minio123")
+	c := server.client(t, "minio123")
+
+	put(t, c, "redis/default/sample/20210101T000000Z-rfr-redis-sample-1-0.rdb", "REDIS0009")
+	put(t, c, "redis/default/sample/key with space+plus.rdb", "REDIS0009")
+
+	objects, err := c.ListObjects("redis/default/sample/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Key != "redis/default/sample/20210101T000000Z-rfr-redis-sample-1-0.rdb" || objects[0].Size != 9 {
+		t.Errorf("ListObjects() = %+v", objects)
+	}
+
+	if err := c.DeleteObject("redis/default/sample/key with space+plus.rdb"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := server.keys(); len(keys) != 1 {
+		t.Errorf("keys after DeleteObject() = %v", keys)
+	}
+
+	if err := c.PutObject("redis/default/sample/bad.rdb", strings.NewReader("REDIS0009"), 9, s3EmptySha256); err == nil || !strings.Contains(err.Error(), "XAmzContentSHA256Mismatch") {
+		t.Errorf("PutObject() with a wrong checksum: err = %v", err)
+	}
+
+	wrong := server.client(t, "wrong")
+	if _, err := wrong.ListObjects(""); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("ListObjects() with a wrong secret key: err = %v", err)
+	}
+}
+
+func TestS3ClientListObjectsPages(t *testing.T) {
+	server := newFakeS3Server(t, "backup", "minio", "minio123")
+	server.pageSize = 2
+	c := server.client(t, "minio123")
+
+	for i := 0; i < 5; i++ {
+		put(t, c, "p/"+strconv.Itoa(i), "x")
+	}
+	objects, err := c.ListObjects("p/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 5 {
+		t.Errorf("len(ListObjects()) = %d; expected 5", len(objects))
+	}
+}
+
+func TestS3ClientPresignGetObject(t *testing.T) {
+	server := newFakeS3Server(t, "backup", "minio", "minio123")
+	c := server.client(t, "minio123")
+	put(t, c, "redis/default/sample/20210101T000000Z-rfr-redis-sample-1-0.rdb", "REDIS0009")
+
+	get := func(u string) (int, string) {
+		resp, err := http.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	u := c.PresignGetObject("redis/default/sample/20210101T000000Z-rfr-redis-sample-1-0.rdb", time.Hour)
+	if status, body := get(u); status != http.StatusOK || body != "REDIS0009" {
+		t.Errorf("GET presigned url = %d %s", status, body)
+	}
+
+	expired := server.client(t, "minio123")
+	expired.Now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	u = expired.PresignGetObject("redis/default/sample/20210101T000000Z-rfr-redis-sample-1-0.rdb", time.Hour)
+	if status, _ := get(u); status != http.StatusForbidden {
+		t.Errorf("GET expired presigned url = %d; expected %d", status, http.StatusForbidden)
+	}
+
+	wrong := server.client(t, "wrong")
+	u = wrong.PresignGetObject("redis/default/sample/20210101T000000Z-rfr-redis-sample-1-0.rdb", time.Hour)
+	if status, _ := get(u); status != http.StatusForbidden {
+		t.Errorf("GET presigned url with a wrong secret key = %d; expected %d", status, http.StatusForbidden)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	server := newFakeS3Server(t, "backup", "minio", "minio123")
+	c := server.client(t, "minio123")
+
+	prefix := "redis/default/sample/"
+	for _, ts := range []string{"20210101T000000Z", "20210102T000000Z", "20210103T000000Z", "20210104T000000Z"} {
+		put(t, c, prefix+ts+"-rfr-redis-sample-1-0.rdb", "REDIS0009")
+	}
+	put(t, c, prefix+"manual/20200101T000000Z.rdb", "REDIS0009")
+	put(t, c, "redis/default/sample-2/20200101T000000Z-rfr-redis-sample-2-1-0.rdb", "REDIS0009")
+
+	if err := ApplyRetention(c, prefix, 0); err != nil {
+		t.Fatal(err)
+	}
+	if keys := server.keys(); len(keys) != 6 {
+		t.Errorf("keep 0 should keep all the keys, got %v", keys)
+	}
+
+	if err := ApplyRetention(c, prefix, 2); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"redis/default/sample-2/20200101T000000Z-rfr-redis-sample-2-1-0.rdb",
+		prefix + "20210103T000000Z-rfr-redis-sample-1-0.rdb",
+		prefix + "20210104T000000Z-rfr-redis-sample-1-0.rdb",
+		prefix + "manual/20200101T000000Z.rdb",
+	}
+	if keys := server.keys(); strings.Join(keys, ",") != strings.Join(expected, ",") {
+		t.Errorf("keys after ApplyRetention() = %v; expected %v", keys, expected)
+	}
+}
@@ -0,0 +1,44 @@
+package check
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"strconv"
+)
+
+func (r RedisHealer) ClusterMeet(redisPod redis_client.RedisParam, newPod redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Making "+redisPod.Name+" meet "+newPod.Name+" with ip "+newPod.Ip, rf)
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.ClusterMeet(redisPod, password, newPod.Ip, util.GetRedisPortFromSpecByIndex(rf, 0))
+}
+
+func (r RedisHealer) ClusterAddSlots(redisPod redis_client.RedisParam, slots []int, rf *roav1.Redis) error {
+	Info(r.Log, "Adding "+strconv.Itoa(len(slots))+" slots to "+redisPod.Name, rf)
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.ClusterAddSlots(redisPod, password, slots)
+}
+
+func (r RedisHealer) ClusterReplicate(redisPod redis_client.RedisParam, masterID string, rf *roav1.Redis) error {
+	Info(r.Log, "Making "+redisPod.Name+" replica of node "+masterID, rf)
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.ClusterReplicate(redisPod, password, masterID)
+}
+
+func (r RedisHealer) ClusterForget(redisPod redis_client.RedisParam, nodeID string, rf *roav1.Redis) error {
+	Info(r.Log, "Making "+redisPod.Name+" forget node "+nodeID, rf)
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.ClusterForget(redisPod, password, nodeID)
+}
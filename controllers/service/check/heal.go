@@ -2,32 +2,49 @@ package check
 
 import (
 	"errors"
+	"fmt"
 	"github.com/go-logr/logr"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
 	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
 	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type RedisHeal interface {
 	MakeMaster(redisPod redis_client.RedisParam, rs *roav1.Redis) error
-	SetOldestAsMaster(rs *roav1.Redis) error
+	SetOldestAsMaster(rs *roav1.Redis) (redis_client.RedisParam, error)
 	SetMasterOnAll(masterIP string, rs *roav1.Redis) error
+	DemoteMaster(redisPod redis_client.RedisParam, masterIP string, rs *roav1.Redis) (string, error)
 	NewSentinelMonitor(sentinel redis_client.RedisParam, monitor string, rs *roav1.Redis) error
 	RestoreSentinel(sentinel redis_client.RedisParam) error
+	SetRedisSlavePriority(redisPod redis_client.RedisParam, rs *roav1.Redis) error
 	SetSentinelCustomConfig(sentinel redis_client.RedisParam, rs *roav1.Redis) error
 	SetRedisCustomConfig(redisPod redis_client.RedisParam, rs *roav1.Redis) error
-	UpdateRedisConfigStatus(redis *roav1.Redis, currentStatus roav1.RedisConfig) error
-	UpdateSentinelConfigStatus(redis *roav1.Redis, currentStatus roav1.SentinelConfig) error
-	UpdateRedisPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error
-	UpdatePodStatus(redis *roav1.Redis, currentStatus roav1.State) error
-	UpdateSentinelPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error
 	SetRedisPassword(redisPod redis_client.RedisParam, rs *roav1.Redis) error
 	SetSentinelPassword(redisPod redis_client.RedisParam, rs *roav1.Redis) error
+	SetRedisACLUsers(redisPod redis_client.RedisParam, users []util.ACLUserRules, deleted []string, rs *roav1.Redis) error
+	SetSentinelAuthUser(sentinel redis_client.RedisParam, rs *roav1.Redis) error
+	ReloadRedisTLS(redisPod redis_client.RedisParam, rs *roav1.Redis) error
+	SentinelFailover(sentinel redis_client.RedisParam, rs *roav1.Redis) error
+	DisableRedisPromotion(redisPod redis_client.RedisParam, rs *roav1.Redis) error
+	EnableRedisPromotion(redisPod redis_client.RedisParam, rs *roav1.Redis) error
+	ClusterMeet(redisPod redis_client.RedisParam, newPod redis_client.RedisParam, rs *roav1.Redis) error
+	ClusterAddSlots(redisPod redis_client.RedisParam, slots []int, rs *roav1.Redis) error
+	ClusterReplicate(redisPod redis_client.RedisParam, masterID string, rs *roav1.Redis) error
+	ClusterForget(redisPod redis_client.RedisParam, nodeID string, rs *roav1.Redis) error
 }
 
+var (
+	SnapshotPollInterval = time.Second
+	// SnapshotTimeout is how long the BGSAVE before a master is demoted may run
+	SnapshotTimeout = 5 * time.Minute
+)
+
 type RedisHealer struct {
 	K8sService  k8s.Services
 	RedisClient redis_client.RedisClient
@@ -52,58 +69,114 @@ func (r RedisHealer) MakeMaster(redisPod redis_client.RedisParam, rs *roav1.Redi
 	return r.RedisClient.MakeMaster(redisPod, password)
 }
 
-func (r RedisHealer) SetOldestAsMaster(rf *roav1.Redis) error {
+// SetOldestAsMaster makes the redis with the lowest lag the master, the one that has received the largest
+// offset from the lost master, the oldest one among equal offsets. Like sentinel, a lower replica priority
+// comes first and a redis with priority 0 is never promoted. It returns the new master
+func (r RedisHealer) SetOldestAsMaster(rf *roav1.Redis) (redis_client.RedisParam, error) {
+	newMaster := redis_client.RedisParam{}
 	ssp, err := r.K8sService.ListPods(rf.Namespace, util.GetRedisLabels(rf))
 	if err != nil {
-		return err
+		return newMaster, err
 	}
 	if len(ssp.Items) < 1 {
-		return errors.New("number of redis pods are 0")
+		return newMaster, errors.New("number of redis pods are 0")
 	}
 
 	// Order the pods so we start by the oldest one
 	sort.Slice(ssp.Items, func(i, j int) bool {
 		return ssp.Items[i].CreationTimestamp.Before(&ssp.Items[j].CreationTimestamp)
 	})
+	offsets := r.getReplOffsets(ssp.Items)
+	priorities := make(map[string]int)
+	for _, pod := range ssp.Items {
+		priorities[pod.Name] = getRedisSlavePriority(pod.Name, rf)
+	}
+	sort.SliceStable(ssp.Items, func(i, j int) bool {
+		pi, pj := priorities[ssp.Items[i].Name], priorities[ssp.Items[j].Name]
+		if pi != pj {
+			// 0 is the largest
+			return pj == 0 || (pi != 0 && pi < pj)
+		}
+		return offsets[ssp.Items[i].Name] > offsets[ssp.Items[j].Name]
+	})
+	if priorities[ssp.Items[0].Name] == 0 {
+		return newMaster, errors.New("the replica priority of all the redis pods is 0, none can be promoted")
+	}
 
 	newMasterIP := ""
 	for _, pod := range ssp.Items {
 		password, err := r.RedisClient.GetRedisPassword(
 			redis_client.RedisParam{
-				NameSpace: pod.Namespace,
-				Name:      pod.Name,
+				NameSpace:     pod.Namespace,
+				Name:          pod.Name,
+				ContainerName: util.GetContainerNameFromLabel(pod),
 			})
 		if err != nil {
-			return err
+			return newMaster, err
 		}
 
 		if newMasterIP == "" {
 			newMasterIP = pod.Status.PodIP
+			newMaster = redis_client.RedisParam{
+				NameSpace:     pod.Namespace,
+				Name:          pod.Name,
+				ContainerName: util.GetContainerNameFromLabel(pod),
+				Ip:            pod.Status.PodIP,
+			}
 			Info(r.Log, "New master is "+pod.Name+" with ip "+newMasterIP, rf)
 			if err := r.RedisClient.MakeMaster(
 				redis_client.RedisParam{
-					NameSpace: pod.Namespace,
-					Name:      pod.Name,
+					NameSpace:     pod.Namespace,
+					Name:          pod.Name,
+					ContainerName: util.GetContainerNameFromLabel(pod),
 				},
 				password,
 			); err != nil {
-				return err
+				return newMaster, err
 			}
 		} else {
 			Info(r.Log, "Making pod "+pod.Name+" slave of "+newMasterIP, rf)
 			if err := r.RedisClient.MakeSlaveOf(
 				redis_client.RedisParam{
-					NameSpace: pod.Namespace,
-					Name:      pod.Name,
+					NameSpace:     pod.Namespace,
+					Name:          pod.Name,
+					ContainerName: util.GetContainerNameFromLabel(pod),
 				},
 				password,
 				newMasterIP,
 			); err != nil {
-				return err
+				return newMaster, err
 			}
 		}
 	}
-	return nil
+	return newMaster, nil
+}
+
+// getReplOffsets returns the offsets the redis pods have received by pod name, -1 for a pod that does not
+// answer, so that it is not chosen as the master
+func (r RedisHealer) getReplOffsets(pods []corev1.Pod) map[string]int64 {
+	offsets := make(map[string]int64)
+	for _, pod := range pods {
+		offsets[pod.Name] = -1
+		redisPod := redis_client.RedisParam{NameSpace: pod.Namespace, Name: pod.Name, ContainerName: util.GetContainerNameFromLabel(pod)}
+		password, err := r.RedisClient.GetRedisPassword(redisPod)
+		if err != nil {
+			continue
+		}
+		info, err := r.RedisClient.GetReplicationInfo(redisPod, password)
+		if err != nil {
+			continue
+		}
+		// a slave reports slave_repl_offset, a redis that was never a slave only master_repl_offset
+		offset, ok := info["slave_repl_offset"]
+		if !ok {
+			offset = info["master_repl_offset"]
+		}
+		if n, err := strconv.ParseInt(offset, 10, 64); err == nil {
+			offsets[pod.Name] = n
+		}
+	}
+	return offsets
 }
 
 func (r RedisHealer) SetMasterOnAll(masterIP string, rf *roav1.Redis) error {
@@ -115,8 +188,9 @@ func (r RedisHealer) SetMasterOnAll(masterIP string, rf *roav1.Redis) error {
 	for _, pod := range ssp.Items {
 		password, err := r.RedisClient.GetRedisPassword(
 			redis_client.RedisParam{
-				NameSpace: pod.Namespace,
-				Name:      pod.Name,
+				NameSpace:     pod.Namespace,
+				Name:          pod.Name,
+				ContainerName: util.GetContainerNameFromLabel(pod),
 			})
 		if err != nil {
 			return err
@@ -125,8 +199,9 @@ func (r RedisHealer) SetMasterOnAll(masterIP string, rf *roav1.Redis) error {
 			Info(r.Log, "Ensure pod "+pod.Name+" is master", rf)
 			if err := r.RedisClient.MakeMaster(
 				redis_client.RedisParam{
-					NameSpace: pod.Namespace,
-					Name:      pod.Name,
+					NameSpace:     pod.Namespace,
+					Name:          pod.Name,
+					ContainerName: util.GetContainerNameFromLabel(pod),
 				},
 				password,
 			); err != nil {
@@ -136,8 +211,9 @@ func (r RedisHealer) SetMasterOnAll(masterIP string, rf *roav1.Redis) error {
 			Info(r.Log, "Making pod "+pod.Name+" slave of "+masterIP, rf)
 			if err := r.RedisClient.MakeSlaveOf(
 				redis_client.RedisParam{
-					NameSpace: pod.Namespace,
-					Name:      pod.Name,
+					NameSpace:     pod.Namespace,
+					Name:          pod.Name,
+					ContainerName: util.GetContainerNameFromLabel(pod),
 				},
 				password,
 				masterIP,
@@ -149,6 +225,86 @@ func (r RedisHealer) SetMasterOnAll(masterIP string, rf *roav1.Redis) error {
 	return nil
 }
 
+// DemoteMaster makes a redis that should not be a master a slave of masterIP. With
+// Spec.SplitBrain.SnapshotBeforeDemote its dataset is first saved to a file next to dump.rdb, since the full
+// resync replaces it, the file is returned
+func (r RedisHealer) DemoteMaster(redisPod redis_client.RedisParam, masterIP string, rf *roav1.Redis) (string, error) {
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := ""
+	if rf.Spec.SplitBrain.SnapshotBeforeDemote {
+		snapshot = "split-brain-" + time.Now().UTC().Format("20060102T150405Z") + ".rdb"
+		Info(r.Log, "Saving the dataset of redis "+redisPod.Name+" to "+snapshot+"...", rf)
+		if err := r.saveSnapshot(redisPod, password, snapshot, rf); err != nil {
+			return "", err
+		}
+	}
+
+	Info(r.Log, "Making pod "+redisPod.Name+" slave of "+masterIP, rf)
+	return snapshot, r.RedisClient.MakeSlaveOf(redisPod, password, masterIP)
+}
+
+// saveSnapshot runs BGSAVE with dbfilename set to file and waits for it, a full resync would kill the child
+func (r RedisHealer) saveSnapshot(redisPod redis_client.RedisParam, password, file string, rf *roav1.Redis) error {
+	// a save in progress, e.g. of a RedisBackup, has to finish before dbfilename changes
+	if _, err := r.waitBgSave(redisPod, password); err != nil {
+		return err
+	}
+
+	if err := r.RedisClient.SetCustomRedisConfig(redisPod, []string{"dbfilename " + file}, password); err != nil {
+		return err
+	}
+	// the child of BGSAVE has its own copy of dbfilename
+	bgSaveErr := r.RedisClient.BgSave(redisPod, password)
+	if err := r.RedisClient.SetCustomRedisConfig(redisPod, []string{"dbfilename " + getDBFileName(rf)}, password); err != nil {
+		return err
+	}
+	if bgSaveErr != nil {
+		return bgSaveErr
+	}
+
+	info, err := r.waitBgSave(redisPod, password)
+	if err != nil {
+		return err
+	}
+	if info["rdb_last_bgsave_status"] != "ok" {
+		return errors.New("BGSAVE failed, rdb_last_bgsave_status:" + info["rdb_last_bgsave_status"])
+	}
+	return nil
+}
+
+// waitBgSave waits until rdb_bgsave_in_progress is 0 for SnapshotTimeout at most
+func (r RedisHealer) waitBgSave(redisPod redis_client.RedisParam, password string) (map[string]string, error) {
+	deadline := time.Now().Add(SnapshotTimeout)
+	for {
+		info, err := r.RedisClient.GetPersistenceInfo(redisPod, password)
+		if err != nil {
+			return nil, err
+		}
+		if info["rdb_bgsave_in_progress"] == "0" {
+			return info, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout after %s waiting for BGSAVE of %s", SnapshotTimeout, redisPod.Name)
+		}
+		time.Sleep(SnapshotPollInterval)
+	}
+}
+
+// getDBFileName returns the dbfilename of Spec.Redis.CustomConfig, or the default one
+func getDBFileName(rf *roav1.Redis) string {
+	for _, config := range rf.Spec.Redis.CustomConfig {
+		fields := strings.Fields(config)
+		if len(fields) == 2 && strings.ToLower(fields[0]) == "dbfilename" {
+			return fields[1]
+		}
+	}
+	return "dump.rdb"
+}
+
 func (r RedisHealer) NewSentinelMonitor(sentinel redis_client.RedisParam, monitor string, rf *roav1.Redis) error {
 	Info(r.Log, "Sentinel is not monitoring the correct master, changing...", rf)
 	quorum := strconv.Itoa(int(util.GetQuorum(rf)))
@@ -166,40 +322,69 @@ func (r RedisHealer) RestoreSentinel(sentinel redis_client.RedisParam) error {
 	return r.RedisClient.ResetSentinel(sentinel)
 }
 
-func (r RedisHealer) SetSentinelCustomConfig(sentinel redis_client.RedisParam, rf *roav1.Redis) error {
-	Info(r.Log, "Setting the custom config on sentinel "+sentinel.Ip+"...", rf)
-	return r.RedisClient.SetCustomSentinelConfig(sentinel, rf.Spec.Sentinel.CustomConfig)
+func (r RedisHealer) SentinelFailover(sentinel redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Failing over the master through sentinel "+sentinel.Name+"...", rf)
+	return r.RedisClient.SentinelFailover(sentinel)
 }
 
-func (r RedisHealer) SetRedisCustomConfig(redisPod redis_client.RedisParam, rf *roav1.Redis) error {
-	Info(r.Log, "Setting the custom config on redis "+redisPod.Ip+"...", rf)
-
+// DisableRedisPromotion sets the replica priority of a redis to 0, so that sentinel never promotes it
+func (r RedisHealer) DisableRedisPromotion(redisPod redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Disabling the promotion of redis "+redisPod.Name+"...", rf)
 	password, err := r.RedisClient.GetRedisPassword(redisPod)
 	if err != nil {
 		return err
 	}
+	return r.RedisClient.SetCustomRedisConfig(redisPod, []string{"slave-priority 0"}, password)
+}
 
-	return r.RedisClient.SetCustomRedisConfig(redisPod, rf.Spec.Redis.CustomConfig, password)
+// EnableRedisPromotion sets the replica priority of a redis back to the one of Spec.Redis.ReplicaPriorities,
+// Spec.Redis.CustomConfig, or the default
+func (r RedisHealer) EnableRedisPromotion(redisPod redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Enabling the promotion of redis "+redisPod.Name+"...", rf)
+	return r.SetRedisSlavePriority(redisPod, rf)
 }
 
-func (r RedisHealer) UpdateRedisConfigStatus(redis *roav1.Redis, currentStatus roav1.RedisConfig) error {
-	return r.K8sService.UpdateRedisConfigStatus(redis, currentStatus)
+// SetRedisSlavePriority sets the replica priority of a redis to the one of its index
+func (r RedisHealer) SetRedisSlavePriority(redisPod redis_client.RedisParam, rf *roav1.Redis) error {
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.SetCustomRedisConfig(redisPod, []string{getRedisSlavePriorityConfig(redisPod, rf)}, password)
 }
 
-func (r RedisHealer) UpdateSentinelConfigStatus(redis *roav1.Redis, currentStatus roav1.SentinelConfig) error {
-	return r.K8sService.UpdateSentinelConfigStatus(redis, currentStatus)
+func getRedisSlavePriority(podName string, rf *roav1.Redis) int {
+	index, ok := util.GetRedisIndexFromPodName(rf, podName)
+	if !ok {
+		index = -1
+	}
+	return util.GetRedisSlavePriorityByIndex(rf, index)
 }
 
-func (r RedisHealer) UpdateRedisPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error {
-	return r.K8sService.UpdateRedisPasswordStatus(redis, currentStatus)
+func getRedisSlavePriorityConfig(redisPod redis_client.RedisParam, rf *roav1.Redis) string {
+	index, ok := util.GetRedisIndexFromPodName(rf, redisPod.Name)
+	if !ok {
+		return util.GetRedisSlavePriorityConfig(rf)
+	}
+	return util.GetRedisSlavePriorityConfigByIndex(rf, index)
 }
 
-func (r RedisHealer) UpdatePodStatus(redis *roav1.Redis, currentStatus roav1.State) error {
-	return r.K8sService.UpdatePodStatus(redis, currentStatus)
+func (r RedisHealer) SetSentinelCustomConfig(sentinel redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Setting the custom config on sentinel "+sentinel.Ip+"...", rf)
+	return r.RedisClient.SetCustomSentinelConfig(sentinel, rf.Spec.Sentinel.CustomConfig)
 }
 
-func (r RedisHealer) UpdateSentinelPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error {
-	return r.K8sService.UpdateSentinelPasswordStatus(redis, currentStatus)
+func (r RedisHealer) SetRedisCustomConfig(redisPod redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Setting the custom config on redis "+redisPod.Ip+"...", rf)
+
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+
+	// a slave-priority of Spec.Redis.CustomConfig does not override the one of Spec.Redis.ReplicaPriorities
+	configs := append(append([]string{}, rf.Spec.Redis.CustomConfig...), getRedisSlavePriorityConfig(redisPod, rf))
+	return r.RedisClient.SetCustomRedisConfig(redisPod, configs, password)
 }
 
 func (r RedisHealer) SetRedisPassword(redisPod redis_client.RedisParam, rs *roav1.Redis) error {
@@ -217,3 +402,42 @@ func (r RedisHealer) SetSentinelPassword(redisPod redis_client.RedisParam, rs *r
 	}
 	return r.RedisClient.SetSentinelPassword(redisPod, newPassword)
 }
+
+// SetRedisACLUsers creates or updates the users, deletes the deleted ones, then sets masteruser, which
+// also rewrites redis.conf so that the users survive a restart
+func (r RedisHealer) SetRedisACLUsers(redisPod redis_client.RedisParam, users []util.ACLUserRules, deleted []string, rf *roav1.Redis) error {
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		Info(r.Log, "Setting the ACL user "+user.Name+" on redis "+redisPod.Name+"...", rf)
+		if err := r.RedisClient.SetACLUser(redisPod, password, user.Name, user.Rules); err != nil {
+			return err
+		}
+	}
+	for _, user := range deleted {
+		Info(r.Log, "Deleting the ACL user "+user+" on redis "+redisPod.Name+"...", rf)
+		if err := r.RedisClient.DelACLUser(redisPod, password, user); err != nil {
+			return err
+		}
+	}
+	return r.RedisClient.SetCustomRedisConfig(redisPod, []string{"masteruser " + util.GetMasterUser(rf)}, password)
+}
+
+// SetSentinelAuthUser makes the sentinel authenticate with the user of util.GetMasterUser and the password of Spec.Auth
+func (r RedisHealer) SetSentinelAuthUser(sentinel redis_client.RedisParam, rf *roav1.Redis) error {
+	Info(r.Log, "Setting the auth-user on sentinel "+sentinel.Name+"...", rf)
+	return r.RedisClient.SetCustomSentinelConfig(sentinel, []string{"auth-user " + util.GetMasterUser(rf)})
+}
+
+// ReloadRedisTLS sets tls-cert-file to the same file, which makes redis load the certificate, the key and
+// the CA from the disk again
+func (r RedisHealer) ReloadRedisTLS(redisPod redis_client.RedisParam, rf *roav1.Redis) error {
+	password, err := r.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	Info(r.Log, "Reloading the certificates of redis "+redisPod.Name+"...", rf)
+	return r.RedisClient.SetCustomRedisConfig(redisPod, []string{"tls-cert-file " + util.GetTLSCertFile()}, password)
+}
@@ -14,37 +14,59 @@ import (
 )
 
 type RedisCheck interface {
+	Observe(el element.Element) (*element.Snapshot, error)
 	CheckRedisNumber(el element.Element) error
 	CheckSentinelNumber(el element.Element) error
 	CheckAllSlavesFromMaster(master redis_client.RedisParam, el element.Element) error
 	CheckSentinelNumberInMemory(sentinel redis_client.RedisParam, el element.Element) error
 	CheckSentinelSlavesNumberInMemory(sentinel redis_client.RedisParam, el element.Element) error
-	CheckSentinelMonitor(sentinel redis_client.RedisParam, monitor ...string) error
+	CheckSentinelMonitor(el element.Element, sentinel redis_client.RedisParam, monitor ...string) error
+	GetTopology(el element.Element) (*element.Topology, error)
+	GetSplitBrain(el element.Element) (SplitBrain, error)
+	GetReplicationStates(el element.Element) (map[string]SlaveReplication, error)
 	GetMasterPod(el element.Element) (redis_client.RedisParam, error)
+	LabelRedisRoles(el element.Element, master redis_client.RedisParam) ([]string, error)
 	GetNumberMasters(el element.Element) (int, error)
 	GetRedisPods(el element.Element) ([]redis_client.RedisParam, error)
 	GetSentinelsPods(el element.Element) ([]redis_client.RedisParam, error)
 	GetMinimumRedisPodTime(el element.Element) (time.Duration, error)
+	GetRedisScaleDownIndexes(el element.Element) ([]int, error)
+	GetSentinelScaleDownIndexes(el element.Element) ([]int, error)
+	CheckScaleDownPodsDeleted(el element.Element, labels map[string]string) error
+	CheckRedisClusterShardNumber(el element.Element) error
+	GetRedisClusterShardPods(el element.Element, index int) ([]redis_client.RedisParam, error)
+	GetClusterNodes(redisPod redis_client.RedisParam) ([]redis_client.ClusterNode, error)
+	CheckClusterSlots(redisPod redis_client.RedisParam) error
+	CheckRestoreLoaded(el element.Element) error
+	CheckSwitchoverTarget(master, target redis_client.RedisParam) error
+	GetRedisRollingIndexes(el element.Element) ([]int, bool, error)
+	CheckRedisInSync(redisPod redis_client.RedisParam) error
+	GetRedisACLUsers(redisPod redis_client.RedisParam) ([]string, error)
 }
 
 type RedisChecker struct {
 	K8sService  k8s.Services
 	RedisClient redis_client.RedisClient
 	Log         logr.Logger
+	// ProbeWorkers and ProbeTimeout bound the probe of the topology
+	ProbeWorkers int
+	ProbeTimeout time.Duration
 }
 
 func NewRedisChecker(k8sService k8s.Services, redisClient redis_client.RedisClient, log logr.Logger) *RedisChecker {
 	log = log.WithValues("check", "RedisChecker")
 	return &RedisChecker{
-		K8sService:  k8sService,
-		RedisClient: redisClient,
-		Log:         log,
+		K8sService:   k8sService,
+		RedisClient:  redisClient,
+		Log:          log,
+		ProbeWorkers: defaultProbeWorkers,
+		ProbeTimeout: defaultProbeTimeout,
 	}
 }
 
 func (rc *RedisChecker) CheckRedisNumber(el element.Element) error {
 	labels := util.GetRedisLabels(el.Redis)
-	ss, err := rc.K8sService.ListStatefulSets(el.Redis.Namespace, labels)
+	ss, err := rc.listStatefulSets(el, labels)
 	if err != nil {
 		return err
 	}
@@ -64,7 +86,7 @@ func (rc *RedisChecker) CheckRedisNumber(el element.Element) error {
 
 func (rc *RedisChecker) CheckSentinelNumber(el element.Element) error {
 	labels := util.GetSentinelLabels(el.Redis)
-	d, err := rc.K8sService.ListStatefulSets(el.Redis.Namespace, labels)
+	d, err := rc.listStatefulSets(el, labels)
 	if err != nil {
 		return err
 	}
@@ -83,86 +105,100 @@ func (rc *RedisChecker) CheckSentinelNumber(el element.Element) error {
 }
 
 func (rc *RedisChecker) CheckAllSlavesFromMaster(master redis_client.RedisParam, el element.Element) error {
-	redisPods, err := rc.GetRedisPods(el)
+	topology, err := rc.GetTopology(el)
 	if err != nil {
 		return err
 	}
 
-	for _, redisPod := range redisPods {
-		password, err := rc.RedisClient.GetRedisPassword(redisPod)
-		if err != nil {
-			return err
-		}
-		slave, err := rc.RedisClient.GetSlaveOf(redisPod, password)
-		if err != nil {
-			return err
+	for _, node := range topology.Redises {
+		if node.Err != nil {
+			return node.Err
 		}
+		slave := node.MasterHost
 		if slave != "" && slave != master.Ip {
-			return fmt.Errorf("slave %s don't have the master %s, has %s", redisPod.Name, master, slave)
+			return fmt.Errorf("slave %s don't have the master %s, has %s", node.Pod.Name, master, slave)
 		}
 	}
 	return nil
 }
 
 func (rc *RedisChecker) CheckSentinelNumberInMemory(sentinel redis_client.RedisParam, el element.Element) error {
-	nSentinels, err := rc.RedisClient.GetNumberSentinelsInMemory(sentinel)
+	node, err := rc.getReadySentinel(el, sentinel)
 	if err != nil {
 		return err
-	} else if nSentinels != el.Redis.Spec.Sentinel.Replicas {
+	} else if node.Sentinels != el.Redis.Spec.Sentinel.Replicas {
 		return errors.New("sentinels in memory mismatch")
 	}
 	return nil
 }
 
 func (rc *RedisChecker) CheckSentinelSlavesNumberInMemory(sentinel redis_client.RedisParam, el element.Element) error {
-	nSlaves, err := rc.RedisClient.GetNumberSentinelSlavesInMemory(sentinel)
+	node, err := rc.getReadySentinel(el, sentinel)
 	if err != nil {
 		return err
-	} else if nSlaves != el.Redis.Spec.Redis.Replicas-1 {
+	} else if node.Slaves != el.Redis.Spec.Redis.Replicas-1 {
 		return errors.New("redis slaves in sentinel memory mismatch")
 	}
 	return nil
 }
 
-func (rc *RedisChecker) CheckSentinelMonitor(sentinel redis_client.RedisParam, monitor ...string) error {
+// getReadySentinel returns the probed node of a sentinel whose master is ok
+func (rc *RedisChecker) getReadySentinel(el element.Element, sentinel redis_client.RedisParam) (*element.SentinelNode, error) {
+	node, err := rc.getSentinelNode(el, sentinel)
+	if err != nil {
+		return nil, err
+	}
+	if node.Status != "ok" {
+		return nil, errors.New("Sentinels not ready")
+	}
+	return node, nil
+}
+
+func (rc *RedisChecker) getSentinelNode(el element.Element, sentinel redis_client.RedisParam) (*element.SentinelNode, error) {
+	topology, err := rc.GetTopology(el)
+	if err != nil {
+		return nil, err
+	}
+	node := topology.Sentinel(sentinel.Name)
+	if node == nil {
+		return nil, fmt.Errorf("sentinel pod %s is not running", sentinel.Name)
+	}
+	if node.Err != nil {
+		return nil, node.Err
+	}
+	return node, nil
+}
+
+func (rc *RedisChecker) CheckSentinelMonitor(el element.Element, sentinel redis_client.RedisParam, monitor ...string) error {
 
 	monitorIP := monitor[0]
 	monitorPort := ""
 	if len(monitor) > 1 {
 		monitorPort = monitor[1]
 	}
-	actualMonitorIP, actualMonitorPort, err := rc.RedisClient.GetSentinelMonitor(sentinel)
+	node, err := rc.getSentinelNode(el, sentinel)
 	if err != nil {
 		return err
 	}
-	if actualMonitorIP != monitorIP || (monitorPort != "" && monitorPort != actualMonitorPort) {
+	if node.MasterHost != monitorIP || (monitorPort != "" && monitorPort != node.MasterPort) {
 		return errors.New("the monitor on the sentinel config does not match with the expected one")
 	}
 	return nil
 }
 
 func (rc *RedisChecker) GetMasterPod(el element.Element) (redis_client.RedisParam, error) {
-	redisPods, err := rc.GetRedisPods(el)
+	topology, err := rc.GetTopology(el)
 	if err != nil {
 		return redis_client.RedisParam{}, err
 	}
 
 	masterExecPods := []redis_client.RedisParam{}
-	for _, redisPod := range redisPods {
-		password, err := rc.RedisClient.GetRedisPassword(
-			redis_client.RedisParam{
-				NameSpace: redisPod.NameSpace,
-				Name:      redisPod.Name,
-			})
-		if err != nil {
-			return redis_client.RedisParam{}, err
-		}
-		master, err := rc.RedisClient.IsMaster(redisPod, password)
-		if err != nil {
-			return redis_client.RedisParam{}, err
+	for _, node := range topology.Redises {
+		if node.Err != nil {
+			return redis_client.RedisParam{}, node.Err
 		}
-		if master {
-			masterExecPods = append(masterExecPods, redisPod)
+		if node.IsMaster() {
+			masterExecPods = append(masterExecPods, node.Pod)
 		}
 	}
 
@@ -180,32 +216,26 @@ func getStatefulSetPodNames(statefulSetName string, replicas int32) []string {
 	return names
 }
 
+// GetNumberMasters counts the masters among the redis pods of the spec, which must all be running
 func (rc *RedisChecker) GetNumberMasters(el element.Element) (int, error) {
 	nMasters := 0
 
+	topology, err := rc.GetTopology(el)
+	if err != nil {
+		return nMasters, err
+	}
+
 	podNames := getStatefulSetPodNames(util.GetRedisRootName(el.Redis), el.Redis.Spec.Redis.Replicas)
 
 	for _, podName := range podNames {
-		password, err := rc.RedisClient.GetRedisPassword(
-			redis_client.RedisParam{
-				NameSpace: el.Redis.Namespace,
-				Name:      podName,
-			})
-		if err != nil {
-			return nMasters, err
+		node := topology.Redis(podName)
+		if node == nil {
+			return nMasters, fmt.Errorf("redis pod %s is not running", podName)
 		}
-
-		master, err := rc.RedisClient.IsMaster(
-			redis_client.RedisParam{
-				NameSpace: el.Redis.Namespace,
-				Name:      podName,
-			},
-			password,
-		)
-		if err != nil {
-			return nMasters, err
+		if node.Err != nil {
+			return nMasters, node.Err
 		}
-		if master {
+		if node.IsMaster() {
 			nMasters++
 		}
 	}
@@ -214,17 +244,22 @@ func (rc *RedisChecker) GetNumberMasters(el element.Element) (int, error) {
 
 func (rc *RedisChecker) GetRedisPods(el element.Element) ([]redis_client.RedisParam, error) {
 	var redises []redis_client.RedisParam
-	podList, err := rc.K8sService.ListPods(el.Redis.Namespace, util.GetRedisLabels(el.Redis))
+	podList, err := rc.listPods(el, util.GetRedisLabels(el.Redis))
 	if err != nil {
 		return nil, err
 	}
 	for _, rp := range podList.Items {
 		if rp.Status.Phase == corev1.PodRunning && rp.DeletionTimestamp == nil { // Only work with running pods
-			redises = append(redises, redis_client.RedisParam{
-				Ip:        rp.Status.PodIP,
-				NameSpace: rp.Namespace,
-				Name:      rp.Name,
-			})
+			redis := redis_client.RedisParam{
+				Ip:            rp.Status.PodIP,
+				NameSpace:     rp.Namespace,
+				Name:          rp.Name,
+				ContainerName: util.GetContainerNameFromLabel(rp),
+			}
+			if port := util.GetPort(rp); port > 0 {
+				redis.Port = strconv.Itoa(int(port))
+			}
+			redises = append(redises, redis)
 		}
 	}
 	return redises, nil
@@ -232,16 +267,17 @@ func (rc *RedisChecker) GetRedisPods(el element.Element) ([]redis_client.RedisPa
 
 func (rc *RedisChecker) GetSentinelsPods(el element.Element) ([]redis_client.RedisParam, error) {
 	sentinels := []redis_client.RedisParam{}
-	rps, err := rc.K8sService.ListPods(el.Redis.Namespace, util.GetSentinelLabels(el.Redis))
+	rps, err := rc.listPods(el, util.GetSentinelLabels(el.Redis))
 	if err != nil {
 		return nil, err
 	}
 	for _, sp := range rps.Items {
 		if sp.Status.Phase == corev1.PodRunning && sp.DeletionTimestamp == nil { // Only work with running pods
 			sentinels = append(sentinels, redis_client.RedisParam{
-				NameSpace: sp.Namespace,
-				Name:      sp.Name,
-				Ip:        sp.Status.PodIP,
+				NameSpace:     sp.Namespace,
+				Name:          sp.Name,
+				ContainerName: util.GetContainerNameFromLabel(sp),
+				Ip:            sp.Status.PodIP,
 			})
 		}
 	}
@@ -250,7 +286,7 @@ func (rc *RedisChecker) GetSentinelsPods(el element.Element) ([]redis_client.Red
 
 func (rc *RedisChecker) GetMinimumRedisPodTime(el element.Element) (time.Duration, error) {
 	minTime := 100000 * time.Hour // More than ten years
-	rps, err := rc.K8sService.ListPods(el.Redis.Namespace, util.GetRedisLabels(el.Redis))
+	rps, err := rc.listPods(el, util.GetRedisLabels(el.Redis))
 	if err != nil {
 		return minTime, err
 	}
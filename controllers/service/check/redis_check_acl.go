@@ -0,0 +1,14 @@
+package check
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+)
+
+// GetRedisACLUsers returns the names of the ACL users of the redis
+func (rc *RedisChecker) GetRedisACLUsers(redisPod redis_client.RedisParam) ([]string, error) {
+	password, err := rc.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return nil, err
+	}
+	return rc.RedisClient.GetACLUsers(redisPod, password)
+}
@@ -56,8 +56,14 @@ func (r RedisDeleteChecker) DeleteCheckSentinelPvcs(el element.Element) (element
 	if err != nil {
 		return el, err
 	}
-	if len(pvcList.Items) > 0 {
-		return el, errors.New("sentinel pvc's size = " + strconv.Itoa(len(pvcList.Items)))
+	deleting := 0
+	for _, pvc := range pvcList.Items {
+		if !util.IsSentinelPvcKeptAfterDeletion(el.Redis, pvc.Name) {
+			deleting++
+		}
+	}
+	if deleting > 0 {
+		return el, errors.New("sentinel pvc's size = " + strconv.Itoa(deleting))
 	}
 	return el, nil
 }
@@ -67,8 +73,14 @@ func (r RedisDeleteChecker) DeleteCheckRedisPvcs(el element.Element) (element.El
 	if err != nil {
 		return el, err
 	}
-	if len(pvcList.Items) > 0 {
-		return el, errors.New("redis pvc's size = " + strconv.Itoa(len(pvcList.Items)))
+	deleting := 0
+	for _, pvc := range pvcList.Items {
+		if !util.IsRedisPvcKeptAfterDeletion(el.Redis, pvc.Name) {
+			deleting++
+		}
+	}
+	if deleting > 0 {
+		return el, errors.New("redis pvc's size = " + strconv.Itoa(deleting))
 	}
 	return el, nil
 }
@@ -0,0 +1,88 @@
+package check
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"math/bits"
+)
+
+// the lag and the seconds in the status below these are not updated, a slave in sync has a few hundred bytes of
+// lag and its master_last_io_seconds_ago goes up to repl-ping-replica-period, 10 by default
+const (
+	statusLagFloor     = 1024
+	statusSecondsFloor = 16
+)
+
+// SlaveReplication is the replication of a slave, the counters, which change on every probe, are published as
+// metrics and written to the status by StatusReplicationState
+type SlaveReplication struct {
+	State roav1.ReplicationState
+	// Lag is the bytes the offset of the slave is behind the one of its master, 0 when the master is unknown
+	Lag int64
+	// LastIOSecondsAgo is the master_last_io_seconds_ago of a slave whose link is up
+	LastIOSecondsAgo int64
+	// LinkDownSinceSeconds is the master_link_down_since_seconds of a slave whose link is down
+	LinkDownSinceSeconds int64
+}
+
+// GetReplicationStates returns the replication of the slaves that answered the probe by pod name
+func (rc *RedisChecker) GetReplicationStates(el element.Element) (map[string]SlaveReplication, error) {
+	topology, err := rc.GetTopology(el)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]SlaveReplication)
+	for i := range topology.Redises {
+		node := &topology.Redises[i]
+		if node.Err != nil || node.IsMaster() {
+			continue
+		}
+		states[node.Pod.Name] = getReplicationState(node, topology.Master(node), el.Redis.Spec.Redis.MaxReplicationLag)
+	}
+	return states, nil
+}
+
+func getReplicationState(slave, master *element.RedisNode, maxLag int64) SlaveReplication {
+	replication := SlaveReplication{
+		State: roav1.ReplicationState{MasterLinkStatus: slave.MasterLinkStatus},
+	}
+	if !slave.IsLinkUp() {
+		replication.LinkDownSinceSeconds = slave.MasterLinkDownSinceSeconds
+		replication.State.Degraded = true
+		return replication
+	}
+	replication.LastIOSecondsAgo = slave.MasterLastIOSecondsAgo
+	// the master of the slave is not running or not a master anymore, the lag is unknown
+	if master == nil {
+		return replication
+	}
+	replication.Lag = slave.Lag(master)
+	replication.State.Degraded = maxLag > 0 && replication.Lag > maxLag
+	return replication
+}
+
+// StatusReplicationState returns the state of the slave for the status, the previous one when the link and
+// Degraded are the same and the counters have not moved to another power of two, so that the status is not
+// patched on every reconcile
+func StatusReplicationState(previous *roav1.ReplicationState, replication SlaveReplication) roav1.ReplicationState {
+	state := replication.State
+	state.Lag = replication.Lag
+	state.LastIOSecondsAgo = replication.LastIOSecondsAgo
+	state.LinkDownSinceSeconds = replication.LinkDownSinceSeconds
+	if previous != nil && previous.MasterLinkStatus == state.MasterLinkStatus && previous.Degraded == state.Degraded &&
+		statusBucket(previous.Lag, statusLagFloor) == statusBucket(state.Lag, statusLagFloor) &&
+		statusBucket(previous.LastIOSecondsAgo, statusSecondsFloor) == statusBucket(state.LastIOSecondsAgo, statusSecondsFloor) &&
+		statusBucket(previous.LinkDownSinceSeconds, statusSecondsFloor) == statusBucket(state.LinkDownSinceSeconds, statusSecondsFloor) {
+		return *previous
+	}
+	return state
+}
+
+// statusBucket is 0 below floor, and the power of two of the value above
+func statusBucket(value, floor int64) int {
+	if value < floor {
+		return 0
+	}
+	return bits.Len64(uint64(value))
+}
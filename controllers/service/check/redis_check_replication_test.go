@@ -0,0 +1,64 @@
+package check
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"testing"
+)
+
+func TestGetReplicationState(t *testing.T) {
+	master := &element.RedisNode{Role: "master", MasterReplOffset: 1000}
+	var tests = []struct {
+		name     string
+		slave    element.RedisNode
+		master   *element.RedisNode
+		maxLag   int64
+		expected SlaveReplication
+	}{
+		{"in sync", element.RedisNode{MasterLinkStatus: "up", MasterLastIOSecondsAgo: 1, SlaveReplOffset: 1000}, master, 100,
+			SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, LastIOSecondsAgo: 1}},
+		{"lag below the threshold", element.RedisNode{MasterLinkStatus: "up", SlaveReplOffset: 950}, master, 100,
+			SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, Lag: 50}},
+		{"lag above the threshold", element.RedisNode{MasterLinkStatus: "up", SlaveReplOffset: 800}, master, 100,
+			SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up", Degraded: true}, Lag: 200}},
+		{"no threshold", element.RedisNode{MasterLinkStatus: "up", SlaveReplOffset: 800}, master, 0,
+			SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, Lag: 200}},
+		{"link down", element.RedisNode{MasterLinkStatus: "down", MasterLinkDownSinceSeconds: 30, SlaveReplOffset: 800}, master, 0,
+			SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "down", Degraded: true}, LinkDownSinceSeconds: 30}},
+		{"unknown master", element.RedisNode{MasterLinkStatus: "up", SlaveReplOffset: 800}, nil, 100,
+			SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}}},
+	}
+	for _, tt := range tests {
+		if actual := getReplicationState(&tt.slave, tt.master, tt.maxLag); actual != tt.expected {
+			t.Errorf("%s: actual = %+v; expected = %+v", tt.name, actual, tt.expected)
+		}
+	}
+}
+
+func TestStatusReplicationState(t *testing.T) {
+	previous := &roav1.ReplicationState{MasterLinkStatus: "up", Lag: 300, LastIOSecondsAgo: 1}
+	var tests = []struct {
+		name        string
+		previous    *roav1.ReplicationState
+		replication SlaveReplication
+		expected    roav1.ReplicationState
+	}{
+		{"first probe", nil, SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, Lag: 300, LastIOSecondsAgo: 1},
+			roav1.ReplicationState{MasterLinkStatus: "up", Lag: 300, LastIOSecondsAgo: 1}},
+		{"in sync", previous, SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, Lag: 20, LastIOSecondsAgo: 9},
+			*previous},
+		{"lag in the same power of two", &roav1.ReplicationState{MasterLinkStatus: "up", Lag: 5000}, SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, Lag: 7000},
+			roav1.ReplicationState{MasterLinkStatus: "up", Lag: 5000}},
+		{"lag doubled", &roav1.ReplicationState{MasterLinkStatus: "up", Lag: 5000}, SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up"}, Lag: 10000},
+			roav1.ReplicationState{MasterLinkStatus: "up", Lag: 10000}},
+		{"degraded", previous, SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "up", Degraded: true}, Lag: 200},
+			roav1.ReplicationState{MasterLinkStatus: "up", Lag: 200, Degraded: true}},
+		{"link down", previous, SlaveReplication{State: roav1.ReplicationState{MasterLinkStatus: "down", Degraded: true}, LinkDownSinceSeconds: 5},
+			roav1.ReplicationState{MasterLinkStatus: "down", LinkDownSinceSeconds: 5, Degraded: true}},
+	}
+	for _, tt := range tests {
+		if actual := StatusReplicationState(tt.previous, tt.replication); actual != tt.expected {
+			t.Errorf("%s: actual = %+v; expected = %+v", tt.name, actual, tt.expected)
+		}
+	}
+}
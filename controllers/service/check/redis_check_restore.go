@@ -0,0 +1,36 @@
+package check
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+)
+
+// CheckRestoreLoaded returns nil when the master of the restore, the pod of index 0, is running
+// and has finished loading the backup
+func (rc *RedisChecker) CheckRestoreLoaded(el element.Element) error {
+	redisPods, err := rc.GetRedisPods(el)
+	if err != nil {
+		return err
+	}
+
+	podName := util.GetRedisNameByIndex(el.Redis, 0) + "-0"
+	for _, redisPod := range redisPods {
+		if redisPod.Name != podName {
+			continue
+		}
+		password, err := rc.RedisClient.GetRedisPassword(redisPod)
+		if err != nil {
+			return err
+		}
+		info, err := rc.RedisClient.GetPersistenceInfo(redisPod, password)
+		if err != nil {
+			return err
+		}
+		if info["loading"] != "0" {
+			return errors.New(podName + " is loading the backup")
+		}
+		return nil
+	}
+	return errors.New(podName + " is not running")
+}
@@ -0,0 +1,35 @@
+package check
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+)
+
+// LabelRedisRoles labels the master pod with util.RoleMaster and the other redis pods with util.RoleReplica,
+// so that the master and replicas Services follow a failover. It returns the pods it relabelled
+func (rc *RedisChecker) LabelRedisRoles(el element.Element, master redis_client.RedisParam) ([]string, error) {
+	podList, err := rc.listPods(el, util.GetRedisLabels(el.Redis))
+	if err != nil {
+		return nil, err
+	}
+	var relabelled []string
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		role := util.RoleReplica
+		if pod.Name == master.Name {
+			role = util.RoleMaster
+		}
+		if pod.Labels[util.RoleLabelKey] == role {
+			continue
+		}
+		if err := rc.K8sService.PatchPodLabels(pod.Namespace, pod.Name, map[string]string{util.RoleLabelKey: role}); err != nil {
+			return relabelled, err
+		}
+		el.Snapshot.SetPodLabel(pod.Name, util.RoleLabelKey, role)
+		relabelled = append(relabelled, pod.Name)
+	}
+	return relabelled, nil
+}
@@ -0,0 +1,68 @@
+package check
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+type labelK8sService struct {
+	k8s.Services
+	patched map[string]string
+}
+
+func (s *labelK8sService) PatchPodLabels(namespace, name string, labels map[string]string) error {
+	s.patched[name] = labels[util.RoleLabelKey]
+	return nil
+}
+
+func TestLabelRedisRoles(t *testing.T) {
+	rf := &roav1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "redis-sample", Namespace: "redis-system"}}
+	redisPod := func(name, role string) corev1.Pod {
+		labels := util.GetRedisLabels(rf)
+		if role != "" {
+			labels[util.RoleLabelKey] = role
+		}
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rf.Namespace, Labels: labels}}
+	}
+	// redis-redis-sample-1-0 has been promoted by the sentinels, redis-redis-sample-2-0 has been restarted
+	el := element.Element{
+		Redis: rf,
+		Snapshot: &element.Snapshot{Pods: []corev1.Pod{
+			redisPod("redis-redis-sample-0-0", util.RoleMaster),
+			redisPod("redis-redis-sample-1-0", util.RoleReplica),
+			redisPod("redis-redis-sample-2-0", ""),
+		}},
+	}
+	k8sService := &labelK8sService{patched: map[string]string{}}
+	rc := &RedisChecker{K8sService: k8sService}
+
+	relabelled, err := rc.LabelRedisRoles(el, redis_client.RedisParam{Name: "redis-redis-sample-1-0"})
+	if err != nil {
+		t.Fatalf("LabelRedisRoles error: %s", err)
+	}
+	if len(relabelled) != 3 {
+		t.Fatalf("relabelled = %v; expected the 3 pods", relabelled)
+	}
+	expected := map[string]string{
+		"redis-redis-sample-0-0": util.RoleReplica,
+		"redis-redis-sample-1-0": util.RoleMaster,
+		"redis-redis-sample-2-0": util.RoleReplica,
+	}
+	for name, role := range expected {
+		if k8sService.patched[name] != role {
+			t.Fatalf("%s = %s; expected = %s", name, k8sService.patched[name], role)
+		}
+	}
+
+	// the snapshot has the labels, the same master patches nothing
+	relabelled, err = rc.LabelRedisRoles(el, redis_client.RedisParam{Name: "redis-redis-sample-1-0"})
+	if err != nil || len(relabelled) != 0 {
+		t.Fatalf("relabelled = %v, %v; expected none", relabelled, err)
+	}
+}
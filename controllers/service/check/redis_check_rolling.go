@@ -0,0 +1,53 @@
+package check
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+)
+
+// GetRedisRollingIndexes returns the indexes of the redis StatefulSets whose pod template differs from
+// the spec, and whether one of the redis StatefulSets is missing or still rolling out
+func (rc *RedisChecker) GetRedisRollingIndexes(el element.Element) ([]int, bool, error) {
+	ssList, err := rc.listStatefulSets(el, util.GetRedisLabels(el.Redis))
+	if err != nil {
+		return nil, false, err
+	}
+	indexes := make([]int, 0)
+	rolling := false
+	for i := 0; i < int(el.Redis.Spec.Redis.Replicas); i++ {
+		ss := util.SearchStatefulSetByName(util.GetRedisNameByIndex(el.Redis, i), ssList)
+		if ss == nil {
+			rolling = true
+			continue
+		}
+		if !util.IsStatefulSetRolledOut(ss) {
+			rolling = true
+		}
+		if !util.RedisStatefulSetEqual(util.CreateRedisStatefulSetObjByIndex(el.Redis.DeepCopy(), el.OwnerRefs, i), ss) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, rolling, nil
+}
+
+// CheckRedisInSync returns nil when the redis is a master, or a slave whose link to the master is up
+// and which is not loading a full sync
+func (rc *RedisChecker) CheckRedisInSync(redisPod redis_client.RedisParam) error {
+	password, err := rc.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	info, err := rc.RedisClient.GetReplicationInfo(redisPod, password)
+	if err != nil {
+		return err
+	}
+	if info["role"] == "master" {
+		return nil
+	}
+	if info["master_link_status"] != "up" || info["master_sync_in_progress"] == "1" {
+		return errors.New(redisPod.Name + " is not in sync with the master")
+	}
+	return nil
+}
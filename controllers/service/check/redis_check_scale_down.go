@@ -0,0 +1,86 @@
+package check
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"sort"
+)
+
+// GetRedisScaleDownIndexes returns the indexes of the redis StatefulSets and ConfigMaps
+// beyond Spec.Redis.Replicas, the highest first
+func (rc *RedisChecker) GetRedisScaleDownIndexes(el element.Element) ([]int, error) {
+	ss, err := rc.listStatefulSets(el, util.GetRedisLabels(el.Redis))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, item := range ss.Items {
+		names = append(names, item.Name)
+	}
+	indexes := util.GetScaleDownIndexes(util.GetRedisRootName(el.Redis), names, el.Redis.Spec.Redis.Replicas)
+
+	cms, err := rc.listConfigMaps(el, util.GetRedisSlaveConfigMapLabels(el.Redis))
+	if err != nil {
+		return nil, err
+	}
+	names = make([]string, 0)
+	for _, item := range cms.Items {
+		names = append(names, item.Name)
+	}
+	indexes = append(indexes, util.GetScaleDownIndexes(util.GetRedisConfigMapRootName(el.Redis), names, el.Redis.Spec.Redis.Replicas)...)
+
+	return uniqueDesc(indexes), nil
+}
+
+// GetSentinelScaleDownIndexes returns the indexes of the sentinel StatefulSets and ConfigMaps
+// beyond Spec.Sentinel.Replicas, the highest first
+func (rc *RedisChecker) GetSentinelScaleDownIndexes(el element.Element) ([]int, error) {
+	ss, err := rc.listStatefulSets(el, util.GetSentinelLabels(el.Redis))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, item := range ss.Items {
+		names = append(names, item.Name)
+	}
+	indexes := util.GetScaleDownIndexes(util.GetSentinelRootName(el.Redis), names, el.Redis.Spec.Sentinel.Replicas)
+
+	cms, err := rc.listConfigMaps(el, util.GetSentinelSlaveConfigMapLabels(el.Redis))
+	if err != nil {
+		return nil, err
+	}
+	names = make([]string, 0)
+	for _, item := range cms.Items {
+		names = append(names, item.Name)
+	}
+	indexes = append(indexes, util.GetScaleDownIndexes(util.GetSentinelConfigMapRootName(el.Redis), names, el.Redis.Spec.Sentinel.Replicas)...)
+
+	return uniqueDesc(indexes), nil
+}
+
+// CheckScaleDownPodsDeleted returns an error while the pods of a removed StatefulSet are still there, it
+// lists them again as the StatefulSet was deleted after the snapshot
+func (rc *RedisChecker) CheckScaleDownPodsDeleted(el element.Element, labels map[string]string) error {
+	podList, err := rc.K8sService.ListPods(el.Redis.Namespace, labels)
+	if err != nil {
+		return err
+	}
+	if len(podList.Items) > 0 {
+		return errors.New("pod " + podList.Items[0].Name + " is not deleted yet")
+	}
+	return nil
+}
+
+func uniqueDesc(indexes []int) []int {
+	found := make(map[int]bool)
+	result := make([]int, 0)
+	for _, index := range indexes {
+		if !found[index] {
+			found[index] = true
+			result = append(result, index)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(result)))
+	return result
+}
@@ -0,0 +1,53 @@
+package check
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Observe lists the pods, StatefulSets and ConfigMaps of the instance once, the steps after the Ensure work
+// on them
+func (rc *RedisChecker) Observe(el element.Element) (*element.Snapshot, error) {
+	labels := util.GetInstanceLabels(el.Redis.Name)
+	podList, err := rc.K8sService.ListPods(el.Redis.Namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	ssList, err := rc.K8sService.ListStatefulSets(el.Redis.Namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	cmList, err := rc.K8sService.ListConfigMaps(el.Redis.Namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &element.Snapshot{
+		Pods:         podList.Items,
+		StatefulSets: ssList.Items,
+		ConfigMaps:   cmList.Items,
+	}, nil
+}
+
+// listPods lists from the snapshot of the reconcile, or from the cluster before it is taken
+func (rc *RedisChecker) listPods(el element.Element, labels map[string]string) (*corev1.PodList, error) {
+	if el.Snapshot != nil {
+		return el.Snapshot.ListPods(labels), nil
+	}
+	return rc.K8sService.ListPods(el.Redis.Namespace, labels)
+}
+
+func (rc *RedisChecker) listStatefulSets(el element.Element, labels map[string]string) (*appsv1.StatefulSetList, error) {
+	if el.Snapshot != nil {
+		return el.Snapshot.ListStatefulSets(labels), nil
+	}
+	return rc.K8sService.ListStatefulSets(el.Redis.Namespace, labels)
+}
+
+func (rc *RedisChecker) listConfigMaps(el element.Element, labels map[string]string) (*corev1.ConfigMapList, error) {
+	if el.Snapshot != nil {
+		return el.Snapshot.ListConfigMaps(labels), nil
+	}
+	return rc.K8sService.ListConfigMaps(el.Redis.Namespace, labels)
+}
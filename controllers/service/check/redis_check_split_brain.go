@@ -0,0 +1,93 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"sort"
+	"strconv"
+)
+
+// SplitBrain is the master kept when more than one redis is a master, and the masters to demote
+type SplitBrain struct {
+	Master  redis_client.RedisParam
+	Demoted []redis_client.RedisParam
+	// Reason is why Master is kept, e.g. monitored by 2/3 sentinels
+	Reason string
+}
+
+// GetSplitBrain picks the master to keep: the one most of the sentinels monitor, else the one with the
+// largest master_repl_offset. A tie of the offsets is broken by the smallest run_id, so that every
+// reconcile picks the same master
+func (rc *RedisChecker) GetSplitBrain(el element.Element) (SplitBrain, error) {
+	topology, err := rc.GetTopology(el)
+	if err != nil {
+		return SplitBrain{}, err
+	}
+
+	masters := make([]*element.RedisNode, 0)
+	for i := range topology.Redises {
+		node := &topology.Redises[i]
+		if node.Err != nil {
+			return SplitBrain{}, node.Err
+		}
+		if node.IsMaster() {
+			masters = append(masters, node)
+		}
+	}
+	if len(masters) < 2 {
+		return SplitBrain{}, errors.New("number of redis nodes known as master is less than 2")
+	}
+
+	if master, votes := sentinelsMajority(topology, masters); master != nil {
+		return newSplitBrain(masters, master, "monitored by "+strconv.Itoa(votes)+"/"+strconv.Itoa(len(topology.Sentinels))+" sentinels"), nil
+	}
+
+	runIDs := make(map[string]string)
+	for _, master := range masters {
+		password, err := rc.RedisClient.GetRedisPassword(master.Pod)
+		if err != nil {
+			return SplitBrain{}, err
+		}
+		info, err := rc.RedisClient.GetServerInfo(master.Pod, password)
+		if err != nil {
+			return SplitBrain{}, err
+		}
+		runIDs[master.Pod.Name] = info["run_id"]
+	}
+	sort.SliceStable(masters, func(i, j int) bool {
+		if masters[i].MasterReplOffset != masters[j].MasterReplOffset {
+			return masters[i].MasterReplOffset > masters[j].MasterReplOffset
+		}
+		return runIDs[masters[i].Pod.Name] < runIDs[masters[j].Pod.Name]
+	})
+	master := masters[0]
+	return newSplitBrain(masters, master, fmt.Sprintf("largest master_repl_offset %d, run_id %s", master.MasterReplOffset, runIDs[master.Pod.Name])), nil
+}
+
+// sentinelsMajority returns the master monitored by more than half of the sentinels, nil if there is none
+func sentinelsMajority(topology *element.Topology, masters []*element.RedisNode) (*element.RedisNode, int) {
+	for _, master := range masters {
+		votes := 0
+		for _, sentinel := range topology.Sentinels {
+			if sentinel.Err == nil && master.Pod.IsAddress(sentinel.MasterHost, sentinel.MasterPort) {
+				votes++
+			}
+		}
+		if votes*2 > len(topology.Sentinels) {
+			return master, votes
+		}
+	}
+	return nil, 0
+}
+
+func newSplitBrain(masters []*element.RedisNode, master *element.RedisNode, reason string) SplitBrain {
+	splitBrain := SplitBrain{Master: master.Pod, Reason: reason}
+	for _, node := range masters {
+		if node.Pod.Name != master.Pod.Name {
+			splitBrain.Demoted = append(splitBrain.Demoted, node.Pod)
+		}
+	}
+	return splitBrain
+}
@@ -0,0 +1,83 @@
+package check
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strconv"
+	"testing"
+)
+
+func newSplitBrainElement(sentinelMasters ...string) element.Element {
+	rf := &roav1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "redis-sample", Namespace: "default"}}
+	snapshot := &element.Snapshot{}
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		snapshot.Pods = append(snapshot.Pods, newSplitBrainPod("redis-redis-sample-"+strconv.Itoa(i)+"-0", ip, util.GetRedisLabels(rf)))
+	}
+	for i := range sentinelMasters {
+		snapshot.Pods = append(snapshot.Pods, newSplitBrainPod("sentinel-redis-sample-"+strconv.Itoa(i)+"-0", "10.0.1."+strconv.Itoa(i+1), util.GetSentinelLabels(rf)))
+	}
+	return element.Element{Redis: rf, Snapshot: snapshot}
+}
+
+func newSplitBrainPod(name, ip string, labels map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+	}
+}
+
+func newSplitBrainChecker(sentinelMasters []string, server map[string]map[string]string) *RedisChecker {
+	sentinel := make(map[string]map[string]string)
+	for i, ip := range sentinelMasters {
+		sentinel["sentinel-redis-sample-"+strconv.Itoa(i)+"-0"] = map[string]string{"master0": "name=mymaster,status=ok,address=" + ip + ":6379,slaves=1,sentinels=3"}
+	}
+	return &RedisChecker{
+		RedisClient: probeRedisClient{
+			replication: map[string]map[string]string{
+				"redis-redis-sample-0-0": {"role": "master", "master_repl_offset": "100"},
+				"redis-redis-sample-1-0": {"role": "master", "master_repl_offset": "300"},
+				"redis-redis-sample-2-0": {"role": "master", "master_repl_offset": "300"},
+			},
+			sentinel: sentinel,
+			server:   server,
+		},
+	}
+}
+
+func TestGetSplitBrain(t *testing.T) {
+	server := map[string]map[string]string{
+		"redis-redis-sample-0-0": {"run_id": "a"},
+		"redis-redis-sample-1-0": {"run_id": "c"},
+		"redis-redis-sample-2-0": {"run_id": "b"},
+	}
+	var tests = []struct {
+		name            string
+		sentinelMasters []string
+		master          string
+	}{
+		{"sentinels majority", []string{"10.0.0.1", "10.0.0.1", "10.0.0.2"}, "redis-redis-sample-0-0"},
+		{"largest offset and smallest run_id", []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, "redis-redis-sample-2-0"},
+		{"no sentinel", nil, "redis-redis-sample-2-0"},
+	}
+	for _, tt := range tests {
+		rc := newSplitBrainChecker(tt.sentinelMasters, server)
+		splitBrain, err := rc.GetSplitBrain(newSplitBrainElement(tt.sentinelMasters...))
+		if err != nil {
+			t.Fatalf("%s: GetSplitBrain error: %s", tt.name, err)
+		}
+		if splitBrain.Master.Name != tt.master {
+			t.Errorf("%s: master = %s; expected %s", tt.name, splitBrain.Master.Name, tt.master)
+		}
+		if len(splitBrain.Demoted) != 2 {
+			t.Errorf("%s: demoted = %v; expected the 2 other masters", tt.name, splitBrain.Demoted)
+		}
+		for _, demoted := range splitBrain.Demoted {
+			if demoted.Name == tt.master {
+				t.Errorf("%s: the kept master %s is demoted", tt.name, tt.master)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package check
+
+import (
+	"errors"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"strconv"
+)
+
+// CheckSwitchoverTarget returns nil when the target replicates the master and has caught up with the
+// replication offset of the master, which is read first so that new writes do not make it wait forever
+func (rc *RedisChecker) CheckSwitchoverTarget(master, target redis_client.RedisParam) error {
+	masterPassword, err := rc.RedisClient.GetRedisPassword(master)
+	if err != nil {
+		return err
+	}
+	masterInfo, err := rc.RedisClient.GetReplicationInfo(master, masterPassword)
+	if err != nil {
+		return err
+	}
+	masterOffset, err := strconv.ParseInt(masterInfo["master_repl_offset"], 10, 64)
+	if err != nil {
+		return errors.New("invalid master_repl_offset of " + master.Name + ": " + err.Error())
+	}
+
+	targetPassword, err := rc.RedisClient.GetRedisPassword(target)
+	if err != nil {
+		return err
+	}
+	targetInfo, err := rc.RedisClient.GetReplicationInfo(target, targetPassword)
+	if err != nil {
+		return err
+	}
+	if !master.IsAddress(targetInfo["master_host"], targetInfo["master_port"]) || targetInfo["master_link_status"] != "up" {
+		return errors.New(target.Name + " is not replicating the master " + master.Name)
+	}
+	targetOffset, err := strconv.ParseInt(targetInfo["slave_repl_offset"], 10, 64)
+	if err != nil {
+		return errors.New("invalid slave_repl_offset of " + target.Name + ": " + err.Error())
+	}
+	if targetOffset < masterOffset {
+		return errors.New(target.Name + " is " + strconv.FormatInt(masterOffset-targetOffset, 10) + " bytes behind the master " + master.Name)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestGetPodsWithSidecar(t *testing.T) {
+	rf := &roav1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "redis-sample", Namespace: "default"}}
+	sidecarPod := func(name, container string, labels map[string]string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rf.Namespace, Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: container}, {Name: "log-agent"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+		}
+	}
+	el := element.Element{
+		Redis: rf,
+		Snapshot: &element.Snapshot{Pods: []corev1.Pod{
+			sidecarPod("redis-redis-sample-0-0", "redis", util.GetRedisLabels(rf)),
+			sidecarPod("sentinel-redis-sample-0-0", "sentinel", util.GetSentinelLabels(rf)),
+		}},
+	}
+	rc := &RedisChecker{}
+
+	// the exec of a pod with several containers needs the container name
+	redises, err := rc.GetRedisPods(el)
+	if err != nil || len(redises) != 1 || redises[0].ContainerName != "redis" {
+		t.Fatalf("redises = %+v, %v; expected the container redis", redises, err)
+	}
+	sentinels, err := rc.GetSentinelsPods(el)
+	if err != nil || len(sentinels) != 1 || sentinels[0].ContainerName != "sentinel" {
+		t.Fatalf("sentinels = %+v, %v; expected the container sentinel", sentinels, err)
+	}
+}
@@ -0,0 +1,214 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeWorkers = 8
+	defaultProbeTimeout = 10 * time.Second
+)
+
+// GetTopology probes the running redis and sentinel pods of the instance once per snapshot, every check of
+// the replication and of the sentinels works on it
+func (rc *RedisChecker) GetTopology(el element.Element) (*element.Topology, error) {
+	probe := func() (*element.Topology, error) {
+		redisPods, err := rc.GetRedisPods(el)
+		if err != nil {
+			return nil, err
+		}
+		sentinels, err := rc.GetSentinelsPods(el)
+		if err != nil {
+			return nil, err
+		}
+		return rc.probeTopology(redisPods, sentinels), nil
+	}
+	if el.Snapshot == nil {
+		return probe()
+	}
+	return el.Snapshot.Topology(probe)
+}
+
+// probeTopology sends INFO replication to the redis and INFO sentinel to the sentinels with ProbeWorkers
+// workers, a pod that does not answer within ProbeTimeout gets an error
+func (rc *RedisChecker) probeTopology(redisPods, sentinels []redis_client.RedisParam) *element.Topology {
+	topology := &element.Topology{
+		Redises:   make([]element.RedisNode, len(redisPods)),
+		Sentinels: make([]element.SentinelNode, len(sentinels)),
+	}
+
+	jobs := make(chan func(), len(redisPods)+len(sentinels))
+	for i := range redisPods {
+		i := i
+		jobs <- func() {
+			topology.Redises[i] = rc.probeRedis(redisPods[i])
+		}
+	}
+	for i := range sentinels {
+		i := i
+		jobs <- func() {
+			topology.Sentinels[i] = rc.probeSentinel(sentinels[i])
+		}
+	}
+	close(jobs)
+
+	workers := rc.ProbeWorkers
+	if workers <= 0 {
+		workers = defaultProbeWorkers
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job()
+			}
+		}()
+	}
+	wg.Wait()
+	return topology
+}
+
+// probeRedis sends INFO replication to the redis, the exec or the connection is stopped once ProbeTimeout is over
+func (rc *RedisChecker) probeRedis(redisPod redis_client.RedisParam) element.RedisNode {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.probeTimeout())
+	defer cancel()
+	redisClient := rc.RedisClient.WithContext(ctx)
+
+	done := make(chan element.RedisNode, 1)
+	go func() {
+		password, err := redisClient.GetRedisPassword(redisPod)
+		if err != nil {
+			done <- element.RedisNode{Pod: redisPod, Err: err}
+			return
+		}
+		info, err := redisClient.GetReplicationInfo(redisPod, password)
+		if err != nil {
+			done <- element.RedisNode{Pod: redisPod, Err: err}
+			return
+		}
+		done <- parseRedisNode(redisPod, info)
+	}()
+	select {
+	case node := <-done:
+		return node
+	case <-ctx.Done():
+		return element.RedisNode{Pod: redisPod, Err: rc.probeTimeoutError(redisPod)}
+	}
+}
+
+// probeSentinel sends INFO sentinel to the sentinel, the exec or the connection is stopped once ProbeTimeout is over
+func (rc *RedisChecker) probeSentinel(sentinel redis_client.RedisParam) element.SentinelNode {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.probeTimeout())
+	defer cancel()
+	redisClient := rc.RedisClient.WithContext(ctx)
+
+	done := make(chan element.SentinelNode, 1)
+	go func() {
+		info, err := redisClient.GetSentinelInfo(sentinel)
+		if err != nil {
+			done <- element.SentinelNode{Pod: sentinel, Err: err}
+			return
+		}
+		node, err := parseSentinelNode(sentinel, info)
+		if err != nil {
+			done <- element.SentinelNode{Pod: sentinel, Err: err}
+			return
+		}
+		done <- node
+	}()
+	select {
+	case node := <-done:
+		return node
+	case <-ctx.Done():
+		return element.SentinelNode{Pod: sentinel, Err: rc.probeTimeoutError(sentinel)}
+	}
+}
+
+func (rc *RedisChecker) probeTimeout() time.Duration {
+	if rc.ProbeTimeout <= 0 {
+		return defaultProbeTimeout
+	}
+	return rc.ProbeTimeout
+}
+
+func (rc *RedisChecker) probeTimeoutError(pod redis_client.RedisParam) error {
+	return fmt.Errorf("probe of %s timed out after %s", pod.Name, rc.probeTimeout())
+}
+
+func parseRedisNode(redisPod redis_client.RedisParam, info map[string]string) element.RedisNode {
+	// the fields a redis does not report, e.g. the offsets before 2.8, stay 0
+	return element.RedisNode{
+		Pod:                        redisPod,
+		Role:                       info["role"],
+		MasterHost:                 info["master_host"],
+		MasterPort:                 info["master_port"],
+		MasterLinkStatus:           info["master_link_status"],
+		MasterLastIOSecondsAgo:     parseInfoInt(info, "master_last_io_seconds_ago"),
+		MasterLinkDownSinceSeconds: parseInfoInt(info, "master_link_down_since_seconds"),
+		MasterReplOffset:           parseInfoInt(info, "master_repl_offset"),
+		SlaveReplOffset:            parseInfoInt(info, "slave_repl_offset"),
+		SlavePriority:              parseInfoIntOr(info, "slave_priority", -1),
+	}
+}
+
+func parseInfoInt(info map[string]string, field string) int64 {
+	return parseInfoIntOr(info, field, 0)
+}
+
+// parseInfoIntOr returns def when the field is missing or malformed
+func parseInfoIntOr(info map[string]string, field string, def int64) int64 {
+	n, err := strconv.ParseInt(info[field], 10, 64)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// parseSentinelNode parses master0 of INFO sentinel, e.g.
+// name=mymaster,status=ok,address=10.0.0.1:6379,slaves=2,sentinels=3
+func parseSentinelNode(sentinel redis_client.RedisParam, info map[string]string) (element.SentinelNode, error) {
+	node := element.SentinelNode{Pod: sentinel}
+	master, ok := info["master0"]
+	if !ok {
+		// the sentinel monitors no master yet
+		return node, nil
+	}
+	for _, field := range strings.Split(master, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "status":
+			node.Status = kv[1]
+		case "address":
+			if i := strings.LastIndex(kv[1], ":"); i > 0 {
+				node.MasterHost = kv[1][:i]
+				node.MasterPort = kv[1][i+1:]
+			}
+		case "slaves":
+			n, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return node, errors.New("malformed master0 of sentinel " + sentinel.Name + ": " + master)
+			}
+			node.Slaves = int32(n)
+		case "sentinels":
+			n, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return node, errors.New("malformed master0 of sentinel " + sentinel.Name + ": " + master)
+			}
+			node.Sentinels = int32(n)
+		}
+	}
+	return node, nil
+}
@@ -0,0 +1,108 @@
+package check
+
+import (
+	"context"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"testing"
+	"time"
+)
+
+type probeRedisClient struct {
+	redis_client.RedisClient
+	replication map[string]map[string]string
+	sentinel    map[string]map[string]string
+	server      map[string]map[string]string
+	hang        string
+	// stopped gets the pod whose call was stopped by the ctx of the probe
+	stopped chan string
+	ctx     context.Context
+}
+
+func (c probeRedisClient) WithContext(ctx context.Context) redis_client.RedisClient {
+	c.ctx = ctx
+	return c
+}
+
+func (c probeRedisClient) GetRedisPassword(redisParam redis_client.RedisParam) (string, error) {
+	return "", nil
+}
+
+func (c probeRedisClient) GetReplicationInfo(redisParam redis_client.RedisParam, password string) (map[string]string, error) {
+	if redisParam.Name == c.hang {
+		select {
+		case <-c.ctx.Done():
+			c.stopped <- redisParam.Name
+			return nil, c.ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	return c.replication[redisParam.Name], nil
+}
+
+func (c probeRedisClient) GetSentinelInfo(sentinel redis_client.RedisParam) (map[string]string, error) {
+	return c.sentinel[sentinel.Name], nil
+}
+
+func (c probeRedisClient) GetServerInfo(redisParam redis_client.RedisParam, password string) (map[string]string, error) {
+	return c.server[redisParam.Name], nil
+}
+
+func TestProbeTopology(t *testing.T) {
+	rc := &RedisChecker{
+		RedisClient: probeRedisClient{
+			replication: map[string]map[string]string{
+				"redis-redis-sample-0-0": {"role": "master"},
+				"redis-redis-sample-1-0": {"role": "slave", "master_host": "10.0.0.1", "master_port": "6379", "master_link_status": "up", "slave_priority": "0"},
+			},
+			sentinel: map[string]map[string]string{
+				"sentinel-redis-sample-0-0": {"master0": "name=mymaster,status=ok,address=10.0.0.1:6379,slaves=2,sentinels=3"},
+			},
+			hang:    "redis-redis-sample-2-0",
+			stopped: make(chan string, 1),
+		},
+		ProbeWorkers: 2,
+		ProbeTimeout: 100 * time.Millisecond,
+	}
+	topology := rc.probeTopology(
+		[]redis_client.RedisParam{{Name: "redis-redis-sample-0-0"}, {Name: "redis-redis-sample-1-0"}, {Name: "redis-redis-sample-2-0"}},
+		[]redis_client.RedisParam{{Name: "sentinel-redis-sample-0-0"}},
+	)
+
+	if node := topology.Redis("redis-redis-sample-0-0"); node == nil || node.Err != nil || !node.IsMaster() {
+		t.Fatalf("redis-redis-sample-0-0 = %v; expected the master", node)
+	}
+	slave := topology.Redis("redis-redis-sample-1-0")
+	if slave == nil || slave.Err != nil || slave.IsMaster() || slave.MasterHost != "10.0.0.1" || slave.MasterLinkStatus != "up" {
+		t.Fatalf("redis-redis-sample-1-0 = %v; expected a slave of 10.0.0.1", slave)
+	}
+	if master := topology.Redis("redis-redis-sample-0-0"); master.SlavePriority != -1 || slave.SlavePriority != 0 {
+		t.Fatalf("slave priorities = %d, %d; expected -1 for the master and 0 for the slave", master.SlavePriority, slave.SlavePriority)
+	}
+	if node := topology.Redis("redis-redis-sample-2-0"); node == nil || node.Err == nil {
+		t.Fatalf("redis-redis-sample-2-0 = %v; expected a timeout", node)
+	}
+	// the call of the pod that timed out is stopped, not left running
+	select {
+	case <-rc.RedisClient.(probeRedisClient).stopped:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("the probe of redis-redis-sample-2-0 should be stopped after the timeout")
+	}
+	sentinel := topology.Sentinel("sentinel-redis-sample-0-0")
+	if sentinel == nil || sentinel.Err != nil {
+		t.Fatalf("sentinel-redis-sample-0-0 = %v; expected a probed sentinel", sentinel)
+	}
+	if sentinel.Status != "ok" || sentinel.MasterHost != "10.0.0.1" || sentinel.MasterPort != "6379" || sentinel.Slaves != 2 || sentinel.Sentinels != 3 {
+		t.Fatalf("sentinel-redis-sample-0-0 = %+v; expected ok, 10.0.0.1:6379, 2 slaves and 3 sentinels", *sentinel)
+	}
+}
+
+func TestParseSentinelNode(t *testing.T) {
+	node, err := parseSentinelNode(redis_client.RedisParam{Name: "sentinel-redis-sample-0-0"}, map[string]string{})
+	if err != nil || node.Status != "" {
+		t.Fatalf("a sentinel without master0 = %+v, %v; expected no status", node, err)
+	}
+	_, err = parseSentinelNode(redis_client.RedisParam{Name: "sentinel-redis-sample-0-0"}, map[string]string{"master0": "name=mymaster,status=ok,address=10.0.0.1:6379,slaves=x,sentinels=3"})
+	if err == nil {
+		t.Fatalf("a malformed master0 should fail")
+	}
+}
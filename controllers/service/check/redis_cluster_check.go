@@ -0,0 +1,82 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	"sort"
+	"strconv"
+)
+
+func (rc *RedisChecker) CheckRedisClusterShardNumber(el element.Element) error {
+	labels := util.GetRedisLabels(el.Redis)
+	ss, err := rc.listStatefulSets(el, labels)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < int(el.Redis.Spec.Cluster.Shards); i++ {
+		name := util.GetRedisClusterShardNameByIndex(el.Redis, i)
+		statefulSet := util.SearchStatefulSetByName(name, ss)
+		if statefulSet == nil {
+			return errors.New("number of redis cluster shards differ from specification")
+		}
+		if statefulSet.Spec.Replicas == nil || *statefulSet.Spec.Replicas != util.GetRedisClusterShardReplicas(el.Redis) {
+			return errors.New("number of redis pods of shard " + name + " differ from specification")
+		}
+	}
+	return nil
+}
+
+// GetRedisClusterShardPods returns the running pods of a shard, ordered by name
+func (rc *RedisChecker) GetRedisClusterShardPods(el element.Element, index int) ([]redis_client.RedisParam, error) {
+	redises := []redis_client.RedisParam{}
+	labels := util.GetRedisLabelsWithName(el.Redis, util.GetRedisClusterShardNameByIndex(el.Redis, index))
+	podList, err := rc.listPods(el, labels)
+	if err != nil {
+		return nil, err
+	}
+	for _, rp := range podList.Items {
+		if rp.Status.Phase == corev1.PodRunning && rp.DeletionTimestamp == nil && rp.Status.PodIP != "" { // Only work with running pods
+			redises = append(redises, redis_client.RedisParam{
+				Ip:            rp.Status.PodIP,
+				NameSpace:     rp.Namespace,
+				Name:          rp.Name,
+				ContainerName: util.GetContainerNameFromLabel(rp),
+			})
+		}
+	}
+	sort.Slice(redises, func(i, j int) bool {
+		return redises[i].Name < redises[j].Name
+	})
+	return redises, nil
+}
+
+func (rc *RedisChecker) GetClusterNodes(redisPod redis_client.RedisParam) ([]redis_client.ClusterNode, error) {
+	password, err := rc.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return nil, err
+	}
+	return rc.RedisClient.GetClusterNodes(redisPod, password)
+}
+
+func (rc *RedisChecker) CheckClusterSlots(redisPod redis_client.RedisParam) error {
+	password, err := rc.RedisClient.GetRedisPassword(redisPod)
+	if err != nil {
+		return err
+	}
+	info, err := rc.RedisClient.GetClusterInfo(redisPod, password)
+	if err != nil {
+		return err
+	}
+	if info["cluster_state"] != "ok" {
+		return fmt.Errorf("cluster_state of %s is %s", redisPod.Name, info["cluster_state"])
+	}
+	slots := strconv.Itoa(util.ClusterSlots)
+	if info["cluster_slots_assigned"] != slots || info["cluster_slots_ok"] != slots {
+		return fmt.Errorf("%s has %s slots assigned and %s slots ok, expected %s", redisPod.Name, info["cluster_slots_assigned"], info["cluster_slots_ok"], slots)
+	}
+	return nil
+}
@@ -2,14 +2,33 @@ package element
 
 import (
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// Element is what the steps of a reconcile share. The steps change Redis.Status in memory, the reconciler
+// patches the difference with ObservedStatus once at the end
 type Element struct {
-	NeedReLoad       bool
 	NeedReCheckError []error
 	Req              ctrl.Request
 	Redis            *roav1.Redis
-	OwnerRefs        []metav1.OwnerReference
+	// ObservedStatus is the status of the Redis when the reconcile started
+	ObservedStatus roav1.RedisStatus
+	OwnerRefs      []metav1.OwnerReference
+	// Conditions are observed by the checker and written to Status.Conditions at the end of the reconcile
+	Conditions []metav1.Condition
+	// Snapshot is the state of the pods, StatefulSets and ConfigMaps of the Redis after the Ensure, nil
+	// before it
+	Snapshot *Snapshot
+}
+
+// SetCondition records a condition, the last one of the same type wins
+func (el *Element) SetCondition(conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&el.Conditions, metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
 }
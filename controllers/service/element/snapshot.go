@@ -0,0 +1,93 @@
+package element
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sync"
+)
+
+// Snapshot is the state of an instance observed once per reconcile, the checker works on it instead of
+// listing the objects at every step
+type Snapshot struct {
+	Pods         []corev1.Pod
+	StatefulSets []appsv1.StatefulSet
+	ConfigMaps   []corev1.ConfigMap
+
+	mu       sync.Mutex
+	topology *Topology
+}
+
+// ListPods returns the pods matching the labels
+func (s *Snapshot) ListPods(selector map[string]string) *corev1.PodList {
+	podList := &corev1.PodList{}
+	for _, pod := range s.Pods {
+		if labels.SelectorFromSet(selector).Matches(labels.Set(pod.Labels)) {
+			podList.Items = append(podList.Items, pod)
+		}
+	}
+	return podList
+}
+
+// SetPodLabel keeps the snapshot in line with a label the operator set on a pod
+func (s *Snapshot) SetPodLabel(podName, key, value string) {
+	if s == nil {
+		return
+	}
+	for i := range s.Pods {
+		if s.Pods[i].Name == podName {
+			if s.Pods[i].Labels == nil {
+				s.Pods[i].Labels = map[string]string{}
+			}
+			s.Pods[i].Labels[key] = value
+		}
+	}
+}
+
+// ListStatefulSets returns the StatefulSets matching the labels
+func (s *Snapshot) ListStatefulSets(selector map[string]string) *appsv1.StatefulSetList {
+	ssList := &appsv1.StatefulSetList{}
+	for _, ss := range s.StatefulSets {
+		if labels.SelectorFromSet(selector).Matches(labels.Set(ss.Labels)) {
+			ssList.Items = append(ssList.Items, ss)
+		}
+	}
+	return ssList
+}
+
+// ListConfigMaps returns the ConfigMaps matching the labels
+func (s *Snapshot) ListConfigMaps(selector map[string]string) *corev1.ConfigMapList {
+	cmList := &corev1.ConfigMapList{}
+	for _, cm := range s.ConfigMaps {
+		if labels.SelectorFromSet(selector).Matches(labels.Set(cm.Labels)) {
+			cmList.Items = append(cmList.Items, cm)
+		}
+	}
+	return cmList
+}
+
+// Topology returns the topology of the instance, probed by probe the first time
+func (s *Snapshot) Topology(probe func() (*Topology, error)) (*Topology, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topology != nil {
+		return s.topology, nil
+	}
+	topology, err := probe()
+	if err != nil {
+		return nil, err
+	}
+	s.topology = topology
+	return topology, nil
+}
+
+// ForgetTopology drops the topology after the operator changed the replication or the sentinels, the next
+// use probes the pods again
+func (s *Snapshot) ForgetTopology() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topology = nil
+}
@@ -0,0 +1,62 @@
+package element
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestSnapshotListPods(t *testing.T) {
+	s := &Snapshot{
+		Pods: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "redis-redis-sample-0-0", Labels: map[string]string{"app.kubernetes.io/name": "redis-sample", "app.kubernetes.io/component": "redis"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "sentinel-redis-sample-0-0", Labels: map[string]string{"app.kubernetes.io/name": "redis-sample", "app.kubernetes.io/component": "sentinel"}}},
+		},
+	}
+
+	if actual := len(s.ListPods(map[string]string{"app.kubernetes.io/name": "redis-sample"}).Items); actual != 2 {
+		t.Fatalf("actual = %d; expected = 2", actual)
+	}
+	podList := s.ListPods(map[string]string{"app.kubernetes.io/component": "sentinel"})
+	if len(podList.Items) != 1 || podList.Items[0].Name != "sentinel-redis-sample-0-0" {
+		t.Fatalf("actual = %v; expected = [sentinel-redis-sample-0-0]", podList.Items)
+	}
+}
+
+func TestSnapshotTopology(t *testing.T) {
+	s := &Snapshot{}
+	probes := 0
+	probe := func() (*Topology, error) {
+		probes++
+		return &Topology{Redises: []RedisNode{{Pod: redis_client.RedisParam{Name: "redis-redis-sample-0-0"}, Role: "master"}}}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		topology, err := s.Topology(probe)
+		if err != nil {
+			t.Fatalf("Topology error: %s", err)
+		}
+		if node := topology.Redis("redis-redis-sample-0-0"); node == nil || !node.IsMaster() {
+			t.Fatalf("redis-redis-sample-0-0 should be the master")
+		}
+		if node := topology.Redis("redis-redis-sample-1-0"); node != nil {
+			t.Fatalf("redis-redis-sample-1-0 should not be running")
+		}
+	}
+	if probes != 1 {
+		t.Fatalf("probes = %d; expected = 1", probes)
+	}
+
+	s.ForgetTopology()
+	if _, err := s.Topology(probe); err != nil {
+		t.Fatalf("Topology error: %s", err)
+	}
+	if probes != 2 {
+		t.Fatalf("probes = %d; expected = 2", probes)
+	}
+
+	// the steps before the snapshot have none
+	var nilSnapshot *Snapshot
+	nilSnapshot.ForgetTopology()
+}
@@ -0,0 +1,89 @@
+package element
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+)
+
+// Topology is the replication of an instance as its running redis and sentinel pods report it
+type Topology struct {
+	Redises   []RedisNode
+	Sentinels []SentinelNode
+}
+
+// RedisNode is the INFO replication of a redis pod, Err is set when the pod could not be probed
+type RedisNode struct {
+	Pod  redis_client.RedisParam
+	Role string
+	// MasterHost, MasterPort, MasterLinkStatus, MasterLastIOSecondsAgo and MasterLinkDownSinceSeconds
+	// are of a slave
+	MasterHost                 string
+	MasterPort                 string
+	MasterLinkStatus           string
+	MasterLastIOSecondsAgo     int64
+	MasterLinkDownSinceSeconds int64
+	// MasterReplOffset is the replication offset of a master, SlaveReplOffset the offset a slave has received
+	MasterReplOffset int64
+	SlaveReplOffset  int64
+	// SlavePriority is the replica priority of a slave, -1 when the redis does not report it, e.g. a master
+	SlavePriority int64
+	Err           error
+}
+
+func (n RedisNode) IsMaster() bool {
+	return n.Role == "master"
+}
+
+// IsLinkUp returns true when a slave is connected to its master
+func (n RedisNode) IsLinkUp() bool {
+	return n.MasterLinkStatus == "up"
+}
+
+// Lag returns the bytes a slave is behind master
+func (n RedisNode) Lag(master *RedisNode) int64 {
+	if lag := master.MasterReplOffset - n.SlaveReplOffset; lag > 0 {
+		return lag
+	}
+	return 0
+}
+
+// Master returns the master a slave replicates, nil if it is not a running master
+func (t *Topology) Master(slave *RedisNode) *RedisNode {
+	for i := range t.Redises {
+		if t.Redises[i].Err == nil && t.Redises[i].IsMaster() && t.Redises[i].Pod.IsAddress(slave.MasterHost, slave.MasterPort) {
+			return &t.Redises[i]
+		}
+	}
+	return nil
+}
+
+// SentinelNode is the INFO sentinel of a sentinel pod about the master it monitors, Err is set when the pod
+// could not be probed
+type SentinelNode struct {
+	Pod        redis_client.RedisParam
+	Status     string
+	MasterHost string
+	MasterPort string
+	Slaves     int32
+	Sentinels  int32
+	Err        error
+}
+
+// Redis returns the node of a redis pod, nil if it is not running
+func (t *Topology) Redis(podName string) *RedisNode {
+	for i := range t.Redises {
+		if t.Redises[i].Pod.Name == podName {
+			return &t.Redises[i]
+		}
+	}
+	return nil
+}
+
+// Sentinel returns the node of a sentinel pod, nil if it is not running
+func (t *Topology) Sentinel(podName string) *SentinelNode {
+	for i := range t.Sentinels {
+		if t.Sentinels[i].Pod.Name == podName {
+			return &t.Sentinels[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package element
+
+import (
+	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"testing"
+)
+
+func TestTopologyMaster(t *testing.T) {
+	// two redis pods with HostNetwork on the same node
+	topology := &Topology{Redises: []RedisNode{
+		{Pod: redis_client.RedisParam{Name: "redis-redis-sample-0-0", Ip: "10.0.0.1", Port: "6379"}, Role: "master"},
+		{Pod: redis_client.RedisParam{Name: "redis-redis-sample-1-0", Ip: "10.0.0.1", Port: "6389"}, Role: "master"},
+		{Pod: redis_client.RedisParam{Name: "redis-redis-sample-2-0", Ip: "10.0.0.2", Port: "6379"}, Role: "slave", MasterHost: "10.0.0.1", MasterPort: "6389"},
+	}}
+	master := topology.Master(&topology.Redises[2])
+	if master == nil || master.Pod.Name != "redis-redis-sample-1-0" {
+		t.Fatalf("master = %v; expected redis-redis-sample-1-0", master)
+	}
+
+	unknown := &RedisNode{Role: "slave", MasterHost: "10.0.0.1", MasterPort: "6400"}
+	if master := topology.Master(unknown); master != nil {
+		t.Fatalf("master = %v; expected nil", master.Pod.Name)
+	}
+}
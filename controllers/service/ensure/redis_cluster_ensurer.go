@@ -0,0 +1,204 @@
+package ensure
+
+import (
+	"context"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"reflect"
+)
+
+// --- EnsureRedisClusterConfigMap ---
+func (r *RedisEnsurer) EnsureRedisClusterConfigMap(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("RedisEnsurer", "EnsureRedisClusterConfigMap")
+
+	password, err := k8s.GetSpecRedisPassword(r.K8SService, el.Redis)
+	if err != nil {
+		return el, err
+	}
+
+	currentRedisConfigMapStatus := roav1.RedisStatusItem{}
+	previousRedisStatus := el.Redis.Status.Redis
+	currentRedisStatus := *el.Redis.Status.Redis.DeepCopy()
+
+	exists := true
+	redisConfigMap, err := r.K8SService.GetConfigMap(el.Redis.Namespace, util.GetRedisClusterConfigMapName(el.Redis))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			exists = false
+		} else {
+			return el, err
+		}
+	}
+
+	PrintOBJ("get RedisClusterConfigMap", el.Redis, redisConfigMap)
+
+	var desiredRedisConfigMap = &v1.ConfigMap{}
+	if exists {
+		existingRedisConfigMap := redisConfigMap
+		desiredRedisConfigMap = util.CreateRedisClusterConfigMapObjByExistingObj(el.Redis, password, existingRedisConfigMap.DeepCopy())
+
+		if reflect.DeepEqual(desiredRedisConfigMap.Data, existingRedisConfigMap.Data) {
+			Info(r.Log, "RedisClusterConfigMap Data equal", el.Redis)
+			currentRedisConfigMapStatus.Status = roav1.Desired
+		} else {
+			Info(r.Log, "RedisClusterConfigMap Data not equal", el.Redis)
+			currentRedisConfigMapStatus.Status = roav1.Pending
+		}
+	} else {
+		currentRedisConfigMapStatus.Status = ""
+		Info(r.Log, "RedisState.RedisPassword.Status=\"\" set RedisState.RedisPassword.Md5", el.Redis)
+		currentRedisStatus.RedisPassword.Md5 = util.MD5(password)
+	}
+
+	if !reflect.DeepEqual(previousRedisStatus, currentRedisStatus) {
+		PrintOBJ("currentRedisStatus", el.Redis, currentRedisStatus)
+		PrintOBJ("previousRedisStatus", el.Redis, previousRedisStatus)
+
+		Info(log, "RedisState Status not equal", el.Redis)
+		el.Redis.Status.Redis = currentRedisStatus
+	} else {
+		Info(log, "RedisState Status equal", el.Redis)
+	}
+
+	if currentRedisConfigMapStatus.Status == roav1.Desired {
+		return el, nil
+	} else if currentRedisConfigMapStatus.Status == roav1.Pending {
+		Info(r.Log, "start update RedisClusterConfigMap...", el.Redis)
+
+		if err := r.K8SService.Update(context.Background(), desiredRedisConfigMap); err != nil {
+			return el, err
+		}
+	} else {
+		configMap := util.CreateRedisClusterConfigMap(el.Redis, el.OwnerRefs, password)
+		PrintOBJ("create RedisClusterConfigMap object", el.Redis, configMap)
+
+		if err := r.K8SService.Create(context.Background(), configMap); err != nil {
+			return el, err
+		}
+	}
+
+	return el, nil
+}
+
+// --- EnsureRedisClusterStatefulSets ---
+// the changed shards are rolled one at a time, the pods of a shard are rolled by the StatefulSet
+func (r *RedisEnsurer) EnsureRedisClusterStatefulSets(el element.Element) (element.Element, error) {
+	names := make([]string, 0)
+	for i := 0; i < int(el.Redis.Spec.Cluster.Shards); i++ {
+		names = append(names, util.GetRedisClusterShardNameByIndex(el.Redis, i))
+	}
+	rolling, err := r.isRollingOut(el.Redis.Namespace, names)
+	if err != nil {
+		return el, err
+	}
+
+	for i := 0; i < int(el.Redis.Spec.Cluster.Shards); i++ {
+		el, rolling, err = r.ensureRedisClusterStatefulSet(el, i, rolling)
+		if err != nil {
+			return el, err
+		}
+	}
+
+	return el, nil
+}
+
+func (r *RedisEnsurer) ensureRedisClusterStatefulSet(el element.Element, index int, rolling bool) (element.Element, bool, error) {
+	statefulSetName := util.GetRedisClusterShardNameByIndex(el.Redis, index)
+
+	currentRedisStatefulSetStatus := roav1.RedisStatusItem{}
+
+	exists := true
+	statefulSet, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, statefulSetName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			exists = false
+		} else {
+			return el, rolling, err
+		}
+	}
+
+	PrintOBJ("get RedisClusterStatefulSet", el.Redis, statefulSet)
+
+	var desiredRedisStatefulSet = &appsv1.StatefulSet{}
+	if exists {
+		if util.GetSpecHash(statefulSet) == "" {
+			return el, rolling, r.adoptStatefulSet(el, statefulSet, util.CreateRedisClusterStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index))
+		}
+
+		existingRedisStatefulSet := statefulSet
+		desiredRedisStatefulSet = util.CreateRedisClusterStatefulSetObjByExistingObjByIndex(el.Redis, el.OwnerRefs, existingRedisStatefulSet.DeepCopy(), index)
+
+		PrintOBJ("desiredRedisClusterStatefulSet", el.Redis, desiredRedisStatefulSet.Spec)
+		PrintOBJ("existingRedisClusterStatefulSet", el.Redis, existingRedisStatefulSet.Spec)
+
+		if util.RedisClusterStatefulSetEqual(desiredRedisStatefulSet, existingRedisStatefulSet) {
+			Info(r.Log, "RedisClusterStatefulSet Spec equal", el.Redis)
+			currentRedisStatefulSetStatus.Status = roav1.Desired
+		} else {
+			Info(r.Log, "RedisClusterStatefulSet Spec not equal", el.Redis)
+			currentRedisStatefulSetStatus.Status = roav1.Pending
+		}
+	} else {
+		currentRedisStatefulSetStatus.Status = ""
+	}
+
+	if currentRedisStatefulSetStatus.Status == roav1.Desired {
+		return el, rolling, nil
+	} else if currentRedisStatefulSetStatus.Status == roav1.Pending {
+		// scaling the replicas of a shard does not restart its pods
+		templateChanged := !util.RedisStatefulSetEqual(desiredRedisStatefulSet, statefulSet)
+		if rolling && templateChanged {
+			Info(r.Log, "wait for the rolling update of the other RedisClusterStatefulSets", el.Redis)
+			return el, rolling, nil
+		}
+
+		Info(r.Log, "start update RedisClusterStatefulSet...", el.Redis)
+		// ...and Update it on the cluster
+		if err := r.K8SService.Update(context.Background(), desiredRedisStatefulSet); err != nil {
+			return el, rolling, err
+		}
+		rolling = rolling || templateChanged
+	} else {
+		statefulSet := util.CreateRedisClusterStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index)
+
+		PrintOBJ("create RedisClusterStatefulSet object", el.Redis, statefulSet)
+
+		// ...and create it on the cluster
+		if err := r.createStatefulSet(el, statefulSet); err != nil {
+			return el, rolling, err
+		}
+	}
+
+	return el, rolling, nil
+}
+
+// --- EnsureRedisClusterHeadlessServices ---
+func (r *RedisEnsurer) EnsureRedisClusterHeadlessServices(el element.Element) (element.Element, error) {
+	for i := 0; i < int(el.Redis.Spec.Cluster.Shards); i++ {
+		headlessServiceName := util.GetRedisClusterHeadlessServiceNameByIndex(el.Redis, i)
+
+		_, err := r.K8SService.GetService(el.Redis.Namespace, headlessServiceName)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return el, err
+		}
+
+		service := util.CreateRedisClusterHeadlessServiceByIndex(el.Redis, el.OwnerRefs, i)
+
+		PrintOBJ("create RedisClusterHeadlessService object", el.Redis, service)
+
+		// ...and create it on the cluster
+		if err := r.K8SService.Create(context.Background(), service); err != nil {
+			return el, err
+		}
+	}
+
+	return el, nil
+}
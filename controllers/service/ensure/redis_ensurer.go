@@ -9,8 +9,10 @@ import (
 	"github.com/zhizuqiu/redis-operator/controllers/util"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
 )
 
@@ -19,12 +21,21 @@ type RedisEnsure interface {
 	EnsureRedisReadinessConfigMap(el element.Element) (element.Element, error)
 	EnsureRedisMasterConfigMap(el element.Element) (element.Element, error)
 	EnsureRedisSlaveConfigMaps(el element.Element) (element.Element, error)
+	EnsureRedisRestoreSecret(el element.Element) (element.Element, error)
 	EnsureRedisStatefulSets(el element.Element) (element.Element, error)
+	UpdateRedisStatefulSetByIndex(el element.Element, index int) (element.Element, error)
 	EnsureSentinelStatefulSets(el element.Element) (element.Element, error)
 	EnsureSentinelService(el element.Element) (element.Element, error)
 	EnsureExporterDeployment(el element.Element) (element.Element, error)
 	EnsureSentinelHeadlessService(el element.Element) (element.Element, error)
 	EnsureRedisHeadlessService(el element.Element) (element.Element, error)
+	EnsureRedisRoleServices(el element.Element) (element.Element, error)
+	EnsureRedisPodDisruptionBudget(el element.Element) (element.Element, error)
+	EnsureSentinelPodDisruptionBudget(el element.Element) (element.Element, error)
+	EnsureVolumeExpansion(el element.Element) (element.Element, error)
+	EnsureRedisClusterConfigMap(el element.Element) (element.Element, error)
+	EnsureRedisClusterStatefulSets(el element.Element) (element.Element, error)
+	EnsureRedisClusterHeadlessServices(el element.Element) (element.Element, error)
 }
 
 type RedisEnsurer struct {
@@ -43,15 +54,6 @@ func NewRedisEnsurer(k8sService k8s.Services, log logr.Logger) *RedisEnsurer {
 // --- EnsureSentinelConfigMap ---
 func (r *RedisEnsurer) EnsureSentinelConfigMaps(el element.Element) (element.Element, error) {
 
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	for i := 0; i < int(el.Redis.Spec.Sentinel.Replicas); i++ {
 		el, err = r.ensureSentinelConfigMap(el, i)
@@ -64,15 +66,6 @@ func (r *RedisEnsurer) EnsureSentinelConfigMaps(el element.Element) (element.Ele
 }
 
 func (r *RedisEnsurer) ensureSentinelConfigMap(el element.Element, index int) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	password, err := k8s.GetSpecRedisPassword(r.K8SService, el.Redis)
 	if err != nil {
 		return el, err
@@ -120,10 +113,7 @@ func (r *RedisEnsurer) ensureSentinelConfigMap(el element.Element, index int) (e
 		PrintOBJ("previousSentinelStatus", el.Redis, previousSentinelStatus)
 
 		Info(r.Log, "SentinelState Status not equal", el.Redis)
-		if err := r.K8SService.UpdateSentinelState(el.Redis, currentSentinelStatus); err != nil {
-			return el, err
-		}
-		el.NeedReLoad = true
+		el.Redis.Status.Sentinel = currentSentinelStatus
 	} else {
 		Info(r.Log, "SentinelState Status equal", el.Redis)
 	}
@@ -151,15 +141,6 @@ func (r *RedisEnsurer) ensureSentinelConfigMap(el element.Element, index int) (e
 // --- EnsureRedisReadinessConfigMap ---
 func (r *RedisEnsurer) EnsureRedisReadinessConfigMap(el element.Element) (element.Element, error) {
 
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	currentReadinessConfigMapStatus := roav1.RedisStatusItem{}
 
 	exists := true
@@ -200,15 +181,6 @@ func (r *RedisEnsurer) EnsureRedisReadinessConfigMap(el element.Element) (elemen
 func (r *RedisEnsurer) EnsureRedisMasterConfigMap(el element.Element) (element.Element, error) {
 	log := r.Log.WithValues("RedisEnsurer", "EnsureRedisMasterConfigMap")
 
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	password, err := k8s.GetSpecRedisPassword(r.K8SService, el.Redis)
 	if err != nil {
 		return el, err
@@ -256,10 +228,7 @@ func (r *RedisEnsurer) EnsureRedisMasterConfigMap(el element.Element) (element.E
 		PrintOBJ("previousRedisStatus", el.Redis, previousRedisStatus)
 
 		Info(log, "RedisState Status not equal", el.Redis)
-		if err := r.K8SService.UpdateRedisState(el.Redis, currentRedisStatus); err != nil {
-			return el, err
-		}
-		el.NeedReLoad = true
+		el.Redis.Status.Redis = currentRedisStatus
 	} else {
 		Info(log, "RedisState Status equal", el.Redis)
 	}
@@ -286,15 +255,6 @@ func (r *RedisEnsurer) EnsureRedisMasterConfigMap(el element.Element) (element.E
 
 // --- EnsureRedisSlaveConfigMap ---
 func (r *RedisEnsurer) EnsureRedisSlaveConfigMaps(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	for i := 0; i < int(el.Redis.Spec.Redis.Replicas); i++ {
 		if i != 0 {
@@ -311,15 +271,6 @@ func (r *RedisEnsurer) EnsureRedisSlaveConfigMaps(el element.Element) (element.E
 func (r *RedisEnsurer) ensureRedisSlaveConfigMap(el element.Element, index int) (element.Element, error) {
 	log := r.Log.WithValues("RedisEnsurer", "EnsureAutoFailoverRedisConfigMap")
 
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	password, err := k8s.GetSpecRedisPassword(r.K8SService, el.Redis)
 	if err != nil {
 		return el, err
@@ -367,10 +318,7 @@ func (r *RedisEnsurer) ensureRedisSlaveConfigMap(el element.Element, index int)
 		PrintOBJ("previousRedisStatus", el.Redis, previousRedisStatus)
 
 		Info(log, "RedisState Status not equal", el.Redis)
-		if err := r.K8SService.UpdateRedisState(el.Redis, currentRedisStatus); err != nil {
-			return el, err
-		}
-		el.NeedReLoad = true
+		el.Redis.Status.Redis = currentRedisStatus
 	} else {
 		Info(log, "RedisState Status equal", el.Redis)
 	}
@@ -396,17 +344,10 @@ func (r *RedisEnsurer) ensureRedisSlaveConfigMap(el element.Element, index int)
 }
 
 // --- EnsureRedisStatefulSets ---
+// creates the missing redis StatefulSets, the changed ones are rolled by the controller one at a time,
+// replicas first, see UpdateRedisStatefulSetByIndex
 func (r *RedisEnsurer) EnsureRedisStatefulSets(el element.Element) (element.Element, error) {
 
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	for i := 0; i < int(el.Redis.Spec.Redis.Replicas); i++ {
 		el, err = r.ensureRedisStatefulSet(el, i)
@@ -419,93 +360,71 @@ func (r *RedisEnsurer) EnsureRedisStatefulSets(el element.Element) (element.Elem
 }
 
 func (r *RedisEnsurer) ensureRedisStatefulSet(el element.Element, index int) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	statefulSetName := util.GetRedisNameByIndex(el.Redis, index)
 
-	currentRedisStatefulSetStatus := roav1.RedisStatusItem{}
-
-	exists := true
 	statefulSet, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, statefulSetName)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			exists = false
-		} else {
+		if !errors.IsNotFound(err) {
 			return el, err
 		}
-	}
-
-	PrintOBJ("get RedisStatefulSet", el.Redis, statefulSet)
-
-	var desiredRedisStatefulSet = &appsv1.StatefulSet{}
-	if exists {
-		existingRedisStatefulSet := statefulSet
-		desiredRedisStatefulSet = util.CreateRedisStatefulSetObjByExistingObjByIndex(el.Redis, el.OwnerRefs, existingRedisStatefulSet.DeepCopy(), index)
-
-		PrintOBJ("desiredRedisStatefulSet", el.Redis, desiredRedisStatefulSet.Spec)
-		PrintOBJ("existingRedisStatefulSet", el.Redis, existingRedisStatefulSet.Spec)
-
-		currentRedisStatefulSetStatus.Status = roav1.Desired
+		statefulSet := util.CreateRedisStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index)
 
-		DealResource(&desiredRedisStatefulSet.Spec.Template.Spec)
-		DealResource(&existingRedisStatefulSet.Spec.Template.Spec)
+		PrintOBJ("create RedisStatefulSet object", el.Redis, statefulSet)
 
-		if util.RedisStatefulSetEqual(desiredRedisStatefulSet, existingRedisStatefulSet) {
-			Info(r.Log, "RedisStatefulSet Spec equal", el.Redis)
-			currentRedisStatefulSetStatus.Status = roav1.Desired
-		} else {
-			Info(r.Log, "RedisStatefulSet Spec not equal", el.Redis)
-			currentRedisStatefulSetStatus.Status = roav1.Pending
+		// ...and create it on the cluster
+		if err := r.createStatefulSet(el, statefulSet); err != nil {
+			return el, err
 		}
-	} else {
-		currentRedisStatefulSetStatus.Status = ""
+		return el, nil
 	}
 
-	if currentRedisStatefulSetStatus.Status == roav1.Desired {
-		return el, nil
-	} else if currentRedisStatefulSetStatus.Status == roav1.Pending {
+	PrintOBJ("get RedisStatefulSet", el.Redis, statefulSet)
 
-		Info(r.Log, "start update RedisStatefulSet...", el.Redis)
-		// ...and Update it on the cluster
-		if err := r.K8SService.Update(context.Background(), desiredRedisStatefulSet); err != nil {
-			return el, err
-		}
-	} else {
-		statefulSet := util.CreateRedisStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index)
+	if util.GetSpecHash(statefulSet) == "" {
+		return el, r.adoptStatefulSet(el, statefulSet, util.CreateRedisStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index))
+	}
+	if !util.RedisStatefulSetEqual(util.CreateRedisStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index), statefulSet) {
+		Info(r.Log, "RedisStatefulSet "+statefulSetName+" Spec not equal, wait for the rolling update", el.Redis)
+	}
 
-		PrintOBJ("create RedisStatefulSet object", el.Redis, statefulSet)
+	return el, nil
+}
 
-		// ...and create it on the cluster
-		if err := r.K8SService.Create(context.Background(), statefulSet); err != nil {
-			return el, err
-		}
+// UpdateRedisStatefulSetByIndex applies the desired pod template to the redis StatefulSet of the index,
+// the caller makes sure the other redis pods are ready and the pod is not the master
+func (r *RedisEnsurer) UpdateRedisStatefulSetByIndex(el element.Element, index int) (element.Element, error) {
+	statefulSet, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, util.GetRedisNameByIndex(el.Redis, index))
+	if err != nil {
+		return el, err
 	}
+	desiredRedisStatefulSet := util.CreateRedisStatefulSetObjByExistingObjByIndex(el.Redis, el.OwnerRefs, statefulSet.DeepCopy(), index)
+
+	PrintOBJ("desiredRedisStatefulSet", el.Redis, desiredRedisStatefulSet.Spec)
 
+	Info(r.Log, "start update RedisStatefulSet "+statefulSet.Name+"...", el.Redis)
+	// ...and Update it on the cluster
+	if err := r.K8SService.Update(context.Background(), desiredRedisStatefulSet); err != nil {
+		return el, err
+	}
 	return el, nil
 }
 
 // --- EnsureSentinelStatefulSets ---
+// the changed sentinel StatefulSets are rolled one at a time, the next one is updated once
+// the pods of the others are ready
 func (r *RedisEnsurer) EnsureSentinelStatefulSets(el element.Element) (element.Element, error) {
 
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
+	names := make([]string, 0)
+	for i := 0; i < int(el.Redis.Spec.Sentinel.Replicas); i++ {
+		names = append(names, util.GetSentinelNameByIndex(el.Redis, i))
+	}
+	rolling, err := r.isRollingOut(el.Redis.Namespace, names)
+	if err != nil {
+		return el, err
 	}
-	el.NeedReLoad = false
 
-	err := util.NilError()
 	for i := 0; i < int(el.Redis.Spec.Sentinel.Replicas); i++ {
-		el, err = r.ensureSentinelStatefulSet(el, i)
+		el, rolling, err = r.ensureSentinelStatefulSet(el, i, rolling)
 		if err != nil {
 			return el, err
 		}
@@ -514,16 +433,7 @@ func (r *RedisEnsurer) EnsureSentinelStatefulSets(el element.Element) (element.E
 	return el, nil
 }
 
-func (r *RedisEnsurer) ensureSentinelStatefulSet(el element.Element, index int) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
+func (r *RedisEnsurer) ensureSentinelStatefulSet(el element.Element, index int, rolling bool) (element.Element, bool, error) {
 	statefulSetName := util.GetSentinelNameByIndex(el.Redis, index)
 
 	currentSentinelStatefulSetStatus := roav1.RedisStatusItem{}
@@ -534,7 +444,7 @@ func (r *RedisEnsurer) ensureSentinelStatefulSet(el element.Element, index int)
 		if errors.IsNotFound(err) {
 			exists = false
 		} else {
-			return el, err
+			return el, rolling, err
 		}
 	}
 
@@ -542,16 +452,16 @@ func (r *RedisEnsurer) ensureSentinelStatefulSet(el element.Element, index int)
 
 	var desiredSentinelStatefulSet = &appsv1.StatefulSet{}
 	if exists {
+		if util.GetSpecHash(statefulSet) == "" {
+			return el, rolling, r.adoptStatefulSet(el, statefulSet, util.CreateSentinelStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index))
+		}
+
 		existingSentinelStatefulSet := statefulSet
 		desiredSentinelStatefulSet = util.CreateSentinelStatefulSetObjByExistingObjByIndex(el.Redis, el.OwnerRefs, existingSentinelStatefulSet.DeepCopy(), index)
 
 		PrintOBJ("desiredSentinelStatefulSet", el.Redis, desiredSentinelStatefulSet.Spec)
 		PrintOBJ("existingSentinelStatefulSet", el.Redis, existingSentinelStatefulSet.Spec)
 
-		currentSentinelStatefulSetStatus.Status = roav1.Desired
-
-		DealResource(&desiredSentinelStatefulSet.Spec.Template.Spec)
-		DealResource(&existingSentinelStatefulSet.Spec.Template.Spec)
 		if util.SentinelStatefulSetEqual(desiredSentinelStatefulSet, existingSentinelStatefulSet) {
 			Info(r.Log, "SentinelStatefulSet Spec equal", el.Redis)
 			currentSentinelStatefulSetStatus.Status = roav1.Desired
@@ -564,38 +474,58 @@ func (r *RedisEnsurer) ensureSentinelStatefulSet(el element.Element, index int)
 	}
 
 	if currentSentinelStatefulSetStatus.Status == roav1.Desired {
-		return el, nil
+		return el, rolling, nil
 	} else if currentSentinelStatefulSetStatus.Status == roav1.Pending {
+		if rolling {
+			Info(r.Log, "wait for the rolling update of the other SentinelStatefulSets", el.Redis)
+			return el, rolling, nil
+		}
 
 		Info(r.Log, "start update SentinelStatefulSet...", el.Redis)
 		// ...and Update it on the cluster
 		if err := r.K8SService.Update(context.Background(), desiredSentinelStatefulSet); err != nil {
-			return el, err
+			return el, rolling, err
 		}
+		rolling = true
 	} else {
 		statefulSet := util.CreateSentinelStatefulSetObjByIndex(el.Redis, el.OwnerRefs, index)
 		PrintOBJ("create SentinelStatefulSet object", el.Redis, statefulSet)
 
 		// ...and create it on the cluster
-		if err := r.K8SService.Create(context.Background(), statefulSet); err != nil {
-			return el, err
+		if err := r.createStatefulSet(el, statefulSet); err != nil {
+			return el, rolling, err
 		}
 	}
 
-	return el, nil
+	return el, rolling, nil
 }
 
-// --- EnsureSentinelService ---
-func (r *RedisEnsurer) EnsureSentinelService(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
+// isRollingOut returns true if one of the existing StatefulSets has pods that are not updated or not ready
+func (r *RedisEnsurer) isRollingOut(namespace string, names []string) (bool, error) {
+	for _, name := range names {
+		statefulSet, err := r.K8SService.GetStatefulSet(namespace, name)
 		if err != nil {
-			return el, err
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if !util.IsStatefulSetRolledOut(statefulSet) {
+			return true, nil
 		}
-		el.Redis = redisNew
 	}
-	el.NeedReLoad = false
+	return false, nil
+}
+
+// adoptStatefulSet writes the hash of the current spec to a StatefulSet created before the hash was
+// introduced, its pods are not restarted, the next change of the spec rolls them
+func (r *RedisEnsurer) adoptStatefulSet(el element.Element, existing, desired *appsv1.StatefulSet) error {
+	Info(r.Log, "adopt StatefulSet "+existing.Name+" without "+util.SpecHashAnnotation, el.Redis)
+	return r.K8SService.Update(context.Background(), util.SetSpecHash(existing.DeepCopy(), desired))
+}
 
+// --- EnsureSentinelService ---
+func (r *RedisEnsurer) EnsureSentinelService(el element.Element) (element.Element, error) {
 	currentSentinelStatus := roav1.RedisStatusItem{}
 
 	exists := true
@@ -635,15 +565,6 @@ func (r *RedisEnsurer) EnsureSentinelService(el element.Element) (element.Elemen
 
 // --- EnsureExporterDeployment ---
 func (r *RedisEnsurer) EnsureExporterDeployment(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	currentExporterStatus := roav1.RedisStatusItem{}
 
 	exists := true
@@ -663,11 +584,16 @@ func (r *RedisEnsurer) EnsureExporterDeployment(el element.Element) (element.Ele
 		return el, err
 	}
 
+	passwordVersion, err := r.ensureExporterSecret(el, password)
+	if err != nil {
+		return el, err
+	}
+
 	var desiredExporterDeployment = &appsv1.Deployment{}
 	if exists {
 
 		existingExporterDeployment := exporterDeployment
-		desiredExporterDeployment = util.CreateExporterDeploymentObjByExistingObj(el.Redis, password, existingExporterDeployment.DeepCopy())
+		desiredExporterDeployment = util.CreateExporterDeploymentObjByExistingObj(el.Redis, passwordVersion, existingExporterDeployment.DeepCopy())
 
 		PrintOBJ("desiredExporterDeployment", el.Redis, desiredExporterDeployment.Spec)
 		PrintOBJ("existingExporterDeployment", el.Redis, existingExporterDeployment.Spec)
@@ -692,7 +618,7 @@ func (r *RedisEnsurer) EnsureExporterDeployment(el element.Element) (element.Ele
 			return el, err
 		}
 	} else {
-		service := util.CreateExporterDeployment(el.Redis, el.OwnerRefs, password)
+		service := util.CreateExporterDeployment(el.Redis, el.OwnerRefs, passwordVersion)
 
 		PrintOBJ("create exporterDeployment object", el.Redis, service)
 
@@ -705,6 +631,35 @@ func (r *RedisEnsurer) EnsureExporterDeployment(el element.Element) (element.Ele
 	return el, nil
 }
 
+// ensureExporterSecret creates or updates the Secret the exporter reads the password from, and returns its
+// resourceVersion
+func (r *RedisEnsurer) ensureExporterSecret(el element.Element, password string) (string, error) {
+	desiredSecret := util.CreateExporterSecret(el.Redis, el.OwnerRefs, password)
+
+	existingSecret, err := r.K8SService.GetSecret(el.Redis.Namespace, desiredSecret.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+		Info(r.Log, "create ExporterSecret", el.Redis)
+		if err := r.K8SService.Create(context.Background(), desiredSecret); err != nil {
+			return "", err
+		}
+		return desiredSecret.ResourceVersion, nil
+	}
+
+	if util.ExporterSecretEqual(desiredSecret, existingSecret) {
+		return existingSecret.ResourceVersion, nil
+	}
+
+	Info(r.Log, "start update ExporterSecret...", el.Redis)
+	existingSecret.Data = desiredSecret.Data
+	if err := r.K8SService.Update(context.Background(), existingSecret); err != nil {
+		return "", err
+	}
+	return existingSecret.ResourceVersion, nil
+}
+
 // DealResource 将pod的资源量的单位统一
 func DealResource(requirements *v1.PodSpec) {
 	for _, container := range requirements.Containers {
@@ -720,15 +675,6 @@ func DealResource(requirements *v1.PodSpec) {
 
 // --- EnsureSentinelHeadlessService ---
 func (r *RedisEnsurer) EnsureSentinelHeadlessService(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	for i := 0; i < int(el.Redis.Spec.Sentinel.Replicas); i++ {
 		el, err = r.ensureSentinelHeadlessService(el, i)
@@ -742,15 +688,6 @@ func (r *RedisEnsurer) EnsureSentinelHeadlessService(el element.Element) (elemen
 }
 
 func (r *RedisEnsurer) ensureSentinelHeadlessService(el element.Element, index int) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	headlessServiceName := util.GetSentinelHeadlessServiceNameByIndex(el.Redis, index)
 
 	currentSentinelHeadlessStatus := roav1.RedisStatusItem{}
@@ -792,15 +729,6 @@ func (r *RedisEnsurer) ensureSentinelHeadlessService(el element.Element, index i
 
 // --- EnsureRedisHeadlessService ---
 func (r *RedisEnsurer) EnsureRedisHeadlessService(el element.Element) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	err := util.NilError()
 	for i := 0; i < int(el.Redis.Spec.Redis.Replicas); i++ {
 		el, err = r.ensureRedisHeadlessService(el, i)
@@ -813,15 +741,6 @@ func (r *RedisEnsurer) EnsureRedisHeadlessService(el element.Element) (element.E
 }
 
 func (r *RedisEnsurer) ensureRedisHeadlessService(el element.Element, index int) (element.Element, error) {
-	if el.NeedReLoad {
-		redisNew, err := r.K8SService.Get(el.Req)
-		if err != nil {
-			return el, err
-		}
-		el.Redis = redisNew
-	}
-	el.NeedReLoad = false
-
 	headlessServiceName := util.GetRedisHeadlessServiceNameByIndex(el.Redis, index)
 
 	currentRedisHeadlessStatus := roav1.RedisStatusItem{}
@@ -860,3 +779,90 @@ func (r *RedisEnsurer) ensureRedisHeadlessService(el element.Element, index int)
 
 	return el, nil
 }
+
+// --- EnsureRedisRoleServices ---
+// the master (read/write) and replicas (read-only) Services select on the role labels of the redis pods
+func (r *RedisEnsurer) EnsureRedisRoleServices(el element.Element) (element.Element, error) {
+	if err := r.ensureRedisRoleService(el, util.CreateRedisMasterService(el.Redis, el.OwnerRefs)); err != nil {
+		return el, err
+	}
+	if err := r.ensureRedisRoleService(el, util.CreateRedisReplicasService(el.Redis, el.OwnerRefs)); err != nil {
+		return el, err
+	}
+	return el, nil
+}
+
+func (r *RedisEnsurer) ensureRedisRoleService(el element.Element, desiredService *v1.Service) error {
+	service, err := r.K8SService.GetService(el.Redis.Namespace, desiredService.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		PrintOBJ("create RedisRoleService object", el.Redis, desiredService)
+
+		// ...and create it on the cluster
+		return r.K8SService.Create(context.Background(), desiredService)
+	}
+
+	PrintOBJ("get RedisRoleService", el.Redis, service)
+
+	if util.RedisRoleServiceEqual(desiredService, service) {
+		return nil
+	}
+
+	Info(r.Log, desiredService.Name+" Spec not equal", el.Redis)
+	service.Spec.Selector = desiredService.Spec.Selector
+	service.Spec.Ports = desiredService.Spec.Ports
+	return r.K8SService.Update(context.Background(), service)
+}
+
+// --- EnsurePodDisruptionBudget ---
+func (r *RedisEnsurer) EnsureRedisPodDisruptionBudget(el element.Element) (element.Element, error) {
+	desiredPdb := util.CreateRedisPodDisruptionBudget(el.Redis, el.OwnerRefs)
+	return el, r.ensurePodDisruptionBudget(el, desiredPdb, el.Redis.Spec.Redis.PodDisruptionBudget.Enabled)
+}
+
+func (r *RedisEnsurer) EnsureSentinelPodDisruptionBudget(el element.Element) (element.Element, error) {
+	desiredPdb := util.CreateSentinelPodDisruptionBudget(el.Redis, el.OwnerRefs)
+	return el, r.ensurePodDisruptionBudget(el, desiredPdb, el.Redis.Spec.Sentinel.PodDisruptionBudget.Enabled)
+}
+
+// ensurePodDisruptionBudget creates or updates the PodDisruptionBudget, and deletes the one of the Redis once it
+// is disabled
+func (r *RedisEnsurer) ensurePodDisruptionBudget(el element.Element, desiredPdb *policyv1.PodDisruptionBudget, enabled bool) error {
+	pdb, err := r.K8SService.GetPodDisruptionBudget(el.Redis.Namespace, desiredPdb.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if !enabled {
+			return nil
+		}
+
+		PrintOBJ("create PodDisruptionBudget object", el.Redis, desiredPdb)
+
+		// ...and create it on the cluster
+		return r.K8SService.Create(context.Background(), desiredPdb)
+	}
+
+	PrintOBJ("get PodDisruptionBudget", el.Redis, pdb)
+
+	if !enabled {
+		if !metav1.IsControlledBy(pdb, el.Redis) {
+			return nil
+		}
+		Info(r.Log, desiredPdb.Name+" disabled, delete it", el.Redis)
+		return r.K8SService.Delete(context.Background(), pdb)
+	}
+
+	if util.PodDisruptionBudgetEqual(desiredPdb, pdb) {
+		return nil
+	}
+
+	Info(r.Log, desiredPdb.Name+" Spec not equal", el.Redis)
+	pdb.Spec.MinAvailable = desiredPdb.Spec.MinAvailable
+	pdb.Spec.MaxUnavailable = desiredPdb.Spec.MaxUnavailable
+	pdb.Spec.Selector = desiredPdb.Spec.Selector
+	return r.K8SService.Update(context.Background(), pdb)
+}
@@ -15,6 +15,10 @@ type RedisDeleteEnsure interface {
 	DeleteEnsureRedisPods(el element.Element) (element.Element, error)
 	DeleteEnsureSentinelPvcs(el element.Element) (element.Element, error)
 	DeleteEnsureRedisPvcs(el element.Element) (element.Element, error)
+	DeleteEnsureRedisStatefulSetByIndex(el element.Element, index int) (element.Element, error)
+	DeleteEnsureRedisResourcesByIndex(el element.Element, index int) (element.Element, error)
+	DeleteEnsureSentinelStatefulSetByIndex(el element.Element, index int) (element.Element, error)
+	DeleteEnsureSentinelResourcesByIndex(el element.Element, index int) (element.Element, error)
 }
 
 type RedisDeleteEnsurer struct {
@@ -123,8 +127,11 @@ func (r RedisDeleteEnsurer) DeleteEnsureSentinelPvcs(el element.Element) (elemen
 	err := util.NilError()
 
 	labels := util.GetSentinelLabels(el.Redis)
+	kept := func(pvcName string) bool {
+		return util.IsSentinelPvcKeptAfterDeletion(el.Redis, pvcName)
+	}
 
-	if err = r.deleteListPvc(el.Redis.Namespace, labels); err != nil {
+	if err = r.deleteListPvc(el.Redis.Namespace, labels, kept); err != nil {
 		return el, err
 	}
 
@@ -136,15 +143,19 @@ func (r RedisDeleteEnsurer) DeleteEnsureRedisPvcs(el element.Element) (element.E
 	err := util.NilError()
 
 	labels := util.GetRedisLabels(el.Redis)
+	kept := func(pvcName string) bool {
+		return util.IsRedisPvcKeptAfterDeletion(el.Redis, pvcName)
+	}
 
-	if err = r.deleteListPvc(el.Redis.Namespace, labels); err != nil {
+	if err = r.deleteListPvc(el.Redis.Namespace, labels, kept); err != nil {
 		return el, err
 	}
 
 	return el, nil
 }
 
-func (r RedisDeleteEnsurer) deleteListPvc(namespace string, labels map[string]string) error {
+// deleteListPvc deletes the PersistentVolumeClaims of every VolumeClaimTemplate, but the ones kept after deletion
+func (r RedisDeleteEnsurer) deleteListPvc(namespace string, labels map[string]string, kept func(pvcName string) bool) error {
 	pvcList, err := r.K8SService.ListPvc(namespace, labels)
 	if err != nil {
 		return err
@@ -155,6 +166,9 @@ func (r RedisDeleteEnsurer) deleteListPvc(namespace string, labels map[string]st
 	}
 
 	for _, pvc := range pvcList.Items {
+		if kept(pvc.Name) {
+			continue
+		}
 		if err = r.K8SService.Delete(context.Background(), &pvc); err != nil {
 			return err
 		}
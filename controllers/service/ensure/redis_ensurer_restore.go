@@ -0,0 +1,124 @@
+package ensure
+
+import (
+	"context"
+	"fmt"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/backup"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/url"
+	"time"
+)
+
+// restoreURLExpires is how long the presigned url of a backup is valid, the max of S3
+const restoreURLExpires = 7 * 24 * time.Hour
+
+// --- EnsureRedisRestoreSecret ---
+// the restore only happens when the StatefulSet of the master is created, Status.Restore.Phase is
+// Restoring from then until the checker sees the master has loaded the data
+func (r *RedisEnsurer) EnsureRedisRestoreSecret(el element.Element) (element.Element, error) {
+	log := r.Log.WithValues("RedisEnsurer", "EnsureRedisRestoreSecret")
+
+	if el.Redis.Spec.Restore.From.IsEmpty() {
+		return el, nil
+	}
+
+	secretName := util.GetRedisRestoreSecretName(el.Redis)
+	if el.Redis.Status.Restore.Phase == roav1.RestoreComplete {
+		secret, err := r.K8SService.GetSecret(el.Redis.Namespace, secretName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return el, nil
+			}
+			return el, err
+		}
+		Info(log, "delete RedisRestoreSecret", el.Redis)
+		if err := r.K8SService.Delete(context.Background(), secret); err != nil && !errors.IsNotFound(err) {
+			return el, err
+		}
+		return el, nil
+	}
+
+	if el.Redis.Status.Restore.Phase == "" {
+		_, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, util.GetRedisNameByIndex(el.Redis, 0))
+		if err == nil {
+			// the master was created without a restore, e.g. Spec.Restore is added later
+			return el, nil
+		} else if !errors.IsNotFound(err) {
+			return el, err
+		}
+	}
+
+	currentStatus, downloadURL, err := r.getRestoreSource(el.Redis)
+	if err != nil {
+		return el, err
+	}
+
+	_, err = r.K8SService.GetSecret(el.Redis.Namespace, secretName)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return el, err
+		}
+		secret := util.CreateRedisRestoreSecret(el.Redis, el.OwnerRefs, downloadURL)
+		Info(log, "create RedisRestoreSecret", el.Redis)
+		if err := r.K8SService.Create(context.Background(), secret); err != nil {
+			return el, err
+		}
+	}
+
+	if el.Redis.Status.Restore.Phase == "" {
+		Info(log, "start restore "+currentStatus.Key+currentStatus.URL, el.Redis)
+		currentStatus.Phase = roav1.Restoring
+		currentStatus.StartTime = &metav1.Time{Time: time.Now()}
+		// the phase is patched before the StatefulSet of the master is created with the restore, otherwise a
+		// failed patch at the end of the reconcile would take it for a master created without a restore
+		observedStatus := *el.Redis.Status.DeepCopy()
+		el.Redis.Status.Restore = currentStatus
+		status := *el.Redis.Status.DeepCopy()
+		if err := r.K8SService.PatchStatus(el.Redis, observedStatus); err != nil {
+			return el, err
+		}
+		// the patch returns the status as it is stored, without the changes of the reconcile so far
+		el.Redis.Status = status
+	}
+
+	return el, nil
+}
+
+// getRestoreSource returns the status of the restore and the url to download, the url of a RedisBackup is presigned
+func (r *RedisEnsurer) getRestoreSource(rf *roav1.Redis) (roav1.RestoreState, string, error) {
+	from := rf.Spec.Restore.From
+	if from.BackupName == "" {
+		u, err := url.Parse(from.URL)
+		if err != nil {
+			return roav1.RestoreState{}, "", err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return roav1.RestoreState{}, "", fmt.Errorf("Spec.Restore.From.URL %q must start with http:// or https://", from.URL)
+		}
+		// the query may have a signature
+		u.RawQuery = ""
+		return roav1.RestoreState{URL: u.String()}, from.URL, nil
+	}
+
+	redisBackup, err := r.K8SService.GetRedisBackup(rf.Namespace, from.BackupName)
+	if err != nil {
+		return roav1.RestoreState{}, "", err
+	}
+	key := from.Key
+	if key == "" {
+		if len(redisBackup.Status.Backups) == 0 {
+			return roav1.RestoreState{}, "", fmt.Errorf("RedisBackup %s has no successful backup", from.BackupName)
+		}
+		key = redisBackup.Status.Backups[0].Key
+	}
+
+	s3, err := backup.NewS3ClientFromStorage(r.K8SService, rf.Namespace, redisBackup.Spec.Storage.S3)
+	if err != nil {
+		return roav1.RestoreState{}, "", err
+	}
+	return roav1.RestoreState{BackupName: from.BackupName, Key: key}, s3.PresignGetObject(key, restoreURLExpires), nil
+}
@@ -0,0 +1,75 @@
+package ensure
+
+import (
+	"context"
+	"errors"
+	"github.com/go-logr/logr"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+// restoreK8sService has no StatefulSet and no Secret, PatchStatus stores the patch and returns the stored status
+// like the api server does
+type restoreK8sService struct {
+	k8s.Services
+	stored   roav1.RedisStatus
+	patchErr error
+}
+
+func (s *restoreK8sService) GetStatefulSet(namespace, name string) (*appsv1.StatefulSet, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "statefulsets"}, name)
+}
+
+func (s *restoreK8sService) GetSecret(namespace, name string) (*v1.Secret, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+
+func (s *restoreK8sService) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return nil
+}
+
+func (s *restoreK8sService) PatchStatus(redis *roav1.Redis, observedStatus roav1.RedisStatus) error {
+	if s.patchErr != nil {
+		return s.patchErr
+	}
+	s.stored.Restore = redis.Status.Restore
+	redis.Status = *s.stored.DeepCopy()
+	return nil
+}
+
+func TestEnsureRedisRestoreSecret(t *testing.T) {
+	rf := &roav1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "redis-sample", Namespace: "default"}}
+	rf.Spec.Restore.From.URL = "https://minio.example.com/backups/dump.rdb?X-Amz-Signature=abc"
+	// a status the reconcile changed before, it is patched at the end
+	rf.Status.ObservedGeneration = 2
+
+	k8sService := &restoreK8sService{}
+	r := NewRedisEnsurer(k8sService, logr.Discard())
+	el, err := r.EnsureRedisRestoreSecret(element.Element{Redis: rf})
+	if err != nil {
+		t.Fatalf("EnsureRedisRestoreSecret error: %s", err)
+	}
+	if k8sService.stored.Restore.Phase != roav1.Restoring || k8sService.stored.Restore.URL != "https://minio.example.com/backups/dump.rdb" {
+		t.Fatalf("stored restore = %+v; expected Restoring before the StatefulSet is created", k8sService.stored.Restore)
+	}
+	if el.Redis.Status.Restore.Phase != roav1.Restoring || el.Redis.Status.ObservedGeneration != 2 {
+		t.Fatalf("status = %+v; expected the restore and the changes of the reconcile", el.Redis.Status)
+	}
+
+	// the StatefulSet of the master is not created without the phase
+	rf = rf.DeepCopy()
+	rf.Status = roav1.RedisStatus{}
+	k8sService = &restoreK8sService{patchErr: errors.New("conflict")}
+	r = NewRedisEnsurer(k8sService, logr.Discard())
+	if _, err := r.EnsureRedisRestoreSecret(element.Element{Redis: rf}); err == nil {
+		t.Fatalf("EnsureRedisRestoreSecret should fail when the phase can not be patched")
+	}
+}
@@ -0,0 +1,127 @@
+package ensure
+
+import (
+	"context"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// --- DeleteEnsureRedisStatefulSetByIndex ---
+func (r RedisDeleteEnsurer) DeleteEnsureRedisStatefulSetByIndex(el element.Element, index int) (element.Element, error) {
+	statefulSet, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, util.GetRedisNameByIndex(el.Redis, index))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return el, nil
+		}
+		return el, err
+	}
+
+	Info(r.Log, "delete RedisStatefulSet "+statefulSet.Name, el.Redis)
+	if err = r.deleteIgnoreNotFound(statefulSet); err != nil {
+		return el, err
+	}
+	return el, nil
+}
+
+// --- DeleteEnsureRedisResourcesByIndex ---
+// the ConfigMap is deleted last, it is how the index is found again if a deletion fails
+func (r RedisDeleteEnsurer) DeleteEnsureRedisResourcesByIndex(el element.Element, index int) (element.Element, error) {
+	service, err := r.K8SService.GetService(el.Redis.Namespace, util.GetRedisHeadlessServiceNameByIndex(el.Redis, index))
+	if err == nil {
+		Info(r.Log, "delete RedisHeadlessService "+service.Name, el.Redis)
+		if err = r.deleteIgnoreNotFound(service); err != nil {
+			return el, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return el, err
+	}
+
+	if err = r.deletePvcs(el, util.GetRedisPvcNamesByIndex(el.Redis, index)); err != nil {
+		return el, err
+	}
+
+	configMap, err := r.K8SService.GetConfigMap(el.Redis.Namespace, util.GetRedisConfigMapNameByIndex(el.Redis, index))
+	if err == nil {
+		Info(r.Log, "delete RedisSlaveConfigMap "+configMap.Name, el.Redis)
+		if err = r.deleteIgnoreNotFound(configMap); err != nil {
+			return el, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return el, err
+	}
+
+	return el, nil
+}
+
+// --- DeleteEnsureSentinelStatefulSetByIndex ---
+func (r RedisDeleteEnsurer) DeleteEnsureSentinelStatefulSetByIndex(el element.Element, index int) (element.Element, error) {
+	statefulSet, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, util.GetSentinelNameByIndex(el.Redis, index))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return el, nil
+		}
+		return el, err
+	}
+
+	Info(r.Log, "delete SentinelStatefulSet "+statefulSet.Name, el.Redis)
+	if err = r.deleteIgnoreNotFound(statefulSet); err != nil {
+		return el, err
+	}
+	return el, nil
+}
+
+// --- DeleteEnsureSentinelResourcesByIndex ---
+// the ConfigMap is deleted last, it is how the index is found again if a deletion fails
+func (r RedisDeleteEnsurer) DeleteEnsureSentinelResourcesByIndex(el element.Element, index int) (element.Element, error) {
+	service, err := r.K8SService.GetService(el.Redis.Namespace, util.GetSentinelHeadlessServiceNameByIndex(el.Redis, index))
+	if err == nil {
+		Info(r.Log, "delete SentinelHeadlessService "+service.Name, el.Redis)
+		if err = r.deleteIgnoreNotFound(service); err != nil {
+			return el, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return el, err
+	}
+
+	if err = r.deletePvcs(el, util.GetSentinelPvcNamesByIndex(el.Redis, index)); err != nil {
+		return el, err
+	}
+
+	configMap, err := r.K8SService.GetConfigMap(el.Redis.Namespace, util.GetSentinelConfigMapNameByIndex(el.Redis, index))
+	if err == nil {
+		Info(r.Log, "delete SentinelConfigMap "+configMap.Name, el.Redis)
+		if err = r.deleteIgnoreNotFound(configMap); err != nil {
+			return el, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return el, err
+	}
+
+	return el, nil
+}
+
+func (r RedisDeleteEnsurer) deletePvcs(el element.Element, names []string) error {
+	for _, name := range names {
+		pvc, err := r.K8SService.GetPvc(el.Redis.Namespace, name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		Info(r.Log, "delete Pvc "+pvc.Name, el.Redis)
+		if err = r.deleteIgnoreNotFound(pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r RedisDeleteEnsurer) deleteIgnoreNotFound(obj client.Object) error {
+	if err := r.K8SService.Delete(context.Background(), obj); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
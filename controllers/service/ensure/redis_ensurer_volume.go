@@ -0,0 +1,146 @@
+package ensure
+
+import (
+	"context"
+	"fmt"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/element"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// --- EnsureVolumeExpansion ---
+// the VolumeClaimTemplates of a StatefulSet are immutable. When the storage of the spec is increased the
+// PersistentVolumeClaims are expanded, and the StatefulSet is recreated with orphan deletion once its pod
+// template is rolled out, so that its pods are kept
+func (r *RedisEnsurer) EnsureVolumeExpansion(el element.Element) (element.Element, error) {
+	var desiredStatefulSets []*appsv1.StatefulSet
+	if el.Redis.IsClusterMode() {
+		for i := 0; i < int(el.Redis.Spec.Cluster.Shards); i++ {
+			desiredStatefulSets = append(desiredStatefulSets, util.CreateRedisClusterStatefulSetObjByIndex(el.Redis, el.OwnerRefs, i))
+		}
+	} else {
+		for i := 0; i < int(el.Redis.Spec.Redis.Replicas); i++ {
+			desiredStatefulSets = append(desiredStatefulSets, util.CreateRedisStatefulSetObjByIndex(el.Redis, el.OwnerRefs, i))
+		}
+		for i := 0; i < int(el.Redis.Spec.Sentinel.Replicas); i++ {
+			desiredStatefulSets = append(desiredStatefulSets, util.CreateSentinelStatefulSetObjByIndex(el.Redis, el.OwnerRefs, i))
+		}
+	}
+
+	pvcList, err := r.K8SService.ListPvc(el.Redis.Namespace, util.GetInstanceLabels(el.Redis.Name))
+	if err != nil {
+		return el, err
+	}
+
+	var states []roav1.VolumeExpansionState
+	var recreating []roav1.RecreatingStatefulSet
+	for _, desired := range desiredStatefulSets {
+		existing, err := r.K8SService.GetStatefulSet(el.Redis.Namespace, desired.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return el, err
+		}
+		if err != nil || existing.DeletionTimestamp != nil {
+			// the orphan deletion is in progress, or the StatefulSet is not created yet, e.g. the sentinels during
+			// a restore. Ensure creates it from the kept object once it is gone
+			for _, kept := range el.Redis.Status.RecreatingStatefulSets {
+				if kept.Name == desired.Name {
+					recreating = append(recreating, kept)
+				}
+			}
+			continue
+		}
+		statefulSetStates, recreated, err := r.expandStatefulSetVolumes(el, existing, desired, pvcList.Items)
+		if err != nil {
+			return el, err
+		}
+		states = append(states, statefulSetStates...)
+		if recreated != nil {
+			recreating = append(recreating, *recreated)
+		}
+	}
+	el.Redis.Status.VolumeExpansions = states
+	el.Redis.Status.RecreatingStatefulSets = recreating
+	if len(recreating) > 0 {
+		names := make([]string, 0, len(recreating))
+		for _, kept := range recreating {
+			names = append(names, kept.Name)
+		}
+		el.NeedReCheckError = append(el.NeedReCheckError, fmt.Errorf("recreating the StatefulSets %s with the expanded VolumeClaimTemplates", strings.Join(names, ", ")))
+	}
+
+	return el, nil
+}
+
+// expandStatefulSetVolumes expands the PersistentVolumeClaims of the StatefulSet to the storage of desired, and
+// returns the ones not expanded yet, and the status of the StatefulSet when it is deleted to be recreated
+func (r *RedisEnsurer) expandStatefulSetVolumes(el element.Element, existing, desired *appsv1.StatefulSet, pvcs []v1.PersistentVolumeClaim) ([]roav1.VolumeExpansionState, *roav1.RecreatingStatefulSet, error) {
+	expanded := util.GetExpandedVolumeClaimTemplates(existing, desired)
+
+	var states []roav1.VolumeExpansionState
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		templateName, ok := util.GetStatefulSetPvcTemplate(existing, pvc)
+		if !ok {
+			continue
+		}
+		if request, ok := expanded[templateName]; ok && pvc.Spec.Resources.Requests.Storage().Cmp(request) < 0 {
+			Info(r.Log, "expand PersistentVolumeClaim "+pvc.Name+" from "+pvc.Spec.Resources.Requests.Storage().String()+" to "+request.String(), el.Redis)
+			pvc = pvc.DeepCopy()
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = v1.ResourceList{}
+			}
+			pvc.Spec.Resources.Requests[v1.ResourceStorage] = request
+			if err := r.K8SService.Update(context.Background(), pvc); err != nil {
+				return nil, nil, err
+			}
+		}
+		if state, ok := util.GetVolumeExpansionState(pvc); ok {
+			states = append(states, state)
+		}
+	}
+
+	if len(expanded) == 0 {
+		return states, nil, nil
+	}
+	// the recreated StatefulSet keeps the pod template, a change of it is rolled out first
+	if util.GetSpecHash(existing) != util.GetSpecHash(desired) {
+		Info(r.Log, "StatefulSet "+existing.Name+" has expanded volumes, recreate it after the rolling update", el.Redis)
+		return states, nil, nil
+	}
+	recreated, err := r.recreateStatefulSet(el, existing, util.CreateStatefulSetObjWithExpandedVolumes(existing, expanded))
+	return states, recreated, err
+}
+
+// recreateStatefulSet deletes the StatefulSet without its pods, it is kept in the status and created again by a
+// later Ensure once the deletion is done, the pods are adopted
+func (r *RedisEnsurer) recreateStatefulSet(el element.Element, existing, recreated *appsv1.StatefulSet) (*roav1.RecreatingStatefulSet, error) {
+	kept, err := util.NewRecreatingStatefulSet(recreated)
+	if err != nil {
+		return nil, err
+	}
+	Info(r.Log, "recreate StatefulSet "+existing.Name+" with the expanded VolumeClaimTemplates", el.Redis)
+	if err := r.K8SService.Delete(context.Background(), existing, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+		return nil, err
+	}
+	return &kept, nil
+}
+
+// createStatefulSet creates a missing StatefulSet, from the object kept in the status when it was deleted to expand
+// its volumes, so that its pods are adopted without a restart
+func (r *RedisEnsurer) createStatefulSet(el element.Element, desired *appsv1.StatefulSet) error {
+	kept, ok, err := util.GetRecreatingStatefulSet(el.Redis, desired.Name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		PrintOBJ("create StatefulSet object with expanded volumes", el.Redis, kept)
+		return r.K8SService.Create(context.Background(), kept)
+	}
+	return r.K8SService.Create(context.Background(), desired)
+}
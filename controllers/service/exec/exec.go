@@ -2,15 +2,19 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
 )
 
 // IExec is an injectable interface for running remote exec commands.
@@ -18,12 +22,18 @@ type IExec interface {
 	// ExecCommandInPodSet exec cmd in pod set.
 	ExecCommandInPodSet(podSet []*corev1.Pod, cmd ...string) error
 	ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName string, cmd string) (string, string, error)
+	// ExecCommandInContainerToWriter exec cmd in the container and copy its stdout to the writer,
+	// for the output too large to be kept in memory, e.g. a backup file.
+	ExecCommandInContainerToWriter(namespace, podName, containerName string, stdout io.Writer, cmd ...string) (string, error)
+	// WithContext returns an IExec whose commands are stopped when the ctx is done.
+	WithContext(ctx context.Context) IExec
 }
 
 type remoteExec struct {
 	restGVKClient rest.Interface
 	logger        logr.Logger
 	config        *rest.Config
+	ctx           context.Context
 }
 
 // NewRemoteExec returns a new IExec which will exec remote cmd.
@@ -32,9 +42,17 @@ func NewRemoteExec(restGVKClient rest.Interface, config *rest.Config, logger log
 		restGVKClient: restGVKClient,
 		logger:        logger,
 		config:        config,
+		ctx:           context.Background(),
 	}
 }
 
+// WithContext implements IExec interface.
+func (e *remoteExec) WithContext(ctx context.Context) IExec {
+	execer := *e
+	execer.ctx = ctx
+	return &execer
+}
+
 // ExecOptions passed to ExecWithOptions.
 type ExecOptions struct {
 	Command []string
@@ -103,6 +121,29 @@ func (e *remoteExec) ExecCommandInContainerWithFullOutputBySh(namespace, podName
 	})
 }
 
+// ExecCommandInContainerToWriter implements IExec interface, it returns the stderr of the command.
+func (e *remoteExec) ExecCommandInContainerToWriter(namespace, podName, containerName string, stdout io.Writer, cmd ...string) (string, error) {
+	req := e.restGVKClient.Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		Param("container", containerName)
+
+	req.VersionedParams(&corev1.PodExecOptions{
+		Container: containerName,
+		Command:   cmd,
+		Stdin:     false,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+	}, scheme.ParameterCodec)
+
+	var stderr bytes.Buffer
+	err := execute(e.ctx, "POST", req.URL(), e.config, nil, stdout, &stderr, false)
+	return strings.TrimSpace(stderr.String()), err
+}
+
 // ExecWithOptions executes a command in the specified container,
 // returning stdout, stderr and error. `options` allowed for
 // additional parameters to be passed.
@@ -126,7 +167,7 @@ func (e *remoteExec) ExecWithOptions(options ExecOptions) (string, string, error
 	}, scheme.ParameterCodec)
 
 	var stdout, stderr bytes.Buffer
-	err := execute("POST", req.URL(), e.config, options.Stdin, &stdout, &stderr, tty)
+	err := execute(e.ctx, "POST", req.URL(), e.config, options.Stdin, &stdout, &stderr, tty)
 
 	if options.PreserveWhitespace {
 		return stdout.String(), stderr.String(), err
@@ -134,15 +175,60 @@ func (e *remoteExec) ExecWithOptions(options ExecOptions) (string, string, error
 	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
 }
 
-func execute(method string, url *url.URL, config *rest.Config, stdin io.Reader, stdout, stderr io.Writer, tty bool) error {
-	exec, err := remotecommand.NewSPDYExecutor(config, method, url)
+// execute streams the exec, the spdy connection is closed when the ctx is done so that a command that does not
+// return, e.g. on a hung pod, does not keep the stream and its goroutines forever
+func execute(ctx context.Context, method string, url *url.URL, config *rest.Config, stdin io.Reader, stdout, stderr io.Writer, tty bool) error {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
 	if err != nil {
 		return err
 	}
-	return exec.Stream(remotecommand.StreamOptions{
+	exec, err := remotecommand.NewSPDYExecutorForTransports(
+		&contextRoundTripper{ctx: ctx, roundTripper: transport},
+		&contextUpgrader{ctx: ctx, upgrader: upgrader},
+		method, url,
+	)
+	if err != nil {
+		return err
+	}
+	err = exec.Stream(remotecommand.StreamOptions{
 		Stdin:  stdin,
 		Stdout: stdout,
 		Stderr: stderr,
 		Tty:    tty,
 	})
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// contextRoundTripper sends the upgrade request with the ctx, it bounds the dial to the api server
+type contextRoundTripper struct {
+	ctx          context.Context
+	roundTripper http.RoundTripper
+}
+
+func (t *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.roundTripper.RoundTrip(req.WithContext(t.ctx))
+}
+
+// contextUpgrader closes the upgraded connection when the ctx is done, it ends the streams of the exec
+type contextUpgrader struct {
+	ctx      context.Context
+	upgrader spdy.Upgrader
+}
+
+func (u *contextUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.upgrader.NewConnection(resp)
+	if err != nil || u.ctx.Done() == nil {
+		return conn, err
+	}
+	go func() {
+		select {
+		case <-u.ctx.Done():
+			conn.Close()
+		case <-conn.CloseChan():
+		}
+	}()
+	return conn, nil
 }
@@ -3,8 +3,8 @@ package k8s
 import (
 	"context"
 	"github.com/go-logr/logr"
-	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	apl "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ref "k8s.io/client-go/tools/reference"
@@ -13,9 +13,8 @@ import (
 
 type ConfigMap interface {
 	GetConfigMap(namespace string, name string) (*corev1.ConfigMap, error)
+	ListConfigMaps(namespace string, labels map[string]string) (*corev1.ConfigMapList, error)
 	GetConfigMapObjectReference(configMap *corev1.ConfigMap) corev1.ObjectReference
-	UpdateSentinelState(redis *roav1.Redis, currentStatus roav1.SentinelState) error
-	UpdateRedisState(redis *roav1.Redis, currentStatus roav1.RedisState) error
 }
 
 type ConfigMapService struct {
@@ -46,6 +45,20 @@ func (c *ConfigMapService) GetConfigMap(namespace string, name string) (*corev1.
 	return configMap, nil
 }
 
+func (c *ConfigMapService) ListConfigMaps(namespace string, labels map[string]string) (*corev1.ConfigMapList, error) {
+	var configMapList = &corev1.ConfigMapList{}
+	if err := c.KubeClient.List(context.Background(),
+		configMapList,
+		&client.ListOptions{
+			Namespace:     namespace,
+			LabelSelector: apl.SelectorFromSet(labels),
+		},
+	); err != nil {
+		return nil, err
+	}
+	return configMapList, nil
+}
+
 func (c *ConfigMapService) GetConfigMapObjectReference(configMap *corev1.ConfigMap) corev1.ObjectReference {
 
 	if configMap == nil {
@@ -63,19 +76,3 @@ func (c *ConfigMapService) GetConfigMapObjectReference(configMap *corev1.ConfigM
 
 	return *referenceRef
 }
-
-func (c *ConfigMapService) UpdateSentinelState(redis *roav1.Redis, currentStatus roav1.SentinelState) error {
-	redis.Status.Sentinel = currentStatus
-	if err := c.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (c *ConfigMapService) UpdateRedisState(redis *roav1.Redis, currentStatus roav1.RedisState) error {
-	redis.Status.Redis = currentStatus
-	if err := c.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
-	}
-	return nil
-}
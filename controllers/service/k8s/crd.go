@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/go-logr/logr"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -12,10 +13,8 @@ import (
 type CRD interface {
 	GetOnly(req ctrl.Request) (*roav1.Redis, error)
 	Get(req ctrl.Request) (*roav1.Redis, error)
-	UpdateRedisConfigStatus(redis *roav1.Redis, currentStatus roav1.RedisConfig) error
-	UpdateSentinelConfigStatus(redis *roav1.Redis, currentStatus roav1.SentinelConfig) error
-	UpdateRedisPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error
-	UpdateSentinelPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error
+	PatchStatus(redis *roav1.Redis, observedStatus roav1.RedisStatus) error
+	GetRedisBackup(namespace, name string) (*roav1.RedisBackup, error)
 }
 
 type CRDService struct {
@@ -62,34 +61,21 @@ func (r *CRDService) Get(req ctrl.Request) (*roav1.Redis, error) {
 	return redis, nil
 }
 
-func (r *CRDService) UpdateRedisConfigStatus(redis *roav1.Redis, currentStatus roav1.RedisConfig) error {
-	redis.Status.Redis.RedisCustomConfig = currentStatus
-	if err := r.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (r *CRDService) UpdateSentinelConfigStatus(redis *roav1.Redis, currentStatus roav1.SentinelConfig) error {
-	redis.Status.Sentinel.SentinelCustomConfig = currentStatus
-	if err := r.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
-	}
-	return nil
+// PatchStatus writes the changes of the status since observedStatus with a merge patch, which needs no
+// resourceVersion and so does not conflict with the updates of the spec
+func (r *CRDService) PatchStatus(redis *roav1.Redis, observedStatus roav1.RedisStatus) error {
+	observed := redis.DeepCopy()
+	observed.Status = observedStatus
+	return r.KubeClient.Status().Patch(context.Background(), redis, client.MergeFrom(observed))
 }
 
-func (r *CRDService) UpdateRedisPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error {
-	redis.Status.Redis.RedisPassword = currentStatus
-	if err := r.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (r *CRDService) UpdateSentinelPasswordStatus(redis *roav1.Redis, currentStatus roav1.RedisPassword) error {
-	redis.Status.Sentinel.SentinelPassword = currentStatus
-	if err := r.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
+func (r *CRDService) GetRedisBackup(namespace, name string) (*roav1.RedisBackup, error) {
+	redisBackup := &roav1.RedisBackup{}
+	if err := r.KubeClient.Get(context.Background(),
+		types.NamespacedName{Namespace: namespace, Name: name},
+		redisBackup,
+	); err != nil {
+		return nil, err
 	}
-	return nil
+	return redisBackup, nil
 }
@@ -3,7 +3,6 @@ package k8s
 import (
 	"context"
 	"github.com/go-logr/logr"
-	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -16,7 +15,6 @@ type Deployment interface {
 	GetDeployment(namespace, name string) (*appsv1.Deployment, error)
 	GetDeploymentObjectReference(deployment *appsv1.Deployment) corev1.ObjectReference
 	GetDeploymentPods(namespace, name string) (*corev1.PodList, error)
-	UpdateExporterState(redis *roav1.Redis, currentStatus roav1.ExporterState) error
 }
 
 type DeploymentService struct {
@@ -74,11 +72,3 @@ func (d DeploymentService) GetDeploymentPods(namespace, name string) (*corev1.Po
 	}
 	return ListPods(d.KubeClient, namespace, labels)
 }
-
-func (d DeploymentService) UpdateExporterState(redis *roav1.Redis, currentStatus roav1.ExporterState) error {
-	redis.Status.Exporter = currentStatus
-	if err := d.KubeClient.Status().Update(context.Background(), redis); err != nil {
-		return err
-	}
-	return nil
-}
@@ -18,6 +18,7 @@ type Services interface {
 	Service
 	Pv
 	Pvc
+	PodDisruptionBudget
 }
 
 type services struct {
@@ -31,19 +32,21 @@ type services struct {
 	Service
 	Pv
 	Pvc
+	PodDisruptionBudget
 }
 
 func New(kubeClient client.Client, log logr.Logger, scheme *runtime.Scheme) Services {
 	return &services{
-		All:         NewAllService(kubeClient, log),
-		CRD:         NewCRDService(kubeClient, log),
-		Pod:         NewPodService(kubeClient, log, scheme),
-		Secret:      NewSecretService(kubeClient, log),
-		Deployment:  NewDeploymentService(kubeClient, log, scheme),
-		ConfigMap:   NewConfigMapService(kubeClient, log, scheme),
-		StatefulSet: NewStatefulSetService(kubeClient, log, scheme),
-		Service:     NewServiceService(kubeClient, log, scheme),
-		Pv:          NewPvService(kubeClient, log),
-		Pvc:         NewPvcService(kubeClient, log),
+		All:                 NewAllService(kubeClient, log),
+		CRD:                 NewCRDService(kubeClient, log),
+		Pod:                 NewPodService(kubeClient, log, scheme),
+		Secret:              NewSecretService(kubeClient, log),
+		Deployment:          NewDeploymentService(kubeClient, log, scheme),
+		ConfigMap:           NewConfigMapService(kubeClient, log, scheme),
+		StatefulSet:         NewStatefulSetService(kubeClient, log, scheme),
+		Service:             NewServiceService(kubeClient, log, scheme),
+		Pv:                  NewPvService(kubeClient, log),
+		Pvc:                 NewPvcService(kubeClient, log),
+		PodDisruptionBudget: NewPodDisruptionBudgetService(kubeClient, log),
 	}
 }
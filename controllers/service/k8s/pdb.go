@@ -0,0 +1,40 @@
+package k8s
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type PodDisruptionBudget interface {
+	GetPodDisruptionBudget(namespace, name string) (*policyv1.PodDisruptionBudget, error)
+}
+
+type PodDisruptionBudgetService struct {
+	KubeClient client.Client
+	Log        logr.Logger
+}
+
+func NewPodDisruptionBudgetService(kubeClient client.Client, log logr.Logger) *PodDisruptionBudgetService {
+	log = log.WithValues("service", "k8s.PodDisruptionBudgetService")
+	return &PodDisruptionBudgetService{
+		KubeClient: kubeClient,
+		Log:        log,
+	}
+}
+
+func (p PodDisruptionBudgetService) GetPodDisruptionBudget(namespace, name string) (*policyv1.PodDisruptionBudget, error) {
+	var pdb = &policyv1.PodDisruptionBudget{}
+	if err := p.KubeClient.Get(context.Background(),
+		types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		},
+		pdb,
+	); err != nil {
+		return nil, err
+	}
+	return pdb, nil
+}
@@ -2,17 +2,19 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/go-logr/logr"
-	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	apl "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type Pod interface {
+	GetPod(namespace, name string) (*v1.Pod, error)
 	ListPods(namespace string, labels map[string]string) (*v1.PodList, error)
-	UpdatePodStatus(redis *roav1.Redis, currentStatus roav1.State) error
+	PatchPodLabels(namespace, name string, labels map[string]string) error
 }
 
 type PodService struct {
@@ -30,6 +32,20 @@ func NewPodService(kubeClient client.Client, log logr.Logger, scheme *runtime.Sc
 	}
 }
 
+func (p PodService) GetPod(namespace, name string) (*v1.Pod, error) {
+	var pod = &v1.Pod{}
+	if err := p.KubeClient.Get(context.Background(),
+		types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		},
+		pod,
+	); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
 func (p PodService) ListPods(namespace string, labels map[string]string) (*v1.PodList, error) {
 	var podList = &v1.PodList{}
 	if err := p.KubeClient.List(context.Background(),
@@ -47,10 +63,18 @@ func (p PodService) ListPods(namespace string, labels map[string]string) (*v1.Po
 	return podList, nil
 }
 
-func (p PodService) UpdatePodStatus(redis *roav1.Redis, currentStatus roav1.State) error {
-	redis.Status.State = currentStatus
-	if err := p.KubeClient.Status().Update(context.Background(), redis); err != nil {
+// PatchPodLabels sets the labels on a pod with a merge patch, the other labels are kept
+func (p PodService) PatchPodLabels(namespace, name string, labels map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": labels,
+		},
+	})
+	if err != nil {
 		return err
 	}
-	return nil
+	pod := &v1.Pod{}
+	pod.Namespace = namespace
+	pod.Name = name
+	return p.KubeClient.Patch(context.Background(), pod, client.RawPatch(types.MergePatchType, patch))
 }
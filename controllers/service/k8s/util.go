@@ -2,10 +2,9 @@ package k8s
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
-	"github.com/zhizuqiu/redis-operator/controllers/util/sm4"
+	"github.com/zhizuqiu/redis-operator/controllers/util/password"
 	corev1 "k8s.io/api/core/v1"
 	apl "k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,32 +27,75 @@ func GetSpecRedisPassword(s Services, rf *roav1.Redis) (string, error) {
 		return "", fmt.Errorf("secret \"%s\" does not have a password field", rf.Spec.Auth.SecretPath)
 	} else {
 		if rf.Spec.Auth.Password.Value != "" {
-			switch rf.Spec.Auth.Password.EncodeType {
-			case roav1.BASE64:
-				passwordByte, err := base64.StdEncoding.DecodeString(rf.Spec.Auth.Password.Value)
-				if err != nil {
-					return "", err
-				}
-				return string(passwordByte), nil
-			case roav1.SM4:
-				password, err := sm4.DecryptSm4([]byte(rf.Spec.Auth.Password.Value), sm4.Sm4Key)
-				if err != nil {
-					return "", err
-				}
-				return password, nil
-			default:
-				passwordByte, err := base64.StdEncoding.DecodeString(rf.Spec.Auth.Password.Value)
-				if err != nil {
-					return "", err
-				}
-				return string(passwordByte), nil
+			key, err := getPasswordKey(s, rf)
+			if err != nil {
+				return "", err
 			}
+			return password.Decode(rf.Spec.Auth.Password.EncodeType, rf.Spec.Auth.Password.Value, key)
 		}
 	}
 
 	return "", nil
 }
 
+// getPasswordKey returns the key material of Spec.Auth.Password, from its KeySecret or the data named by
+// the encode type in password.DefaultKeySecret, nil when there is none
+func getPasswordKey(s Services, rf *roav1.Redis) ([]byte, error) {
+	keySecret := rf.Spec.Auth.Password.KeySecret
+	if keySecret != nil && keySecret.Name != "" {
+		secret, err := s.GetSecret(rf.Namespace, keySecret.Name)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := secret.Data[keySecret.Key]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("secret \"%s\" does not have a %s field", keySecret.Name, keySecret.Key)
+	}
+	if password.DefaultKeySecret.Name == "" {
+		return nil, nil
+	}
+	secret, err := s.GetSecret(password.DefaultKeySecret.Namespace, password.DefaultKeySecret.Name)
+	if err != nil {
+		return nil, err
+	}
+	encodeType := rf.Spec.Auth.Password.EncodeType
+	if encodeType == "" {
+		encodeType = roav1.GetDefaultPasswordEncodeType()
+	}
+	return secret.Data[string(encodeType)], nil
+}
+
+// GetACLUserPassword retreives the password of an ACL user from its secret
+func GetACLUserPassword(s Services, rf *roav1.Redis, user roav1.ACLUser) (string, error) {
+	secret, err := s.GetSecret(rf.Namespace, user.PasswordSecret.Name)
+	if err != nil {
+		return "", err
+	}
+	key := user.PasswordSecret.Key
+	if key == "" {
+		key = "password"
+	}
+	if password, ok := secret.Data[key]; ok {
+		return string(password), nil
+	}
+	return "", fmt.Errorf("secret \"%s\" does not have a %s field", user.PasswordSecret.Name, key)
+}
+
+// GetTLSSecret returns the Secret of Spec.TLS, it must have the certificate, the key and the CA
+func GetTLSSecret(s Services, rf *roav1.Redis) (*corev1.Secret, error) {
+	secret, err := s.GetSecret(rf.Namespace, rf.Spec.TLS.SecretName)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, "ca.crt"} {
+		if len(secret.Data[key]) == 0 {
+			return nil, fmt.Errorf("secret \"%s\" does not have a %s field", rf.Spec.TLS.SecretName, key)
+		}
+	}
+	return secret, nil
+}
+
 func ListPods(kubeClient client.Client, namespace string, selector map[string]string) (*corev1.PodList, error) {
 	var podList = &corev1.PodList{}
 	if err := kubeClient.List(context.Background(),
@@ -0,0 +1,40 @@
+package redis_client
+
+import (
+	"errors"
+	"strings"
+)
+
+func (rc *RedisExecClienter) SetACLUser(redisParam RedisParam, password, user string, rules []string) error {
+	_, err := rc.RedisApi.aclSetUser(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, user, rules)
+	return err
+}
+
+func (rc *RedisExecClienter) DelACLUser(redisParam RedisParam, password, user string) error {
+	_, err := rc.RedisApi.aclDelUser(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, user)
+	return err
+}
+
+// GetACLUsers returns the names of ACL USERS
+func (rc *RedisExecClienter) GetACLUsers(redisParam RedisParam, password string) ([]string, error) {
+	output, err := rc.RedisApi.aclUsers(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(output, "ERR") {
+		return nil, errors.New("ACL USERS err: " + output)
+	}
+	users := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			users = append(users, line)
+		}
+	}
+	return users, nil
+}
+
+// RewriteConfig persists the ACL users, which are written to redis.conf when there is no aclfile
+func (rc *RedisExecClienter) RewriteConfig(redisParam RedisParam, password string) error {
+	_, err := rc.RedisApi.rewriteRedisConfig(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password)
+	return err
+}
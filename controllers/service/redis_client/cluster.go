@@ -0,0 +1,186 @@
+package redis_client
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	clusterAddSlotsBatch = 1000
+)
+
+// ClusterNode is one line of the CLUSTER NODES output
+type ClusterNode struct {
+	ID        string
+	Ip        string
+	Port      string
+	Flags     []string
+	MasterID  string
+	LinkState string
+	// Slots are the slot ranges served by the node, like "0-5460" or "5461"
+	Slots []string
+}
+
+func (n ClusterNode) hasFlag(flag string) bool {
+	for _, f := range n.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func (n ClusterNode) IsMyself() bool {
+	return n.hasFlag("myself")
+}
+
+func (n ClusterNode) IsMaster() bool {
+	return n.hasFlag("master")
+}
+
+// IsFailed reports whether the cluster agreed the node is down, or the node has no address anymore
+func (n ClusterNode) IsFailed() bool {
+	return n.hasFlag("fail") || n.hasFlag("noaddr")
+}
+
+// ParseClusterNodes parses the output of CLUSTER NODES
+func ParseClusterNodes(output string) ([]ClusterNode, error) {
+	nodes := make([]ClusterNode, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, errors.New("malformed cluster nodes line: " + line)
+		}
+		addr := fields[1]
+		if i := strings.Index(addr, "@"); i >= 0 {
+			addr = addr[:i]
+		}
+		ip, port := "", ""
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			ip, port = addr[:i], addr[i+1:]
+		}
+		masterID := fields[3]
+		if masterID == "-" {
+			masterID = ""
+		}
+		slots := make([]string, 0)
+		for _, slot := range fields[8:] {
+			// importing and migrating slots are printed as [slot-<-id] and [slot->-id]
+			if strings.HasPrefix(slot, "[") {
+				continue
+			}
+			slots = append(slots, slot)
+		}
+		nodes = append(nodes, ClusterNode{
+			ID:        fields[0],
+			Ip:        ip,
+			Port:      port,
+			Flags:     strings.Split(fields[2], ","),
+			MasterID:  masterID,
+			LinkState: fields[7],
+			Slots:     slots,
+		})
+	}
+	return nodes, nil
+}
+
+// ParseClusterInfo parses the key:value lines of CLUSTER INFO
+func ParseClusterInfo(output string) map[string]string {
+	return ParseInfo(output)
+}
+
+// ParseInfo parses the key:value lines of INFO, the "# Section" lines are skipped
+func ParseInfo(output string) map[string]string {
+	info := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, ":"); i > 0 {
+			info[line[:i]] = line[i+1:]
+		}
+	}
+	return info
+}
+
+func (rc *RedisExecClienter) GetClusterInfo(redisParam RedisParam, password string) (map[string]string, error) {
+	output, err := rc.RedisApi.clusterInfo(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password)
+	if err != nil {
+		return nil, err
+	}
+	return ParseClusterInfo(output), nil
+}
+
+func (rc *RedisExecClienter) GetClusterNodes(redisParam RedisParam, password string) ([]ClusterNode, error) {
+	output, err := rc.RedisApi.clusterNodes(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password)
+	if err != nil {
+		return nil, err
+	}
+	return ParseClusterNodes(output)
+}
+
+func (rc *RedisExecClienter) ClusterMeet(redisParam RedisParam, password, ip, port string) error {
+	_, err := rc.RedisApi.clusterMeet(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, ip, port)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rc *RedisExecClienter) ClusterAddSlots(redisParam RedisParam, password string, slots []int) error {
+	for start := 0; start < len(slots); start += clusterAddSlotsBatch {
+		end := start + clusterAddSlotsBatch
+		if end > len(slots) {
+			end = len(slots)
+		}
+		args := make([]string, 0, end-start)
+		for _, slot := range slots[start:end] {
+			args = append(args, strconv.Itoa(slot))
+		}
+		if _, err := rc.RedisApi.clusterAddSlots(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rc *RedisExecClienter) ClusterReplicate(redisParam RedisParam, password, nodeID string) error {
+	_, err := rc.RedisApi.clusterReplicate(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, nodeID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rc *RedisExecClienter) ClusterForget(redisParam RedisParam, password, nodeID string) error {
+	_, err := rc.RedisApi.clusterForget(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, nodeID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ExpandSlots turns slot ranges like "0-5460" and "5461" into the slot numbers they cover
+func ExpandSlots(slots []string) []int {
+	numbers := make([]int, 0)
+	for _, slot := range slots {
+		bounds := strings.SplitN(slot, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			continue
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				continue
+			}
+		}
+		for i := start; i <= end; i++ {
+			numbers = append(numbers, i)
+		}
+	}
+	return numbers
+}
@@ -0,0 +1,26 @@
+package redis_client
+
+func (rc *RedisExecClienter) BgSave(redisParam RedisParam, password string) error {
+	_, err := rc.RedisApi.bgSave(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rc *RedisExecClienter) BgRewriteAof(redisParam RedisParam, password string) error {
+	_, err := rc.RedisApi.bgRewriteAof(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetPersistenceInfo returns the fields of INFO persistence, e.g. rdb_bgsave_in_progress
+func (rc *RedisExecClienter) GetPersistenceInfo(redisParam RedisParam, password string) (map[string]string, error) {
+	output, err := rc.RedisApi.info(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, "persistence")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(output), nil
+}
@@ -1,6 +1,7 @@
 package redis_client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/go-logr/logr"
@@ -20,12 +21,26 @@ type RedisApi interface {
 	sentinelSetPassword(namespace, podName, containerName, password string) (string, error)
 	sentinelInfo(namespace, podName, containerName, section string) (string, error)
 	sentinelReset(namespace, podName, containerName string) (string, error)
+	sentinelFailover(namespace, podName, containerName string) (string, error)
 	applyRedisConfig(namespace, podName, containerName, password, parameter, value string) (string, error)
 	applySentinelConfig(namespace, podName, containerName, parameter, value string) (string, error)
 	rewriteRedisConfig(namespace, podName, containerName, password string) (string, error)
 	getRedisClientPassword(namespace, podName, containerName string) (string, error)
 	setRedisMasterauthPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error)
 	setRedisRequirepassPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error)
+	clusterInfo(namespace, podName, containerName, password string) (string, error)
+	clusterNodes(namespace, podName, containerName, password string) (string, error)
+	clusterMeet(namespace, podName, containerName, password, ip, port string) (string, error)
+	clusterAddSlots(namespace, podName, containerName, password string, slots []string) (string, error)
+	clusterReplicate(namespace, podName, containerName, password, nodeID string) (string, error)
+	clusterForget(namespace, podName, containerName, password, nodeID string) (string, error)
+	bgSave(namespace, podName, containerName, password string) (string, error)
+	bgRewriteAof(namespace, podName, containerName, password string) (string, error)
+	aclSetUser(namespace, podName, containerName, password, user string, rules []string) (string, error)
+	aclDelUser(namespace, podName, containerName, password, user string) (string, error)
+	aclUsers(namespace, podName, containerName, password string) (string, error)
+	// withContext returns a RedisApi whose commands are stopped when the ctx is done
+	withContext(ctx context.Context) RedisApi
 }
 
 type RedisExecApi struct {
@@ -41,17 +56,23 @@ func NewRedisExecApi(log logr.Logger, execer exec.IExec) RedisApi {
 	return &RedisExecApi{
 		Log:            log,
 		Execer:         execer,
-		RedisExport:    "export REDIS_PORT=$(cat /data/conf/redis.conf | grep port | awk '{print $2}') && ",
-		SentinelExport: "export REDIS_PORT=$(cat /data/conf/sentinel.conf | grep port | awk '{print $2}') && ",
+		RedisExport:    util.GetRedisCliExport(util.GetRedisConfigWritablePath()),
+		SentinelExport: util.GetRedisCliExport(util.GetSentinelConfigWritablePath()),
 	}
 }
 
+func (r *RedisExecApi) withContext(ctx context.Context) RedisApi {
+	api := *r
+	api.Execer = r.Execer.WithContext(ctx)
+	return &api
+}
+
 func (r *RedisExecApi) info(namespace, podName, containerName, password, section string) (string, error) {
 	password = EscapeRedisPassword(password)
 
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" info " + section
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" info " + section
 	if password != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " info " + section
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " info " + section
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -69,9 +90,9 @@ func (r *RedisExecApi) info(namespace, podName, containerName, password, section
 func (r *RedisExecApi) makeMaster(namespace, podName, containerName, password string) (string, error) {
 	password = EscapeRedisPassword(password)
 
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" SLAVEOF NO ONE"
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SLAVEOF NO ONE"
 	if password != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " SLAVEOF NO ONE"
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " SLAVEOF NO ONE"
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -96,9 +117,9 @@ func (r *RedisExecApi) makeMaster(namespace, podName, containerName, password st
 func (r *RedisExecApi) slaveOf(namespace, podName, containerName, password, masterIP, masterPort string) (string, error) {
 	password = EscapeRedisPassword(password)
 
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" SLAVEOF " + masterIP + " " + masterPort
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SLAVEOF " + masterIP + " " + masterPort
 	if password != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " SLAVEOF " + masterIP + " " + masterPort
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " SLAVEOF " + masterIP + " " + masterPort
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -136,7 +157,7 @@ func isSlaveOfOk(output string) bool {
 }
 
 func (r *RedisExecApi) sentinelMonitor(namespace, podName, containerName string) (string, error) {
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" SENTINEL master " + masterName
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL master " + masterName
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -169,7 +190,7 @@ func isSentinelMasterSuccess(output string) bool {
 }
 
 func (r *RedisExecApi) sentinelRemoveMaster(namespace, podName, containerName string) (string, error) {
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" SENTINEL REMOVE " + masterName
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL REMOVE " + masterName
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -220,7 +241,7 @@ func isOk(output string) bool {
 }
 
 func (r *RedisExecApi) sentinelMonitorRedis(namespace, podName, containerName, monitor, port, quorum string) (string, error) {
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" SENTINEL MONITOR " + masterName + " " + monitor + " " + port + " " + quorum
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL MONITOR " + masterName + " " + monitor + " " + port + " " + quorum
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -240,7 +261,7 @@ func (r *RedisExecApi) sentinelMonitorRedis(namespace, podName, containerName, m
 func (r *RedisExecApi) sentinelSetPassword(namespace, podName, containerName, password string) (string, error) {
 	password = EscapeRedisPassword(password)
 
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" SENTINEL SET " + masterName + " auth-pass \"" + password + "\""
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL SET " + masterName + " auth-pass \"" + password + "\""
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -258,7 +279,7 @@ func (r *RedisExecApi) sentinelSetPassword(namespace, podName, containerName, pa
 }
 
 func (r *RedisExecApi) sentinelInfo(namespace, podName, containerName, section string) (string, error) {
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" info " + section
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" info " + section
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -273,7 +294,7 @@ func (r *RedisExecApi) sentinelInfo(namespace, podName, containerName, section s
 }
 
 func (r *RedisExecApi) sentinelReset(namespace, podName, containerName string) (string, error) {
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" SENTINEL reset \"*\""
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL reset \"*\""
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -290,6 +311,24 @@ func (r *RedisExecApi) sentinelReset(namespace, podName, containerName string) (
 	}
 }
 
+func (r *RedisExecApi) sentinelFailover(namespace, podName, containerName string) (string, error) {
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL failover " + masterName
+
+	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
+
+	if len(stderr) != 0 {
+		fmt.Println("STDERR:", stderr, containerName, podName, namespace)
+	}
+	if err != nil {
+		return "", err
+	} else {
+		if !isOk(output) {
+			return output, errors.New("SENTINEL failover err: " + output)
+		}
+		return output, nil
+	}
+}
+
 func hasBeenReset(output string) bool {
 
 	if output == "" {
@@ -330,9 +369,9 @@ func IsDigit(data string) bool {
 func (r *RedisExecApi) applyRedisConfig(namespace, podName, containerName, password, parameter, value string) (string, error) {
 	password = EscapeRedisPassword(password)
 
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" CONFIG SET " + parameter + " " + value
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" CONFIG SET " + parameter + " " + value
 	if password != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " CONFIG SET " + parameter + " " + value
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " CONFIG SET " + parameter + " " + value
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -363,7 +402,7 @@ func (r *RedisExecApi) applyRedisConfig(namespace, podName, containerName, passw
 }
 
 func (r *RedisExecApi) applySentinelConfig(namespace, podName, containerName, parameter, value string) (string, error) {
-	var command = r.SentinelExport + "redis-cli -p \"${REDIS_PORT}\" SENTINEL SET " + masterName + " " + parameter + " " + value
+	var command = r.SentinelExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" SENTINEL SET " + masterName + " " + parameter + " " + value
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
 
@@ -383,9 +422,9 @@ func (r *RedisExecApi) applySentinelConfig(namespace, podName, containerName, pa
 func (r *RedisExecApi) rewriteRedisConfig(namespace, podName, containerName, password string) (string, error) {
 	password = EscapeRedisPassword(password)
 
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" CONFIG REWRITE"
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" CONFIG REWRITE"
 	if password != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " CONFIG REWRITE"
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " CONFIG REWRITE"
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -419,9 +458,9 @@ func (r *RedisExecApi) getRedisClientPassword(namespace, podName, containerName
 }
 
 func (r *RedisExecApi) setRedisMasterauthPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error) {
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" CONFIG SET masterauth \"" + newPassword + "\""
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" CONFIG SET masterauth \"" + newPassword + "\""
 	if oldPassword != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + oldPassword + " CONFIG SET masterauth \"" + newPassword + "\""
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + oldPassword + " CONFIG SET masterauth \"" + newPassword + "\""
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -444,9 +483,9 @@ func (r *RedisExecApi) setRedisMasterauthPassword(namespace, podName, containerN
 }
 
 func (r *RedisExecApi) setRedisRequirepassPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error) {
-	var command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" CONFIG SET requirepass \"" + newPassword + "\""
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" CONFIG SET requirepass \"" + newPassword + "\""
 	if oldPassword != "" {
-		command = r.RedisExport + "redis-cli -p \"${REDIS_PORT}\" --no-auth-warning -a " + oldPassword + " CONFIG SET requirepass \"" + newPassword + "\""
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + oldPassword + " CONFIG SET requirepass \"" + newPassword + "\""
 	}
 
 	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
@@ -467,3 +506,137 @@ func (r *RedisExecApi) setRedisRequirepassPassword(namespace, podName, container
 		return output, nil
 	}
 }
+
+func (r *RedisExecApi) execRedisCommand(namespace, podName, containerName, password, args string) (string, error) {
+	password = EscapeRedisPassword(password)
+
+	var command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" " + args
+	if password != "" {
+		command = r.RedisExport + "redis-cli ${REDIS_TLS} -p \"${REDIS_PORT}\" --no-auth-warning -a " + password + " " + args
+	}
+
+	output, stderr, err := r.Execer.ExecCommandInContainerWithFullOutputBySh(namespace, podName, containerName, command)
+
+	if len(stderr) != 0 {
+		fmt.Println("STDERR:", stderr, containerName, podName, namespace)
+	}
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) clusterInfo(namespace, podName, containerName, password string) (string, error) {
+	return r.execRedisCommand(namespace, podName, containerName, password, "CLUSTER INFO")
+}
+
+func (r *RedisExecApi) clusterNodes(namespace, podName, containerName, password string) (string, error) {
+	return r.execRedisCommand(namespace, podName, containerName, password, "CLUSTER NODES")
+}
+
+func (r *RedisExecApi) clusterMeet(namespace, podName, containerName, password, ip, port string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "CLUSTER MEET "+ip+" "+port)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER MEET " + ip + " " + port + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) clusterAddSlots(namespace, podName, containerName, password string, slots []string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "CLUSTER ADDSLOTS "+strings.Join(slots, " "))
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER ADDSLOTS err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) clusterReplicate(namespace, podName, containerName, password, nodeID string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "CLUSTER REPLICATE "+nodeID)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER REPLICATE " + nodeID + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) clusterForget(namespace, podName, containerName, password, nodeID string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "CLUSTER FORGET "+nodeID)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER FORGET " + nodeID + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) bgSave(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "BGSAVE")
+	if err != nil {
+		return "", err
+	}
+	if !isBackgroundStarted(output) {
+		return output, errors.New("BGSAVE err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) bgRewriteAof(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "BGREWRITEAOF")
+	if err != nil {
+		return "", err
+	}
+	if !isBackgroundStarted(output) {
+		return output, errors.New("BGREWRITEAOF err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) aclSetUser(namespace, podName, containerName, password, user string, rules []string) (string, error) {
+	args := "ACL SETUSER " + shellQuote(user)
+	for _, rule := range rules {
+		args += " " + shellQuote(rule)
+	}
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, args)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("ACL SETUSER " + user + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) aclDelUser(namespace, podName, containerName, password, user string) (string, error) {
+	output, err := r.execRedisCommand(namespace, podName, containerName, password, "ACL DELUSER "+shellQuote(user))
+	if err != nil {
+		return "", err
+	}
+	if !IsDigit(strings.TrimSpace(output)) {
+		return output, errors.New("ACL DELUSER " + user + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisExecApi) aclUsers(namespace, podName, containerName, password string) (string, error) {
+	return r.execRedisCommand(namespace, podName, containerName, password, "ACL USERS")
+}
+
+// shellQuote quotes an argument of redis-cli, the ACL patterns have * which the shell would expand
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", "'\\''") + "'"
+}
+
+// isBackgroundStarted checks the reply of BGSAVE and BGREWRITEAOF, e.g. "Background saving started"
+// or "Background append only file rewriting scheduled"
+func isBackgroundStarted(output string) bool {
+	return strings.HasPrefix(output, "Background")
+}
@@ -1,10 +1,22 @@
 package redis_client
 
+import (
+	"context"
+)
+
 type RedisParam struct {
 	Ip            string
 	NameSpace     string
 	Name          string
 	ContainerName string
+	// Port is the port of the container, several pods with HostNetwork can share an Ip
+	Port string
+}
+
+// IsAddress returns true when host:port, e.g. the master_host and master_port of a slave, is the pod, the port is
+// not compared when it is unknown
+func (p RedisParam) IsAddress(host, port string) bool {
+	return p.Ip == host && (p.Port == "" || p.Port == port)
 }
 
 // Client defines the functions neccesary to connect to redis and sentinel to get or set what we nned
@@ -12,6 +24,7 @@ type RedisClient interface {
 	GetNumberSentinelsInMemory(redisParam RedisParam) (int32, error)
 	GetNumberSentinelSlavesInMemory(sentinel RedisParam) (int32, error)
 	ResetSentinel(sentinel RedisParam) error
+	SentinelFailover(sentinel RedisParam) error
 	GetSlaveOf(redisParam RedisParam, password string) (string, error)
 	IsMaster(redisParam RedisParam, password string) (bool, error)
 	MonitorRedis(redisParam RedisParam, monitor, quorum, password string) error
@@ -25,4 +38,22 @@ type RedisClient interface {
 	SetRedisPassword(redisParam RedisParam, newPassword string) error
 	SetSentinelPassword(redisParam RedisParam, newPassword string) error
 	GetRedisPassword(redisParam RedisParam) (string, error)
+	GetClusterInfo(redisParam RedisParam, password string) (map[string]string, error)
+	GetClusterNodes(redisParam RedisParam, password string) ([]ClusterNode, error)
+	ClusterMeet(redisParam RedisParam, password, ip, port string) error
+	ClusterAddSlots(redisParam RedisParam, password string, slots []int) error
+	ClusterReplicate(redisParam RedisParam, password, nodeID string) error
+	ClusterForget(redisParam RedisParam, password, nodeID string) error
+	BgSave(redisParam RedisParam, password string) error
+	BgRewriteAof(redisParam RedisParam, password string) error
+	GetPersistenceInfo(redisParam RedisParam, password string) (map[string]string, error)
+	GetReplicationInfo(redisParam RedisParam, password string) (map[string]string, error)
+	GetSentinelInfo(sentinel RedisParam) (map[string]string, error)
+	GetServerInfo(redisParam RedisParam, password string) (map[string]string, error)
+	SetACLUser(redisParam RedisParam, password, user string, rules []string) error
+	DelACLUser(redisParam RedisParam, password, user string) error
+	GetACLUsers(redisParam RedisParam, password string) ([]string, error)
+	RewriteConfig(redisParam RedisParam, password string) error
+	// WithContext returns a client whose calls are stopped when the ctx is done, e.g. a probe that timed out
+	WithContext(ctx context.Context) RedisClient
 }
@@ -129,3 +129,71 @@ func TestEscapeRedisPassword(t *testing.T) {
 		}
 	}
 }
+
+func TestShellQuote(t *testing.T) {
+	var quoteTests = []struct {
+		in       string
+		expected string
+	}{
+		{"~cache:*", "'~cache:*'"},
+		{"&it's", "'&it'\\''s'"},
+	}
+
+	for _, tt := range quoteTests {
+		actual := shellQuote(tt.in)
+		if actual != tt.expected {
+			t.Errorf("shellQuote(%s) = %s; expected %s", tt.in, actual, tt.expected)
+		}
+	}
+}
+
+func TestParseClusterNodes(t *testing.T) {
+	output := `07c37dfeb235213a872192d90877d0cd55635b91 10.0.0.4:6379@16379 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 10.0.0.2:6379@16379 master - 0 1426238316232 2 connected 5461-10922
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-5460 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]
+6ec23923021cf3ffec47632106199cb7f496ce01 :0@0 master,fail,noaddr - 1426238316232 1426238316232 5 disconnected
+`
+	nodes, err := ParseClusterNodes(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("len(nodes) = %d; expected 4", len(nodes))
+	}
+
+	if nodes[0].IsMaster() || nodes[0].MasterID != "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca" {
+		t.Errorf("nodes[0] should be a replica of e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca, got %+v", nodes[0])
+	}
+	if nodes[1].Ip != "10.0.0.2" || nodes[1].Port != "6379" {
+		t.Errorf("nodes[1] address = %s:%s; expected 10.0.0.2:6379", nodes[1].Ip, nodes[1].Port)
+	}
+	if !nodes[2].IsMyself() || !nodes[2].IsMaster() || len(nodes[2].Slots) != 1 || nodes[2].Slots[0] != "0-5460" {
+		t.Errorf("nodes[2] should be myself, a master with slots 0-5460, got %+v", nodes[2])
+	}
+	if !nodes[3].IsFailed() || nodes[3].Ip != "" {
+		t.Errorf("nodes[3] should be failed without address, got %+v", nodes[3])
+	}
+
+	if _, err := ParseClusterNodes("e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 10.0.0.1:6379@16379"); err == nil {
+		t.Errorf("ParseClusterNodes() of a malformed line should fail")
+	}
+}
+
+func TestExpandSlots(t *testing.T) {
+	var expandTests = []struct {
+		in       []string
+		expected int
+	}{
+		{[]string{"0-5460"}, 5461},
+		{[]string{"5461"}, 1},
+		{[]string{"0-1", "3", "5-6"}, 5},
+		{[]string{}, 0},
+	}
+
+	for _, tt := range expandTests {
+		actual := ExpandSlots(tt.in)
+		if len(actual) != tt.expected {
+			t.Errorf("len(ExpandSlots(%v)) = %d; expected %d", tt.in, len(actual), tt.expected)
+		}
+	}
+}
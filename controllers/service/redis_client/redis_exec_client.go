@@ -1,6 +1,7 @@
 package redis_client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/go-logr/logr"
@@ -41,6 +42,13 @@ func NewRedisExecClienter(log logr.Logger, redisApi RedisApi) RedisClient {
 	}
 }
 
+func (rc *RedisExecClienter) WithContext(ctx context.Context) RedisClient {
+	return &RedisExecClienter{
+		Log:      rc.Log,
+		RedisApi: rc.RedisApi.withContext(ctx),
+	}
+}
+
 func (rc *RedisExecClienter) GetNumberSentinelsInMemory(redisParam RedisParam) (int32, error) {
 	info, err := rc.RedisApi.sentinelInfo(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, "sentinel")
 	if err != nil {
@@ -95,6 +103,14 @@ func (rc *RedisExecClienter) ResetSentinel(sentinel RedisParam) error {
 	return nil
 }
 
+func (rc *RedisExecClienter) SentinelFailover(sentinel RedisParam) error {
+	_, err := rc.RedisApi.sentinelFailover(sentinel.NameSpace, sentinel.Name, sentinel.ContainerName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (rc *RedisExecClienter) GetSlaveOf(redisParam RedisParam, password string) (string, error) {
 	info, err := rc.RedisApi.info(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, "replication")
 	if err != nil {
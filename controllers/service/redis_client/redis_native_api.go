@@ -0,0 +1,569 @@
+package redis_client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/go-logr/logr"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
+	"github.com/zhizuqiu/redis-operator/controllers/util"
+	"k8s.io/apimachinery/pkg/types"
+	"net"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNativeDialTimeout = 5 * time.Second
+	defaultNativeTimeout     = 10 * time.Second
+)
+
+// AddrResolver returns the host:port the native client should dial for a pod.
+type AddrResolver interface {
+	Resolve(namespace, podName, containerName string) (string, error)
+}
+
+// AddrResolverFunc adapts a plain function to AddrResolver.
+type AddrResolverFunc func(namespace, podName, containerName string) (string, error)
+
+func (f AddrResolverFunc) Resolve(namespace, podName, containerName string) (string, error) {
+	return f(namespace, podName, containerName)
+}
+
+// PasswordLookup returns the candidate passwords for a redis pod, most
+// likely first. It replaces reading requirepass out of the pod's redis.conf.
+type PasswordLookup func(namespace, podName string) ([]string, error)
+
+// TLSConfigLookup returns the tls config to dial a redis or sentinel pod with, nil when the pod does not
+// serve TLS
+type TLSConfigLookup func(namespace, podName string) (*tls.Config, error)
+
+// PodAddrResolver resolves a pod to its pod IP and the redis/sentinel
+// container port. With HostNetwork the pod IP is the host IP and the container
+// port is the static port, so static resources need no special case.
+type PodAddrResolver struct {
+	K8sService k8s.Pod
+}
+
+func NewPodAddrResolver(k8sService k8s.Pod) *PodAddrResolver {
+	return &PodAddrResolver{
+		K8sService: k8sService,
+	}
+}
+
+func (p *PodAddrResolver) Resolve(namespace, podName, containerName string) (string, error) {
+	pod, err := p.K8sService.GetPod(namespace, podName)
+	if err != nil {
+		return "", err
+	}
+	if pod.Status.PodIP == "" {
+		return "", errors.New("pod " + podName + " has no ip yet")
+	}
+	port := util.GetPort(*pod)
+	if port == 0 {
+		return "", errors.New("pod " + podName + " has no redis or sentinel port")
+	}
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port))), nil
+}
+
+// NewSpecPasswordLookup returns the password declared on the Redis the pod belongs to.
+func NewSpecPasswordLookup(k8sService k8s.Services) PasswordLookup {
+	return func(namespace, podName string) ([]string, error) {
+		rf, err := getPodRedis(k8sService, namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		password, err := k8s.GetSpecRedisPassword(k8sService, rf)
+		if err != nil {
+			return nil, err
+		}
+		return []string{password}, nil
+	}
+}
+
+// NewSpecTLSConfigLookup returns the certificates of Spec.TLS of the Redis the pod belongs to.
+func NewSpecTLSConfigLookup(k8sService k8s.Services) TLSConfigLookup {
+	return func(namespace, podName string) (*tls.Config, error) {
+		rf, err := getPodRedis(k8sService, namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		if !rf.IsTLSEnabled() {
+			return nil, nil
+		}
+		secret, err := k8s.GetTLSSecret(k8sService, rf)
+		if err != nil {
+			return nil, err
+		}
+		return NewTLSConfig(secret.Data[util.TLSCertKey], secret.Data[util.TLSKeyKey], secret.Data[util.TLSCACertKey])
+	}
+}
+
+// NewTLSConfig returns a tls config presenting the certificate as a client certificate. Like redis-cli, the
+// server certificate is verified against the CA but not against the pod ip, which it rarely contains
+func NewTLSConfig(cert, key, ca []byte) (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificate found in " + util.TLSCACertKey)
+	}
+	return &tls.Config{
+		Certificates:       []tls.Certificate{certificate},
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("no server certificate")
+			}
+			certs := make([]*x509.Certificate, 0, len(rawCerts))
+			for _, raw := range rawCerts {
+				c, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				certs = append(certs, c)
+			}
+			intermediates := x509.NewCertPool()
+			for _, c := range certs[1:] {
+				intermediates.AddCert(c)
+			}
+			_, err := certs[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}, nil
+}
+
+func getPodRedis(k8sService k8s.Services, namespace, podName string) (*roav1.Redis, error) {
+	pod, err := k8sService.GetPod(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+	name := util.GetInstanceNameFromLabel(*pod)
+	if name == "" {
+		return nil, errors.New("pod " + podName + " does not belong to a redis instance")
+	}
+	return k8sService.GetOnly(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+}
+
+// RedisNativeApi talks RESP over TCP to the pods instead of running redis-cli
+// through kubectl exec. Replies are rendered like redis-cli output, so
+// RedisExecClienter parses them the same way.
+type RedisNativeApi struct {
+	Log         logr.Logger
+	Resolver    AddrResolver
+	Passwords   PasswordLookup
+	TLSConfigs  TLSConfigLookup
+	DialTimeout time.Duration
+	Timeout     time.Duration
+
+	// ctx stops the commands when it is done
+	ctx context.Context
+	// passwords known to work, by namespace/pod, so that a password rotation
+	// can still authenticate with the old one, shared with the copies of withContext
+	knownPasswords map[string]string
+	mu             *sync.Mutex
+}
+
+// NewRedisNativeApi returns a redis api speaking RESP directly to the pods
+func NewRedisNativeApi(log logr.Logger, resolver AddrResolver, passwords PasswordLookup, tlsConfigs TLSConfigLookup) RedisApi {
+	log = log.WithValues("redisClient", "RedisNativeApi")
+	return &RedisNativeApi{
+		Log:            log,
+		Resolver:       resolver,
+		Passwords:      passwords,
+		TLSConfigs:     tlsConfigs,
+		DialTimeout:    defaultNativeDialTimeout,
+		Timeout:        defaultNativeTimeout,
+		ctx:            context.Background(),
+		knownPasswords: make(map[string]string),
+		mu:             &sync.Mutex{},
+	}
+}
+
+func (r *RedisNativeApi) withContext(ctx context.Context) RedisApi {
+	api := *r
+	api.ctx = ctx
+	return &api
+}
+
+func (r *RedisNativeApi) connect(namespace, podName, containerName, password string) (*respConn, error) {
+	addr, err := r.Resolver.Resolve(namespace, podName, containerName)
+	if err != nil {
+		return nil, err
+	}
+	var tlsConfig *tls.Config
+	if r.TLSConfigs != nil {
+		tlsConfig, err = r.TLSConfigs(namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	conn, err := dialResp(r.ctx, addr, tlsConfig, r.DialTimeout, r.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if password != "" {
+		reply, err := conn.Do("AUTH", password)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if reply.IsError() {
+			conn.Close()
+			return nil, errors.New("AUTH err: " + reply.String())
+		}
+	}
+	return conn, nil
+}
+
+// do runs a single command on the pod and returns its redis-cli style output
+func (r *RedisNativeApi) do(namespace, podName, containerName, password string, args ...string) (string, error) {
+	conn, err := r.connect(namespace, podName, containerName, password)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	reply, err := conn.Do(args...)
+	if err != nil {
+		return "", err
+	}
+	return reply.String(), nil
+}
+
+func (r *RedisNativeApi) rememberPassword(namespace, podName, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.knownPasswords[namespace+"/"+podName] = password
+}
+
+func (r *RedisNativeApi) knownPassword(namespace, podName string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	password, ok := r.knownPasswords[namespace+"/"+podName]
+	return password, ok
+}
+
+func (r *RedisNativeApi) info(namespace, podName, containerName, password, section string) (string, error) {
+	return r.do(namespace, podName, containerName, password, "INFO", section)
+}
+
+func (r *RedisNativeApi) makeMaster(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "SLAVEOF", "NO", "ONE")
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("SLAVEOF NO ONE err: " + output)
+	}
+	_, err = r.rewriteRedisConfig(namespace, podName, containerName, password)
+	if err != nil {
+		Error2(err.Error(), namespace, podName)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) slaveOf(namespace, podName, containerName, password, masterIP, masterPort string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "SLAVEOF", masterIP, masterPort)
+	if err != nil {
+		return "", err
+	}
+	if !isSlaveOfOk(output) {
+		return output, errors.New("SLAVEOF " + masterIP + " " + masterPort + " err: " + output)
+	}
+	_, err = r.rewriteRedisConfig(namespace, podName, containerName, password)
+	if err != nil {
+		Error2(err.Error(), namespace, podName)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) sentinelMonitor(namespace, podName, containerName string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "master", masterName)
+	if err != nil {
+		return "", err
+	}
+	if !isSentinelMasterSuccess(output) {
+		return output, errors.New("SENTINEL master " + masterName + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) sentinelRemoveMaster(namespace, podName, containerName string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "REMOVE", masterName)
+	if err != nil {
+		return "", err
+	}
+	if !r.sentinelRemoveERRCanIgnore(output) {
+		return output, errors.New("SENTINEL REMOVE err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) sentinelRemoveERRCanIgnore(output string) bool {
+	return output == "OK" || output == "ERR No such master with that name"
+}
+
+func (r *RedisNativeApi) sentinelMonitorRedis(namespace, podName, containerName, monitor, port, quorum string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "MONITOR", masterName, monitor, port, quorum)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("SENTINEL MONITOR err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) sentinelSetPassword(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "SET", masterName, "auth-pass", password)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("SENTINEL set auth-pass err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) sentinelInfo(namespace, podName, containerName, section string) (string, error) {
+	return r.do(namespace, podName, containerName, "", "INFO", section)
+}
+
+func (r *RedisNativeApi) sentinelReset(namespace, podName, containerName string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "reset", "*")
+	if err != nil {
+		return "", err
+	}
+	if !hasBeenReset(output) {
+		return output, errors.New("SENTINEL reset * err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) sentinelFailover(namespace, podName, containerName string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "failover", masterName)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("SENTINEL failover err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) applyRedisConfig(namespace, podName, containerName, password, parameter, value string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "CONFIG", "SET", parameter, value)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("REDIS CONFIG SET err: " + output)
+	}
+	if parameter == "requirepass" {
+		r.rememberPassword(namespace, podName, value)
+		password = value
+	}
+	_, err = r.rewriteRedisConfig(namespace, podName, containerName, password)
+	if err != nil {
+		Error2(err.Error(), namespace, podName)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) applySentinelConfig(namespace, podName, containerName, parameter, value string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, "", "SENTINEL", "SET", masterName, parameter, value)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("SENTINEL CONFIG SET err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) rewriteRedisConfig(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "CONFIG", "REWRITE")
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CONFIG REWRITE err: " + output)
+	}
+	return output, nil
+}
+
+// getRedisClientPassword can not read redis.conf from the pod, so it tries the
+// last password known to work and then the candidates from Passwords, and
+// returns the first one the server accepts.
+func (r *RedisNativeApi) getRedisClientPassword(namespace, podName, containerName string) (string, error) {
+	candidates := make([]string, 0)
+	if password, ok := r.knownPassword(namespace, podName); ok {
+		candidates = append(candidates, password)
+	}
+	if r.Passwords != nil {
+		passwords, err := r.Passwords(namespace, podName)
+		if err != nil {
+			return "", err
+		}
+		candidates = append(candidates, passwords...)
+	}
+	candidates = append(candidates, "")
+
+	var lastErr error
+	for _, password := range candidates {
+		output, err := r.do(namespace, podName, containerName, password, "PING")
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if output != "PONG" {
+			lastErr = errors.New("PING err: " + output)
+			continue
+		}
+		r.rememberPassword(namespace, podName, password)
+		return password, nil
+	}
+	return "", lastErr
+}
+
+func (r *RedisNativeApi) setRedisMasterauthPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, oldPassword, "CONFIG", "SET", "masterauth", newPassword)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("REDIS CONFIG SET err: " + output)
+	}
+	_, err = r.rewriteRedisConfig(namespace, podName, containerName, oldPassword)
+	if err != nil {
+		Error2(err.Error(), namespace, podName)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) setRedisRequirepassPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, oldPassword, "CONFIG", "SET", "requirepass", newPassword)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("REDIS CONFIG SET err: " + output)
+	}
+	r.rememberPassword(namespace, podName, newPassword)
+	_, err = r.rewriteRedisConfig(namespace, podName, containerName, newPassword)
+	if err != nil {
+		Error2(err.Error(), namespace, podName)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) clusterInfo(namespace, podName, containerName, password string) (string, error) {
+	return r.do(namespace, podName, containerName, password, "CLUSTER", "INFO")
+}
+
+func (r *RedisNativeApi) clusterNodes(namespace, podName, containerName, password string) (string, error) {
+	return r.do(namespace, podName, containerName, password, "CLUSTER", "NODES")
+}
+
+func (r *RedisNativeApi) clusterMeet(namespace, podName, containerName, password, ip, port string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "CLUSTER", "MEET", ip, port)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER MEET " + ip + " " + port + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) clusterAddSlots(namespace, podName, containerName, password string, slots []string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, append([]string{"CLUSTER", "ADDSLOTS"}, slots...)...)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER ADDSLOTS err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) clusterReplicate(namespace, podName, containerName, password, nodeID string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "CLUSTER", "REPLICATE", nodeID)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER REPLICATE " + nodeID + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) clusterForget(namespace, podName, containerName, password, nodeID string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "CLUSTER", "FORGET", nodeID)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("CLUSTER FORGET " + nodeID + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) bgSave(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "BGSAVE")
+	if err != nil {
+		return "", err
+	}
+	if !isBackgroundStarted(output) {
+		return output, errors.New("BGSAVE err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) bgRewriteAof(namespace, podName, containerName, password string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "BGREWRITEAOF")
+	if err != nil {
+		return "", err
+	}
+	if !isBackgroundStarted(output) {
+		return output, errors.New("BGREWRITEAOF err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) aclSetUser(namespace, podName, containerName, password, user string, rules []string) (string, error) {
+	args := append([]string{"ACL", "SETUSER", user}, rules...)
+	output, err := r.do(namespace, podName, containerName, password, args...)
+	if err != nil {
+		return "", err
+	}
+	if !isOk(output) {
+		return output, errors.New("ACL SETUSER " + user + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) aclDelUser(namespace, podName, containerName, password, user string) (string, error) {
+	output, err := r.do(namespace, podName, containerName, password, "ACL", "DELUSER", user)
+	if err != nil {
+		return "", err
+	}
+	if !IsDigit(output) {
+		return output, errors.New("ACL DELUSER " + user + " err: " + output)
+	}
+	return output, nil
+}
+
+func (r *RedisNativeApi) aclUsers(namespace, podName, containerName, password string) (string, error) {
+	return r.do(namespace, podName, containerName, password, "ACL", "USERS")
+}
@@ -0,0 +1,407 @@
+package redis_client
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// fakeRespServer answers RESP commands with the canned replies of handle,
+// and records every command it received.
+type fakeRespServer struct {
+	listener net.Listener
+	password string
+	handle   func(args []string) string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeRespServer(t *testing.T, password string, handle func(args []string) string) *fakeRespServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRespServer{
+		listener: listener,
+		password: password,
+		handle:   handle,
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	return s
+}
+
+func (s *fakeRespServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *fakeRespServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		req, err := readRespReply(reader)
+		if err != nil {
+			return
+		}
+		args := make([]string, 0, len(req.Elems))
+		for _, elem := range req.Elems {
+			args = append(args, elem.Str)
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		s.mu.Unlock()
+
+		var reply string
+		switch {
+		case strings.EqualFold(args[0], "AUTH"):
+			if len(args) == 2 && args[1] == s.password {
+				authed = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid username-password pair\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		default:
+			reply = s.handle(args)
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRespServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *fakeRespServer) api() *RedisNativeApi {
+	addr := s.listener.Addr().String()
+	resolver := AddrResolverFunc(func(namespace, podName, containerName string) (string, error) {
+		return addr, nil
+	})
+	passwords := func(namespace, podName string) ([]string, error) {
+		return []string{s.password}, nil
+	}
+	return NewRedisNativeApi(ctrl.Log, resolver, passwords, nil).(*RedisNativeApi)
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func TestNativeInfo(t *testing.T) {
+	info := "# Replication\r\nrole:master\r\nconnected_slaves:0\r\n"
+	server := newFakeRespServer(t, "pass", func(args []string) string {
+		if strings.EqualFold(args[0], "INFO") && args[1] == "replication" {
+			return bulk(info)
+		}
+		return "-ERR unknown command\r\n"
+	})
+	api := server.api()
+
+	output, err := api.info("default", "rfr-redis-sample-0", "", "pass", "replication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != info {
+		t.Errorf("info() = %q; expected %q", output, info)
+	}
+
+	if _, err := api.info("default", "rfr-redis-sample-0", "", "wrong", "replication"); err == nil {
+		t.Errorf("info() with a wrong password should fail")
+	}
+}
+
+func TestNativeWithContext(t *testing.T) {
+	// a hung redis that never answers
+	release := make(chan struct{})
+	t.Cleanup(func() {
+		close(release)
+	})
+	server := newFakeRespServer(t, "", func(args []string) string {
+		<-release
+		return "-ERR released\r\n"
+	})
+	api := server.api()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := api.withContext(ctx).info("default", "rfr-redis-sample-0", "", "", "replication")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("info() err = %v; expected %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("info() returned after %s; expected it to stop with the ctx, not after the Timeout of %s", elapsed, api.Timeout)
+	}
+}
+
+func TestNativeSlaveOf(t *testing.T) {
+	server := newFakeRespServer(t, "", func(args []string) string {
+		return "+OK\r\n"
+	})
+	api := server.api()
+
+	if _, err := api.slaveOf("default", "rfr-redis-sample-1", "", "", "10.0.0.1", "6379"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"SLAVEOF 10.0.0.1 6379", "CONFIG REWRITE"}
+	actual := server.received()
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("slaveOf() sent %v; expected %v", actual, expected)
+	}
+}
+
+func TestNativeGetSentinelMonitor(t *testing.T) {
+	server := newFakeRespServer(t, "", func(args []string) string {
+		if strings.EqualFold(args[0], "SENTINEL") && args[1] == "master" {
+			return "*6\r\n" + bulk("name") + bulk("mymaster") + bulk("ip") + bulk("10.0.0.2") + bulk("port") + bulk("6380")
+		}
+		return "-ERR unknown command\r\n"
+	})
+	client := NewRedisExecClienter(ctrl.Log, server.api())
+
+	ip, port, err := client.GetSentinelMonitor(RedisParam{NameSpace: "default", Name: "rfs-redis-sample-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "10.0.0.2" || port != "6380" {
+		t.Errorf("GetSentinelMonitor() = %s:%s; expected 10.0.0.2:6380", ip, port)
+	}
+}
+
+func TestNativeSentinelReset(t *testing.T) {
+	server := newFakeRespServer(t, "", func(args []string) string {
+		return ":1\r\n"
+	})
+	client := NewRedisExecClienter(ctrl.Log, server.api())
+
+	if err := client.ResetSentinel(RedisParam{NameSpace: "default", Name: "rfs-redis-sample-0"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNativeSentinelRemoveMaster(t *testing.T) {
+	var removeTests = []struct {
+		reply   string
+		success bool
+	}{
+		{"+OK\r\n", true},
+		{"-ERR No such master with that name\r\n", true},
+		{"-ERR unknown error\r\n", false},
+	}
+	for _, tt := range removeTests {
+		reply := tt.reply
+		server := newFakeRespServer(t, "", func(args []string) string {
+			return reply
+		})
+		_, err := server.api().sentinelRemoveMaster("default", "rfs-redis-sample-0", "")
+		if (err == nil) != tt.success {
+			t.Errorf("sentinelRemoveMaster() with reply %q: err = %v", tt.reply, err)
+		}
+	}
+}
+
+func TestNativeBgSave(t *testing.T) {
+	var bgSaveTests = []struct {
+		reply   string
+		success bool
+	}{
+		{"+Background saving started\r\n", true},
+		{"+Background saving scheduled\r\n", true},
+		{"-ERR Background save already in progress\r\n", false},
+	}
+	for _, tt := range bgSaveTests {
+		reply := tt.reply
+		server := newFakeRespServer(t, "", func(args []string) string {
+			return reply
+		})
+		client := NewRedisExecClienter(ctrl.Log, server.api())
+		err := client.BgSave(RedisParam{NameSpace: "default", Name: "rfr-redis-sample-1-0"}, "")
+		if (err == nil) != tt.success {
+			t.Errorf("BgSave() with reply %q: err = %v", tt.reply, err)
+		}
+	}
+}
+
+func TestNativeGetPersistenceInfo(t *testing.T) {
+	server := newFakeRespServer(t, "", func(args []string) string {
+		return bulk("# Persistence\r\nrdb_bgsave_in_progress:0\r\nrdb_last_save_time:1600000000\r\nrdb_last_bgsave_status:ok\r\n")
+	})
+	client := NewRedisExecClienter(ctrl.Log, server.api())
+
+	info, err := client.GetPersistenceInfo(RedisParam{NameSpace: "default", Name: "rfr-redis-sample-1-0"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["rdb_bgsave_in_progress"] != "0" || info["rdb_last_save_time"] != "1600000000" || info["rdb_last_bgsave_status"] != "ok" {
+		t.Errorf("GetPersistenceInfo() = %v", info)
+	}
+	if _, ok := info["# Persistence"]; ok {
+		t.Errorf("GetPersistenceInfo() should skip the section line")
+	}
+}
+
+func TestNativeGetRedisClientPassword(t *testing.T) {
+	server := newFakeRespServer(t, "new", func(args []string) string {
+		if strings.EqualFold(args[0], "PING") {
+			return "+PONG\r\n"
+		}
+		return "+OK\r\n"
+	})
+	api := server.api()
+
+	password, err := api.getRedisClientPassword("default", "rfr-redis-sample-0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "new" {
+		t.Errorf("getRedisClientPassword() = %q; expected %q", password, "new")
+	}
+}
+
+func TestNativeACLUsers(t *testing.T) {
+	server := newFakeRespServer(t, "", func(args []string) string {
+		switch strings.ToUpper(args[1]) {
+		case "SETUSER":
+			return "+OK\r\n"
+		case "DELUSER":
+			return ":1\r\n"
+		default:
+			return "*2\r\n" + bulk("default") + bulk("app")
+		}
+	})
+	client := NewRedisExecClienter(ctrl.Log, server.api())
+	redisParam := RedisParam{NameSpace: "default", Name: "rfr-redis-sample-1-0"}
+
+	if err := client.SetACLUser(redisParam, "", "app", []string{"reset", "on", "~cache:*", "+@read"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DelACLUser(redisParam, "", "old"); err != nil {
+		t.Fatal(err)
+	}
+	users, err := client.GetACLUsers(redisParam, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(users, ",") != "default,app" {
+		t.Errorf("GetACLUsers() = %v; expected [default app]", users)
+	}
+	expected := []string{"ACL SETUSER app reset on ~cache:* +@read", "ACL DELUSER old", "ACL USERS"}
+	actual := server.received()
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("sent %v; expected %v", actual, expected)
+	}
+}
+
+// newTestCertificate returns a self-signed certificate, which is its own CA, and its key
+func newTestCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestNativeTLS(t *testing.T) {
+	cert, key := newTestCertificate(t)
+	serverCertificate, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(cert)
+	// the server requires a client certificate, like redis with tls-auth-clients yes
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRespServer{
+		listener: listener,
+		handle: func(args []string) string {
+			return "+PONG\r\n"
+		},
+	}
+	go server.serve()
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	tlsConfig, err := NewTLSConfig(cert, key, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := server.api()
+	api.TLSConfigs = func(namespace, podName string) (*tls.Config, error) {
+		return tlsConfig, nil
+	}
+	output, err := api.do("default", "redis-redis-sample-0-0", "", "", "PING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "PONG" {
+		t.Errorf("do() = %q; expected PONG", output)
+	}
+
+	otherCert, otherKey := newTestCertificate(t)
+	tlsConfig, err = NewTLSConfig(cert, key, otherCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.do("default", "redis-redis-sample-0-0", "", "", "PING"); err == nil {
+		t.Errorf("do() should fail when the server certificate is not signed by the CA")
+	}
+	if _, err := NewTLSConfig(cert, otherKey, cert); err == nil {
+		t.Errorf("NewTLSConfig() should fail when the key does not match the certificate")
+	}
+}
@@ -0,0 +1,222 @@
+package redis_client
+
+import (
+	"context"
+	"time"
+)
+
+// CommandObserver is called after every command of a RedisApi, e.g. to export its latency and failures
+type CommandObserver func(method string, duration time.Duration, err error)
+
+// ObservedRedisApi calls the Observer with the method name, duration and error of each command of the RedisApi
+type ObservedRedisApi struct {
+	RedisApi RedisApi
+	Observer CommandObserver
+}
+
+func NewObservedRedisApi(redisApi RedisApi, observer CommandObserver) RedisApi {
+	return &ObservedRedisApi{
+		RedisApi: redisApi,
+		Observer: observer,
+	}
+}
+
+func (r *ObservedRedisApi) withContext(ctx context.Context) RedisApi {
+	return &ObservedRedisApi{
+		RedisApi: r.RedisApi.withContext(ctx),
+		Observer: r.Observer,
+	}
+}
+
+func (r *ObservedRedisApi) info(namespace, podName, containerName, password, section string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.info(namespace, podName, containerName, password, section)
+	r.Observer("info", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) makeMaster(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.makeMaster(namespace, podName, containerName, password)
+	r.Observer("makeMaster", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) slaveOf(namespace, podName, containerName, password, masterIP, masterPort string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.slaveOf(namespace, podName, containerName, password, masterIP, masterPort)
+	r.Observer("slaveOf", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelMonitor(namespace, podName, containerName string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelMonitor(namespace, podName, containerName)
+	r.Observer("sentinelMonitor", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelRemoveMaster(namespace, podName, containerName string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelRemoveMaster(namespace, podName, containerName)
+	r.Observer("sentinelRemoveMaster", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelRemoveERRCanIgnore(output string) bool {
+	return r.RedisApi.sentinelRemoveERRCanIgnore(output)
+}
+
+func (r *ObservedRedisApi) sentinelMonitorRedis(namespace, podName, containerName, monitor, port, quorum string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelMonitorRedis(namespace, podName, containerName, monitor, port, quorum)
+	r.Observer("sentinelMonitorRedis", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelSetPassword(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelSetPassword(namespace, podName, containerName, password)
+	r.Observer("sentinelSetPassword", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelInfo(namespace, podName, containerName, section string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelInfo(namespace, podName, containerName, section)
+	r.Observer("sentinelInfo", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelReset(namespace, podName, containerName string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelReset(namespace, podName, containerName)
+	r.Observer("sentinelReset", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) sentinelFailover(namespace, podName, containerName string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.sentinelFailover(namespace, podName, containerName)
+	r.Observer("sentinelFailover", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) applyRedisConfig(namespace, podName, containerName, password, parameter, value string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.applyRedisConfig(namespace, podName, containerName, password, parameter, value)
+	r.Observer("applyRedisConfig", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) applySentinelConfig(namespace, podName, containerName, parameter, value string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.applySentinelConfig(namespace, podName, containerName, parameter, value)
+	r.Observer("applySentinelConfig", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) rewriteRedisConfig(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.rewriteRedisConfig(namespace, podName, containerName, password)
+	r.Observer("rewriteRedisConfig", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) getRedisClientPassword(namespace, podName, containerName string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.getRedisClientPassword(namespace, podName, containerName)
+	r.Observer("getRedisClientPassword", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) setRedisMasterauthPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.setRedisMasterauthPassword(namespace, podName, containerName, oldPassword, newPassword)
+	r.Observer("setRedisMasterauthPassword", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) setRedisRequirepassPassword(namespace, podName, containerName, oldPassword, newPassword string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.setRedisRequirepassPassword(namespace, podName, containerName, oldPassword, newPassword)
+	r.Observer("setRedisRequirepassPassword", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) clusterInfo(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.clusterInfo(namespace, podName, containerName, password)
+	r.Observer("clusterInfo", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) clusterNodes(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.clusterNodes(namespace, podName, containerName, password)
+	r.Observer("clusterNodes", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) clusterMeet(namespace, podName, containerName, password, ip, port string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.clusterMeet(namespace, podName, containerName, password, ip, port)
+	r.Observer("clusterMeet", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) clusterAddSlots(namespace, podName, containerName, password string, slots []string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.clusterAddSlots(namespace, podName, containerName, password, slots)
+	r.Observer("clusterAddSlots", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) clusterReplicate(namespace, podName, containerName, password, nodeID string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.clusterReplicate(namespace, podName, containerName, password, nodeID)
+	r.Observer("clusterReplicate", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) clusterForget(namespace, podName, containerName, password, nodeID string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.clusterForget(namespace, podName, containerName, password, nodeID)
+	r.Observer("clusterForget", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) bgSave(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.bgSave(namespace, podName, containerName, password)
+	r.Observer("bgSave", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) bgRewriteAof(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.bgRewriteAof(namespace, podName, containerName, password)
+	r.Observer("bgRewriteAof", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) aclSetUser(namespace, podName, containerName, password, user string, rules []string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.aclSetUser(namespace, podName, containerName, password, user, rules)
+	r.Observer("aclSetUser", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) aclDelUser(namespace, podName, containerName, password, user string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.aclDelUser(namespace, podName, containerName, password, user)
+	r.Observer("aclDelUser", time.Since(start), err)
+	return output, err
+}
+
+func (r *ObservedRedisApi) aclUsers(namespace, podName, containerName, password string) (string, error) {
+	start := time.Now()
+	output, err := r.RedisApi.aclUsers(namespace, podName, containerName, password)
+	r.Observer("aclUsers", time.Since(start), err)
+	return output, err
+}
@@ -0,0 +1,41 @@
+package redis_client
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObservedRedisApi(t *testing.T) {
+	server := newFakeRespServer(t, "pass", func(args []string) string {
+		if strings.EqualFold(args[0], "INFO") {
+			return bulk("# Replication\r\nrole:master\r\n")
+		}
+		return "-ERR unknown command\r\n"
+	})
+
+	observed := make([]string, 0)
+	failed := make([]string, 0)
+	api := NewObservedRedisApi(server.api(), func(method string, duration time.Duration, err error) {
+		observed = append(observed, method)
+		if err != nil {
+			failed = append(failed, method)
+		}
+	})
+
+	if _, err := api.info("default", "rfr-redis-sample-0", "", "pass", "replication"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.makeMaster("default", "rfr-redis-sample-0", "", "pass"); err == nil {
+		t.Fatal("makeMaster() should fail")
+	}
+	// not a command
+	api.sentinelRemoveERRCanIgnore("ERR No such master with that name")
+
+	if strings.Join(observed, ",") != "info,makeMaster" {
+		t.Errorf("observed = %v; expected [info makeMaster]", observed)
+	}
+	if strings.Join(failed, ",") != "makeMaster" {
+		t.Errorf("failed = %v; expected [makeMaster]", failed)
+	}
+}
@@ -0,0 +1,28 @@
+package redis_client
+
+// GetReplicationInfo returns the fields of INFO replication, e.g. master_repl_offset
+func (rc *RedisExecClienter) GetReplicationInfo(redisParam RedisParam, password string) (map[string]string, error) {
+	output, err := rc.RedisApi.info(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, "replication")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(output), nil
+}
+
+// GetSentinelInfo returns the fields of INFO sentinel, e.g. master0
+func (rc *RedisExecClienter) GetSentinelInfo(sentinel RedisParam) (map[string]string, error) {
+	output, err := rc.RedisApi.sentinelInfo(sentinel.NameSpace, sentinel.Name, sentinel.ContainerName, "sentinel")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(output), nil
+}
+
+// GetServerInfo returns the fields of INFO server, e.g. run_id
+func (rc *RedisExecClienter) GetServerInfo(redisParam RedisParam, password string) (map[string]string, error) {
+	output, err := rc.RedisApi.info(redisParam.NameSpace, redisParam.Name, redisParam.ContainerName, password, "server")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(output), nil
+}
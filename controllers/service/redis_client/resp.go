@@ -0,0 +1,211 @@
+package redis_client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// respConn is a minimal RESP2 connection, just enough to send the commands
+// the operator needs and read back a single reply per command.
+type respConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	ctx     context.Context
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// dialResp connects to addr, with TLS when tlsConfig is not nil. The connection is closed when the ctx is done.
+func dialResp(ctx context.Context, addr string, tlsConfig *tls.Config, dialTimeout, timeout time.Duration) (*respConn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &respConn{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		timeout: timeout,
+		ctx:     ctx,
+		closed:  make(chan struct{}),
+	}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.conn.Close()
+			case <-c.closed:
+			}
+		}()
+	}
+	return c, nil
+}
+
+func (c *respConn) Close() error {
+	err := c.conn.Close()
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return err
+}
+
+// Do sends one command and waits for its reply.
+func (c *respConn) Do(args ...string) (respReply, error) {
+	if c.timeout > 0 {
+		if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return respReply{}, err
+		}
+	}
+	if _, err := c.conn.Write(encodeRespCommand(args...)); err != nil {
+		return respReply{}, c.contextErr(err)
+	}
+	reply, err := readRespReply(c.reader)
+	return reply, c.contextErr(err)
+}
+
+// contextErr returns the error of the ctx instead of the error of the closed connection
+func (c *respConn) contextErr(err error) error {
+	if err != nil && c.ctx.Err() != nil {
+		return c.ctx.Err()
+	}
+	return err
+}
+
+func encodeRespCommand(args ...string) []byte {
+	var sb strings.Builder
+	sb.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		sb.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n")
+		sb.WriteString(arg)
+		sb.WriteString("\r\n")
+	}
+	return []byte(sb.String())
+}
+
+type respKind byte
+
+const (
+	respSimpleString respKind = '+'
+	respError        respKind = '-'
+	respInteger      respKind = ':'
+	respBulkString   respKind = '$'
+	respArray        respKind = '*'
+)
+
+type respReply struct {
+	Kind  respKind
+	Str   string
+	Int   int64
+	Nil   bool
+	Elems []respReply
+}
+
+// IsError reports whether the server answered with an error reply.
+func (r respReply) IsError() bool {
+	return r.Kind == respError
+}
+
+// String renders the reply the same way `redis-cli` does when stdout is not
+// a tty, so that the parsers written for RedisExecApi output keep working
+// unchanged: errors and status replies as plain text, integers as digits
+// and arrays one element per line.
+func (r respReply) String() string {
+	switch r.Kind {
+	case respInteger:
+		return strconv.FormatInt(r.Int, 10)
+	case respArray:
+		lines := make([]string, 0, len(r.Elems))
+		for _, elem := range r.Elems {
+			lines = append(lines, elem.String())
+		}
+		return strings.Join(lines, "\n")
+	default:
+		if r.Nil {
+			return ""
+		}
+		return r.Str
+	}
+}
+
+func readRespLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed RESP line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+func readRespReply(reader *bufio.Reader) (respReply, error) {
+	line, err := readRespLine(reader)
+	if err != nil {
+		return respReply{}, err
+	}
+	if len(line) == 0 {
+		return respReply{}, errors.New("empty RESP line")
+	}
+
+	kind := respKind(line[0])
+	payload := line[1:]
+	switch kind {
+	case respSimpleString, respError:
+		return respReply{Kind: kind, Str: payload}, nil
+	case respInteger:
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return respReply{}, err
+		}
+		return respReply{Kind: kind, Int: n}, nil
+	case respBulkString:
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return respReply{}, err
+		}
+		if n < 0 {
+			return respReply{Kind: kind, Nil: true}, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return respReply{}, err
+		}
+		return respReply{Kind: kind, Str: string(buf[:n])}, nil
+	case respArray:
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return respReply{}, err
+		}
+		if n < 0 {
+			return respReply{Kind: kind, Nil: true}, nil
+		}
+		elems := make([]respReply, 0, n)
+		for i := 0; i < n; i++ {
+			elem, err := readRespReply(reader)
+			if err != nil {
+				return respReply{}, err
+			}
+			elems = append(elems, elem)
+		}
+		return respReply{Kind: kind, Elems: elems}, nil
+	default:
+		return respReply{}, fmt.Errorf("unknown RESP type %q", line[0])
+	}
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"strings"
+)
+
+// ACLUserRules is an ACL user and the rules of ACL SETUSER
+type ACLUserRules struct {
+	Name  string
+	Rules []string
+}
+
+// GetACLUserRules returns the rules of ACL SETUSER, reset first so that the rules removed from the spec
+// are removed from the user. The password is passed as its sha256 so it is not in the command line
+func GetACLUserRules(user roav1.ACLUser, password string) ACLUserRules {
+	rules := []string{"reset", "on", "#" + sha256Hex(password)}
+	for _, key := range user.Keys {
+		rules = append(rules, "~"+key)
+	}
+	for _, channel := range user.Channels {
+		rules = append(rules, "&"+channel)
+	}
+	rules = append(rules, user.Commands...)
+	return ACLUserRules{Name: user.Name, Rules: rules}
+}
+
+// GetOperatorACLUserRules returns the rules of roav1.OperatorACLUser, the slaves replicate and the
+// sentinels monitor with it
+func GetOperatorACLUserRules(password string) ACLUserRules {
+	rules := []string{"reset", "on", "nopass", "~*", "&*", "+@all"}
+	if password != "" {
+		rules[2] = "#" + sha256Hex(password)
+	}
+	return ACLUserRules{Name: roav1.OperatorACLUser, Rules: rules}
+}
+
+// GetACLUserMd5 is the md5 of the rules recorded in Status.Redis.ACLUsers
+func GetACLUserMd5(user ACLUserRules) string {
+	return MD5(user.Name + " " + strings.Join(user.Rules, " "))
+}
+
+// GetMasterUser returns the user the slaves replicate and the sentinels monitor with, default when
+// Spec.Auth.Users is not set
+func GetMasterUser(rf *roav1.Redis) string {
+	if len(rf.Spec.Auth.Users) == 0 {
+		return "default"
+	}
+	return roav1.OperatorACLUser
+}
+
+func sha256Hex(str string) string {
+	h := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+// the reasons of Status.Conditions
+const (
+	ReasonReady               = "Ready"
+	ReasonPodsNotReady        = "PodsNotReady"
+	ReasonNoMasterElected     = "NoMasterElected"
+	ReasonMasterElected       = "MasterElected"
+	ReasonNoMaster            = "NoMaster"
+	ReasonMultipleMasters     = "MultipleMasters"
+	ReasonSplitBrainResolved  = "SplitBrainResolved"
+	ReasonConsistent          = "Consistent"
+	ReasonHealing             = "Healing"
+	ReasonApplied             = "Applied"
+	ReasonApplying            = "Applying"
+	ReasonReconcileSucceeded  = "ReconcileSucceeded"
+	ReasonInvalidSpec         = "InvalidSpec"
+	ReasonEnsureFailed        = "EnsureFailed"
+	ReasonScaleDownFailed     = "ScaleDownFailed"
+	ReasonCheckAndHealFailed  = "CheckAndHealFailed"
+	ReasonCheckClusterFailed  = "CheckClusterFailed"
+	ReasonSwitchoverFailed    = "SwitchoverFailed"
+	ReasonSwitchingOver       = "SwitchingOver"
+	ReasonRollingUpdate       = "RollingUpdate"
+	ReasonRollingUpdateFailed = "RollingUpdateFailed"
+	ReasonACLUsersFailed      = "ACLUsersFailed"
+	ReasonTLSReloadFailed     = "TLSReloadFailed"
+	ReasonObserveFailed       = "ObserveFailed"
+	ReasonSynced              = "Synced"
+	ReasonReplicasDegraded    = "ReplicasDegraded"
+)
+
+// MergeConditions returns Status.Conditions updated with the conditions observed by a reconcile of the generation.
+// Available and Degraded are computed here: failedReason and errs are of the step that failed, errs may also be
+// NeedReCheckError which means the reconcile is waiting for a heal to take effect
+func MergeConditions(rf *roav1.Redis, observed []metav1.Condition, generation int64, failedReason string, errs []error) []metav1.Condition {
+	conditions := make([]metav1.Condition, 0, len(rf.Status.Conditions)+len(observed)+2)
+	for _, condition := range rf.Status.Conditions {
+		conditions = append(conditions, *condition.DeepCopy())
+	}
+	for _, condition := range observed {
+		meta.SetStatusCondition(&conditions, condition)
+	}
+	if rf.IsClusterMode() {
+		meta.RemoveStatusCondition(&conditions, roav1.ConditionSentinelsConsistent)
+		meta.RemoveStatusCondition(&conditions, roav1.ConditionReplicasSynced)
+	}
+
+	switch {
+	case !rf.Status.State.Ready:
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    roav1.ConditionAvailable,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonPodsNotReady,
+			Message: "not all the pods are ready",
+		})
+	case !meta.IsStatusConditionTrue(conditions, roav1.ConditionMasterElected):
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    roav1.ConditionAvailable,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonNoMasterElected,
+			Message: "the master is not elected",
+		})
+	default:
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    roav1.ConditionAvailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonReady,
+			Message: "all the pods are ready and the master is elected",
+		})
+	}
+
+	if len(errs) > 0 {
+		if failedReason == "" {
+			failedReason = ReasonHealing
+		}
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			if err != nil {
+				messages = append(messages, err.Error())
+			}
+		}
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    roav1.ConditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  failedReason,
+			Message: strings.Join(messages, "; "),
+		})
+	} else {
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    roav1.ConditionDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonReconcileSucceeded,
+			Message: "",
+		})
+	}
+
+	for i := range conditions {
+		conditions[i].ObservedGeneration = generation
+	}
+	return conditions
+}
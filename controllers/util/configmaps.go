@@ -21,7 +21,7 @@ func CreateSentinelConfigMapByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerRef
 	realSentinelConfigFileContent := fmt.Sprintf("sentinel monitor %s %s %s %s\n%s", redisGroupName, masterIp, masterPort, quorum, sentinelConfigFile)
 
 	port := GetSentinelPortFromSpecByIndex(rf, index)
-	realSentinelConfigFileContent = fmt.Sprintf("port %s\n%s", port, realSentinelConfigFileContent)
+	realSentinelConfigFileContent = fmt.Sprintf("%s\n%s", getPortConfig(rf, port), realSentinelConfigFileContent)
 
 	if password != "" {
 		realSentinelConfigFileContent = fmt.Sprintf("%s\nsentinel auth-pass %s \"%s\"", realSentinelConfigFileContent, redisGroupName, password)
@@ -47,7 +47,7 @@ func CreateSentinelConfigMapObjByExistingObjByIndex(rf *roav1.Redis, password st
 	realSentinelConfigFileContent := fmt.Sprintf("sentinel monitor mymaster %s %s %s\n%s", masterIp, masterPort, quorum, sentinelConfigFile)
 
 	port := GetSentinelPortFromSpecByIndex(rf, index)
-	realSentinelConfigFileContent = fmt.Sprintf("port %s\n%s", port, realSentinelConfigFileContent)
+	realSentinelConfigFileContent = fmt.Sprintf("%s\n%s", getPortConfig(rf, port), realSentinelConfigFileContent)
 
 	if password != "" {
 		realSentinelConfigFileContent = fmt.Sprintf("%s\nsentinel auth-pass %s \"%s\"", realSentinelConfigFileContent, redisGroupName, password)
@@ -98,10 +98,10 @@ func CreateRedisMasterConfigMap(rf *roav1.Redis, ownerRefs []metav1.OwnerReferen
 		panic(err)
 	}
 
-	redisConfigFileContent := tplOutput.String()
+	redisConfigFileContent := setRedisSlavePriority(rf, tplOutput.String(), 0)
 
 	_, port := GetMasterIpAndPortFromSpec(rf)
-	redisConfigFileContent = fmt.Sprintf("port %s\n%s", port, redisConfigFileContent)
+	redisConfigFileContent = fmt.Sprintf("%s\n%s", getPortConfig(rf, port), redisConfigFileContent)
 
 	if password != "" {
 		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth \"%s\"\nrequirepass \"%s\"", redisConfigFileContent, password, password)
@@ -135,13 +135,13 @@ func CreateRedisSlaveConfigMapByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerR
 		panic(err)
 	}
 
-	redisConfigFileContent := tplOutput.String()
+	redisConfigFileContent := setRedisSlavePriority(rf, tplOutput.String(), index)
 
 	masterIp, masterPort := GetMasterIpAndPortFromSpec(rf)
 	redisConfigFileContent = fmt.Sprintf("replicaof %s %s\n%s", masterIp, masterPort, redisConfigFileContent)
 
 	port := GetRedisPortFromSpecByIndex(rf, index)
-	redisConfigFileContent = fmt.Sprintf("port %s\n%s", port, redisConfigFileContent)
+	redisConfigFileContent = fmt.Sprintf("%s\n%s", getPortConfig(rf, port), redisConfigFileContent)
 
 	if password != "" {
 		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth \"%s\"\nrequirepass \"%s\"", redisConfigFileContent, password, password)
@@ -180,10 +180,10 @@ func CreateRedisMasterConfigMapObjByExistingObj(rf *roav1.Redis, password string
 		panic(err)
 	}
 
-	redisConfigFileContent := tplOutput.String()
+	redisConfigFileContent := setRedisSlavePriority(rf, tplOutput.String(), 0)
 
 	_, port := GetMasterIpAndPortFromSpec(rf)
-	redisConfigFileContent = fmt.Sprintf("port %s\n%s", port, redisConfigFileContent)
+	redisConfigFileContent = fmt.Sprintf("%s\n%s", getPortConfig(rf, port), redisConfigFileContent)
 
 	if password != "" {
 		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth \"%s\"\nrequirepass \"%s\"", redisConfigFileContent, password, password)
@@ -207,13 +207,13 @@ func CreateRedisSlaveConfigMapObjByExistingObjByIndex(rf *roav1.Redis, password
 		panic(err)
 	}
 
-	redisConfigFileContent := tplOutput.String()
+	redisConfigFileContent := setRedisSlavePriority(rf, tplOutput.String(), index)
 
 	masterIp, masterPort := GetMasterIpAndPortFromSpec(rf)
 	redisConfigFileContent = fmt.Sprintf("replicaof %s %s\n%s", masterIp, masterPort, redisConfigFileContent)
 
 	port := GetRedisPortFromSpecByIndex(rf, index)
-	redisConfigFileContent = fmt.Sprintf("port %s\n%s", port, redisConfigFileContent)
+	redisConfigFileContent = fmt.Sprintf("%s\n%s", getPortConfig(rf, port), redisConfigFileContent)
 
 	if password != "" {
 		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth \"%s\"\nrequirepass \"%s\"", redisConfigFileContent, password, password)
@@ -236,11 +236,19 @@ func GetRedisReadinessConfigMapName(rf *roav1.Redis) string {
 }
 
 func GetRedisConfigMapNameByIndex(rf *roav1.Redis, index int) string {
-	return generateName(redisRoleName, rf.Name) + "-" + strconv.Itoa(index)
+	return GetRedisConfigMapRootName(rf) + "-" + strconv.Itoa(index)
 }
 
 func GetSentinelConfigMapNameByIndex(rf *roav1.Redis, index int) string {
-	return generateName(sentinelRoleName, rf.Name) + "-" + strconv.Itoa(index)
+	return GetSentinelConfigMapRootName(rf) + "-" + strconv.Itoa(index)
+}
+
+func GetRedisConfigMapRootName(rf *roav1.Redis) string {
+	return generateName(redisRoleName, rf.Name)
+}
+
+func GetSentinelConfigMapRootName(rf *roav1.Redis) string {
+	return generateName(sentinelRoleName, rf.Name)
 }
 
 func GetSentinelSlaveConfigMapLabels(rf *roav1.Redis) map[string]string {
@@ -1,9 +1,7 @@
 package util
 
 import (
-	"fmt"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
-	"github.com/zhizuqiu/redis-operator/controllers/util/sm4"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -13,7 +11,13 @@ import (
 	"strconv"
 )
 
-func CreateExporterDeployment(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, password string) *v1.Deployment {
+// ExporterPasswordVersionAnnotation is the resourceVersion of the exporter Secret on the pod template of the
+// exporter, the password is read from the Secret when the container starts so it is restarted when it changes
+const ExporterPasswordVersionAnnotation = "redis.component.zhizuqiu/password-version"
+
+const exporterPasswordKey = "password"
+
+func CreateExporterDeployment(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, passwordVersion string) *v1.Deployment {
 	name := GetExporterRootName(rf)
 	namespace := rf.Namespace
 
@@ -23,18 +27,13 @@ func CreateExporterDeployment(rf *roav1.Redis, ownerRefs []metav1.OwnerReference
 	productId := GetProductId(rf)
 	regionId := GetRegionId(rf)
 	instanceId := GetInstanceId(rf)
-	annotations := getExporterAnnotations(rf)
+	annotations := getExporterAnnotations(rf, passwordVersion)
 
 	nodeAffinity := getExporterNodeAffinity(rf)
 	affinity := getNodeAndPodAffinity(rf.Spec.Sentinel.Affinity, rf.Spec.Sentinel.EnabledPodAntiAffinity, labels, nodeAffinity)
 	port := GetExporterPortFromSpec(rf)
 	portInt, _ := strconv.Atoi(port)
 
-	sm4Password, err := sm4.EncryptSm4([]byte(password), sm4.Sm4Key)
-	if err != nil {
-		fmt.Println(err)
-	}
-
 	dd := &v1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            name,
@@ -96,10 +95,7 @@ func CreateExporterDeployment(rf *roav1.Redis, ownerRefs []metav1.OwnerReference
 									Name:  "REDIS_EXPORTER_INSTANCE_NAME",
 									Value: rf.Name,
 								},
-								{
-									Name:  "REDIS_SM4_PASSWORD",
-									Value: sm4Password,
-								},
+								getExporterPasswordEnv(rf),
 								{
 									Name:  "TZ",
 									Value: "Asia/Shanghai",
@@ -150,31 +146,57 @@ func CreateExporterDeployment(rf *roav1.Redis, ownerRefs []metav1.OwnerReference
 		},
 	}
 
+	if rf.IsTLSEnabled() {
+		setExporterTLS(rf, dd)
+	}
+
 	return dd
 }
 
-func CreateExporterDeploymentObjByExistingObj(rf *roav1.Redis, password string, oldDeplyment *v1.Deployment) *v1.Deployment {
+// setExporterTLS mounts the certificates of Spec.TLS in the exporter, which connects with rediss://
+func setExporterTLS(rf *roav1.Redis, dd *v1.Deployment) {
+	for i, c := range dd.Spec.Template.Spec.Containers {
+		if c.Name != exporterRoleName {
+			continue
+		}
+		dd.Spec.Template.Spec.Containers[i].VolumeMounts = append(c.VolumeMounts, getTLSVolumeMount())
+		dd.Spec.Template.Spec.Containers[i].Env = append(c.Env,
+			corev1.EnvVar{
+				Name:  "REDIS_EXPORTER_TLS_CLIENT_CERT_FILE",
+				Value: GetTLSCertFile(),
+			},
+			corev1.EnvVar{
+				Name:  "REDIS_EXPORTER_TLS_CLIENT_KEY_FILE",
+				Value: GetTLSKeyFile(),
+			},
+			corev1.EnvVar{
+				Name:  "REDIS_EXPORTER_TLS_CA_CERT_FILE",
+				Value: GetTLSCACertFile(),
+			},
+		)
+	}
+	dd.Spec.Template.Spec.Volumes = append(dd.Spec.Template.Spec.Volumes, getTLSVolume(rf))
+}
+
+func CreateExporterDeploymentObjByExistingObj(rf *roav1.Redis, passwordVersion string, oldDeplyment *v1.Deployment) *v1.Deployment {
 
 	redisAddr := GetRedisAddr(rf)
 	sentinelAddr := GetSentinelAddr(rf)
 
-	sm4Password, err := sm4.EncryptSm4([]byte(password), sm4.Sm4Key)
-	if err != nil {
-		fmt.Println(err)
-	}
-
 	oldDeplyment.Spec.Template.Spec.Containers = SetEnvByContainerName(exporterRoleName, oldDeplyment.Spec.Template.Spec.Containers, "REDIS_ADDR", redisAddr)
 	oldDeplyment.Spec.Template.Spec.Containers = SetEnvByContainerName(exporterRoleName, oldDeplyment.Spec.Template.Spec.Containers, "REDIS_SENTINEL_ADDR", sentinelAddr)
-	oldDeplyment.Spec.Template.Spec.Containers = SetEnvByContainerName(exporterRoleName, oldDeplyment.Spec.Template.Spec.Containers, "REDIS_SM4_PASSWORD", sm4Password)
+	oldDeplyment.Spec.Template.Spec.Containers = setExporterPasswordEnv(rf, oldDeplyment.Spec.Template.Spec.Containers)
+	oldDeplyment.Spec.Template.Annotations = MergeLabels(oldDeplyment.Spec.Template.Annotations, getExporterAnnotations(rf, passwordVersion))
 
 	return oldDeplyment
 }
 
 func ExporterDeploymentEqual(a *v1.Deployment, b *v1.Deployment) bool {
 
-	redisAddrOk := reflect.DeepEqual(getEnvByContainerName(redisName, a.Spec.Template.Spec.Containers, "REDIS_ADDR"), getEnvByContainerName(redisName, b.Spec.Template.Spec.Containers, "REDIS_ADDR"))
-	sentinelAddrOk := reflect.DeepEqual(getEnvByContainerName(redisName, a.Spec.Template.Spec.Containers, "REDIS_SENTINEL_ADDR"), getEnvByContainerName(redisName, b.Spec.Template.Spec.Containers, "REDIS_SENTINEL_ADDR"))
-	passOk := reflect.DeepEqual(getEnvByContainerName(redisName, a.Spec.Template.Spec.Containers, "REDIS_SM4_PASSWORD"), getEnvByContainerName(redisName, b.Spec.Template.Spec.Containers, "REDIS_SM4_PASSWORD"))
+	redisAddrOk := reflect.DeepEqual(getEnvByContainerName(exporterRoleName, a.Spec.Template.Spec.Containers, "REDIS_ADDR"), getEnvByContainerName(exporterRoleName, b.Spec.Template.Spec.Containers, "REDIS_ADDR"))
+	sentinelAddrOk := reflect.DeepEqual(getEnvByContainerName(exporterRoleName, a.Spec.Template.Spec.Containers, "REDIS_SENTINEL_ADDR"), getEnvByContainerName(exporterRoleName, b.Spec.Template.Spec.Containers, "REDIS_SENTINEL_ADDR"))
+	passOk := reflect.DeepEqual(getEnvVarByContainerName(exporterRoleName, a.Spec.Template.Spec.Containers, "REDIS_PASSWORD"), getEnvVarByContainerName(exporterRoleName, b.Spec.Template.Spec.Containers, "REDIS_PASSWORD")) &&
+		a.Spec.Template.Annotations[ExporterPasswordVersionAnnotation] == b.Spec.Template.Annotations[ExporterPasswordVersionAnnotation]
 
 	if redisAddrOk && sentinelAddrOk && passOk {
 		return true
@@ -182,6 +204,77 @@ func ExporterDeploymentEqual(a *v1.Deployment, b *v1.Deployment) bool {
 	return false
 }
 
+// CreateExporterSecret returns the Secret the exporter reads the password from
+func CreateExporterSecret(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, password string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GetExporterSecretName(rf),
+			Namespace:       rf.Namespace,
+			Labels:          GetExporterDeploymentLabels(rf),
+			OwnerReferences: ownerRefs,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			exporterPasswordKey: []byte(password),
+		},
+	}
+}
+
+// ExporterSecretEqual compares the passwords of the Secrets
+func ExporterSecretEqual(a *corev1.Secret, b *corev1.Secret) bool {
+	return reflect.DeepEqual(a.Data[exporterPasswordKey], b.Data[exporterPasswordKey])
+}
+
+func GetExporterSecretName(rf *roav1.Redis) string {
+	return GetExporterRootName(rf)
+}
+
+func getExporterPasswordEnv(rf *roav1.Redis) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: "REDIS_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: GetExporterSecretName(rf),
+				},
+				Key: exporterPasswordKey,
+			},
+		},
+	}
+}
+
+// setExporterPasswordEnv replaces REDIS_SM4_PASSWORD, the password encrypted with the compiled-in key the
+// exporters created before the exporter Secret have, with REDIS_PASSWORD
+func setExporterPasswordEnv(rf *roav1.Redis, oldContainers []corev1.Container) []corev1.Container {
+	containers := make([]corev1.Container, 0)
+	for _, c := range oldContainers {
+		if c.Name == exporterRoleName {
+			envs := make([]corev1.EnvVar, 0)
+			for _, envVar := range c.Env {
+				if envVar.Name != "REDIS_SM4_PASSWORD" && envVar.Name != "REDIS_PASSWORD" {
+					envs = append(envs, envVar)
+				}
+			}
+			c.Env = append(envs, getExporterPasswordEnv(rf))
+		}
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+func getEnvVarByContainerName(name string, container []corev1.Container, key string) *corev1.EnvVar {
+	for _, c := range container {
+		if c.Name == name {
+			for _, envVar := range c.Env {
+				if envVar.Name == key {
+					return envVar.DeepCopy()
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func getEnvByContainerName(name string, container []corev1.Container, key string) string {
 	for _, c := range container {
 		if c.Name == name {
@@ -222,33 +315,42 @@ func GetExporterRootName(rf *roav1.Redis) string {
 }
 
 func GetRedisAddr(rf *roav1.Redis) string {
+	scheme := getExporterScheme(rf)
 	addr := ""
 	for i := 0; i < int(rf.Spec.Redis.Replicas); i++ {
 		host := GetRedisHostByIndex(rf, i)
 		port := GetRedisPortFromSpecByIndex(rf, i)
 		if 0 == i {
-			addr = addr + "redis://" + host + ":" + port
+			addr = addr + scheme + host + ":" + port
 		} else {
-			addr = addr + ",redis://" + host + ":" + port
+			addr = addr + "," + scheme + host + ":" + port
 		}
 	}
 	return addr
 }
 
 func GetSentinelAddr(rf *roav1.Redis) string {
+	scheme := getExporterScheme(rf)
 	addr := ""
 	for i := 0; i < int(rf.Spec.Sentinel.Replicas); i++ {
 		host := GetSentinelHostByIndex(rf, i)
 		port := GetSentinelPortFromSpecByIndex(rf, i)
 		if 0 == i {
-			addr = addr + "redis://" + host + ":" + port
+			addr = addr + scheme + host + ":" + port
 		} else {
-			addr = addr + ",redis://" + host + ":" + port
+			addr = addr + "," + scheme + host + ":" + port
 		}
 	}
 	return addr
 }
 
+func getExporterScheme(rf *roav1.Redis) string {
+	if rf.IsTLSEnabled() {
+		return "rediss://"
+	}
+	return "redis://"
+}
+
 func GetProductId(rf *roav1.Redis) string {
 	productId := "unknown"
 	if rf.Labels != nil {
@@ -279,8 +381,9 @@ func GetInstanceId(rf *roav1.Redis) string {
 	return instanceId
 }
 
-func getExporterAnnotations(rf *roav1.Redis) map[string]string {
+func getExporterAnnotations(rf *roav1.Redis, passwordVersion string) map[string]string {
 	annotations := make(map[string]string)
+	annotations[ExporterPasswordVersionAnnotation] = passwordVersion
 	return annotations
 }
 
@@ -0,0 +1,77 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultKMSTimeout = 10 * time.Second
+
+// kmsDecryptRequest is posted to the plugin, KeyID is the key material of the Redis, if any
+type kmsDecryptRequest struct {
+	Ciphertext string `json:"ciphertext"`
+	KeyID      string `json:"keyID,omitempty"`
+}
+
+type kmsDecryptResponse struct {
+	Plaintext string `json:"plaintext"`
+	Error     string `json:"error,omitempty"`
+}
+
+// KMSDecoder decrypts the passwords with a local plugin, which holds the credentials of the KMS. The plugin
+// answers a POST of kmsDecryptRequest with kmsDecryptResponse
+type KMSDecoder struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewKMSDecoder returns a decoder posting to an http url, or to /decrypt on a unix socket with unix:///path
+func NewKMSDecoder(endpoint string) *KMSDecoder {
+	client := &http.Client{Timeout: defaultKMSTimeout}
+	url := endpoint
+	if strings.HasPrefix(endpoint, "unix://") {
+		socket := strings.TrimPrefix(endpoint, "unix://")
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		url = "http://kms-plugin/decrypt"
+	}
+	return &KMSDecoder{
+		URL:    url,
+		Client: client,
+	}
+}
+
+func (k *KMSDecoder) Decode(value string, key []byte) (string, error) {
+	body, err := json.Marshal(kmsDecryptRequest{Ciphertext: value, KeyID: string(key)})
+	if err != nil {
+		return "", err
+	}
+	resp, err := k.Client.Post(k.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var decrypted kmsDecryptResponse
+	if err := json.Unmarshal(data, &decrypted); err != nil {
+		return "", fmt.Errorf("kms plugin returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode != http.StatusOK || decrypted.Error != "" {
+		return "", fmt.Errorf("kms plugin returned %d: %s", resp.StatusCode, decrypted.Error)
+	}
+	return decrypted.Plaintext, nil
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"encoding/base64"
+	"fmt"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/util/sm4"
+	"k8s.io/apimachinery/pkg/types"
+	"sync"
+)
+
+// Decoder decodes Spec.Auth.Password.Value, key is the key material of its encode type, nil when
+// neither Spec.Auth.Password.KeySecret nor DefaultKeySecret has one
+type Decoder interface {
+	Decode(value string, key []byte) (string, error)
+}
+
+// DecoderFunc adapts a plain function to Decoder.
+type DecoderFunc func(value string, key []byte) (string, error)
+
+func (f DecoderFunc) Decode(value string, key []byte) (string, error) {
+	return f(value, key)
+}
+
+// DefaultKeySecret is the Secret with the keys of the Redis without Spec.Auth.Password.KeySecret, the
+// key of an encode type is the data of the same name, e.g. sm4. It is set by the --password-key-secret flag
+var DefaultKeySecret types.NamespacedName
+
+var (
+	mu       sync.RWMutex
+	decoders = map[roav1.PasswordEncodeType]Decoder{}
+)
+
+func init() {
+	Register(roav1.BASE64, DecoderFunc(decodeBase64))
+	Register(roav1.SM4, DecoderFunc(decodeSm4))
+	Register(roav1.AESGCM, DecoderFunc(decodeAESGCM))
+}
+
+// Register adds or replaces the decoder of an encode type
+func Register(encodeType roav1.PasswordEncodeType, decoder Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	decoders[encodeType] = decoder
+}
+
+// Get returns the decoder of an encode type, base64 when it is empty
+func Get(encodeType roav1.PasswordEncodeType) (Decoder, bool) {
+	if encodeType == "" {
+		encodeType = roav1.GetDefaultPasswordEncodeType()
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	decoder, ok := decoders[encodeType]
+	return decoder, ok
+}
+
+// Decode decodes value with the decoder of encodeType
+func Decode(encodeType roav1.PasswordEncodeType, value string, key []byte) (string, error) {
+	decoder, ok := Get(encodeType)
+	if !ok {
+		return "", fmt.Errorf("no decoder for the password encode type %q", encodeType)
+	}
+	return decoder.Decode(value, key)
+}
+
+func decodeBase64(value string, _ []byte) (string, error) {
+	passwordByte, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	return string(passwordByte), nil
+}
+
+// decodeSm4 falls back to the compiled-in key, which the instances created before the key Secrets use
+func decodeSm4(value string, key []byte) (string, error) {
+	if len(key) == 0 {
+		key = sm4.Sm4Key
+	}
+	return sm4.DecryptSm4([]byte(value), key)
+}
@@ -0,0 +1,97 @@
+package password
+
+import (
+	"encoding/json"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"github.com/zhizuqiu/redis-operator/controllers/util/sm4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	var tests = []struct {
+		encodeType roav1.PasswordEncodeType
+		in         string
+		key        []byte
+		expected   string
+	}{
+		{"", "cGFzcw==", nil, "pass"},
+		{roav1.BASE64, "cGFzcw==", nil, "pass"},
+		{roav1.SM4, "fbd297723eb1d4a925b69d1437bb91ae", nil, "pass"},
+		{roav1.SM4, "fbd297723eb1d4a925b69d1437bb91ae", sm4.Sm4Key, "pass"},
+	}
+
+	for _, tt := range tests {
+		actual, err := Decode(tt.encodeType, tt.in, tt.key)
+		if err != nil {
+			t.Fatalf("Decode(%s, %s) error: %s", tt.encodeType, tt.in, err)
+		}
+		if actual != tt.expected {
+			t.Fatalf("Decode(%s, %s) = %s; expected %s", tt.encodeType, tt.in, actual, tt.expected)
+		}
+	}
+
+	if _, err := Decode("rot13", "cGFzcw==", nil); err == nil {
+		t.Fatalf("Decode of an unknown encode type should fail")
+	}
+}
+
+func TestAESGCM(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	value, err := EncryptAESGCM("pass", key)
+	if err != nil {
+		t.Fatalf("EncryptAESGCM error: %s", err)
+	}
+	actual, err := Decode(roav1.AESGCM, value, key)
+	if err != nil {
+		t.Fatalf("Decode error: %s", err)
+	}
+	if actual != "pass" {
+		t.Fatalf("Decode(%s) = %s; expected pass", value, actual)
+	}
+
+	if _, err := Decode(roav1.AESGCM, value, nil); err == nil {
+		t.Fatalf("Decode without a key should fail")
+	}
+	if _, err := Decode(roav1.AESGCM, value, []byte("fedcba9876543210fedcba9876543210")); err == nil {
+		t.Fatalf("Decode with another key should fail")
+	}
+}
+
+func TestKMSDecoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req kmsDecryptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Ciphertext != "sealed" || req.KeyID != "key-1" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(kmsDecryptResponse{Error: "access denied"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(kmsDecryptResponse{Plaintext: "pass"})
+	}))
+	defer server.Close()
+
+	Register(roav1.KMS, NewKMSDecoder(server.URL))
+	defer func() {
+		mu.Lock()
+		delete(decoders, roav1.KMS)
+		mu.Unlock()
+	}()
+
+	actual, err := Decode(roav1.KMS, "sealed", []byte("key-1"))
+	if err != nil {
+		t.Fatalf("Decode error: %s", err)
+	}
+	if actual != "pass" {
+		t.Fatalf("Decode = %s; expected pass", actual)
+	}
+
+	if _, err := Decode(roav1.KMS, "sealed", []byte("key-2")); err == nil {
+		t.Fatalf("Decode with a denied key should fail")
+	}
+}
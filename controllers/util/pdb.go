@@ -0,0 +1,74 @@
+package util
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+)
+
+func GetRedisPodDisruptionBudgetName(rf *roav1.Redis) string {
+	return GetRedisRootName(rf)
+}
+
+func GetSentinelPodDisruptionBudgetName(rf *roav1.Redis) string {
+	return GetSentinelRootName(rf)
+}
+
+// CreateRedisPodDisruptionBudget creates the PodDisruptionBudget of the redis pods, all of them but one are
+// available by default
+func CreateRedisPodDisruptionBudget(rf *roav1.Redis, ownerRefs []metav1.OwnerReference) *policyv1.PodDisruptionBudget {
+	return createPodDisruptionBudget(rf, ownerRefs, GetRedisPodDisruptionBudgetName(rf), GetRedisLabels(rf),
+		rf.Spec.Redis.PodDisruptionBudget, rf.Spec.Redis.Replicas-1)
+}
+
+// CreateSentinelPodDisruptionBudget creates the PodDisruptionBudget of the sentinel pods, a quorum of them is
+// available by default, so that a drain does not stop the failover. One sentinel can always be evicted, a
+// single sentinel would otherwise block the drain
+func CreateSentinelPodDisruptionBudget(rf *roav1.Redis, ownerRefs []metav1.OwnerReference) *policyv1.PodDisruptionBudget {
+	minAvailable := GetQuorum(rf)
+	if minAvailable > rf.Spec.Sentinel.Replicas-1 {
+		minAvailable = rf.Spec.Sentinel.Replicas - 1
+	}
+	return createPodDisruptionBudget(rf, ownerRefs, GetSentinelPodDisruptionBudgetName(rf), GetSentinelLabels(rf),
+		rf.Spec.Sentinel.PodDisruptionBudget, minAvailable)
+}
+
+func createPodDisruptionBudget(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, name string, labels map[string]string,
+	settings roav1.PodDisruptionBudgetSettings, defaultMinAvailable int32) *policyv1.PodDisruptionBudget {
+	spec := policyv1.PodDisruptionBudgetSpec{
+		Selector: &metav1.LabelSelector{
+			MatchLabels: labels,
+		},
+	}
+	if settings.MaxUnavailable != nil {
+		maxUnavailable := *settings.MaxUnavailable
+		spec.MaxUnavailable = &maxUnavailable
+	} else if settings.MinAvailable != nil {
+		minAvailable := *settings.MinAvailable
+		spec.MinAvailable = &minAvailable
+	} else {
+		if defaultMinAvailable < 0 {
+			defaultMinAvailable = 0
+		}
+		minAvailable := intstr.FromInt(int(defaultMinAvailable))
+		spec.MinAvailable = &minAvailable
+	}
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       rf.Namespace,
+			Labels:          labels,
+			OwnerReferences: ownerRefs,
+		},
+		Spec: spec,
+	}
+}
+
+func PodDisruptionBudgetEqual(a, b *policyv1.PodDisruptionBudget) bool {
+	return reflect.DeepEqual(a.Spec.MinAvailable, b.Spec.MinAvailable) &&
+		reflect.DeepEqual(a.Spec.MaxUnavailable, b.Spec.MaxUnavailable) &&
+		reflect.DeepEqual(a.Spec.Selector, b.Spec.Selector)
+}
@@ -68,18 +68,24 @@ func GetPort(pod v1.Pod) int32 {
 	}
 }
 
+// GetExpectedPodNumber returns the number of pods the instance has when all of them are created
+func GetExpectedPodNumber(redis *roav1.Redis) int {
+	number := int(redis.Spec.Redis.Replicas + redis.Spec.Sentinel.Replicas)
+	if redis.IsClusterMode() {
+		number = int(redis.Spec.Cluster.Shards * GetRedisClusterShardReplicas(redis))
+	}
+	if redis.Spec.Exporter.Enabled {
+		number++
+	}
+	return number
+}
+
 func GetGlobalPhase(redis *roav1.Redis, podList *v1.PodList) v1.PodPhase {
 	if podList == nil {
 		return v1.PodPending
 	}
-	if redis.Spec.Exporter.Enabled {
-		if len(podList.Items) != int(redis.Spec.Redis.Replicas+redis.Spec.Sentinel.Replicas)+1 {
-			return v1.PodPending
-		}
-	} else {
-		if len(podList.Items) != int(redis.Spec.Redis.Replicas+redis.Spec.Sentinel.Replicas) {
-			return v1.PodPending
-		}
+	if len(podList.Items) != GetExpectedPodNumber(redis) {
+		return v1.PodPending
 	}
 	for _, pod := range podList.Items {
 		if pod.Status.Phase != v1.PodRunning {
@@ -105,14 +111,8 @@ func GetGlobalReady(redis *roav1.Redis, podList *v1.PodList) bool {
 	if podList == nil {
 		return false
 	}
-	if redis.Spec.Exporter.Enabled {
-		if len(podList.Items) != int(redis.Spec.Redis.Replicas+redis.Spec.Sentinel.Replicas)+1 {
-			return false
-		}
-	} else {
-		if len(podList.Items) != int(redis.Spec.Redis.Replicas+redis.Spec.Sentinel.Replicas) {
-			return false
-		}
+	if len(podList.Items) != GetExpectedPodNumber(redis) {
+		return false
 	}
 	for _, pod := range podList.Items {
 		containerName := GetContainerNameFromLabel(pod)
@@ -133,3 +133,10 @@ func GetInstanceLabels(name string) map[string]string {
 		appPartOfLabelKey: appLabel,
 	}
 }
+
+func GetInstanceNameFromLabel(pod v1.Pod) string {
+	if pod.Labels == nil {
+		return ""
+	}
+	return pod.Labels[appNameLabelKey]
+}
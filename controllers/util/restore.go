@@ -0,0 +1,110 @@
+package util
+
+import (
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	redisRestoreName = "redis-restore"
+	// RestoreURLKey is the key of the download url in the restore secret
+	RestoreURLKey = "url"
+	restoreURLEnv = "RESTORE_URL"
+)
+
+// GetRedisRestoreSecretName returns the secret of the download url, it may be a presigned url
+// so it is not written to the StatefulSet
+func GetRedisRestoreSecretName(rf *roav1.Redis) string {
+	return generateName(redisRestoreName, rf.Name)
+}
+
+func CreateRedisRestoreSecret(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, url string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GetRedisRestoreSecretName(rf),
+			Namespace:       rf.Namespace,
+			Labels:          GetRedisLabels(rf),
+			OwnerReferences: ownerRefs,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			RestoreURLKey: url,
+		},
+	}
+}
+
+// NeedRestoreByIndex returns true when the StatefulSet of the index loads the backup, which is
+// the initial master, and only while the restore has not completed
+func NeedRestoreByIndex(rf *roav1.Redis, index int) bool {
+	return index == 0 && !rf.IsClusterMode() && !rf.Spec.Restore.From.IsEmpty() && rf.Status.Restore.Phase == roav1.Restoring
+}
+
+// getRedisRestoreContainer downloads the backup into /data before redis-config-copy, it does nothing
+// if /data already has data, e.g. the pod is restarted, or if the restore secret has been deleted.
+// The rdb is copied to the aof file when appendonly is enabled, since redis loads only the aof then
+// and accepts the rdb as the preamble of it
+func getRedisRestoreContainer(rf *roav1.Redis) corev1.Container {
+	image := rf.Spec.Restore.Image
+	if image == "" {
+		image = rf.Spec.Redis.Image
+	}
+	optional := true
+	script := `set -e
+if [ -z "${` + restoreURLEnv + `}" ]; then echo "no restore url"; exit 0; fi
+if [ -f /data/dump.rdb ] || [ -f /data/appendonly.aof ]; then echo "data exists, skip restore"; exit 0; fi
+wget -q -O /data/dump.rdb.tmp "${` + restoreURLEnv + `}"
+mv /data/dump.rdb.tmp /data/dump.rdb
+if grep -q "^appendonly yes" ` + GetRedisConfigPath() + `; then cp /data/dump.rdb /data/appendonly.aof; fi
+echo "restored"`
+
+	return corev1.Container{
+		Name:            redisRestoreName,
+		Image:           image,
+		ImagePullPolicy: pullPolicy(rf.Spec.Redis.ImagePullPolicy),
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      redisConfig,
+				MountPath: redisConfMountPath,
+			},
+			{
+				Name:      getRedisDataVolumeName(rf),
+				MountPath: "/data",
+			},
+		},
+		Command: []string{
+			"sh",
+			"-c",
+			script,
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "TZ",
+				Value: "Asia/Shanghai",
+			},
+			{
+				Name: restoreURLEnv,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: GetRedisRestoreSecretName(rf),
+						},
+						Key:      RestoreURLKey,
+						Optional: &optional,
+					},
+				},
+			},
+		},
+	}
+}
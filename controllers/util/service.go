@@ -5,6 +5,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
 	"strconv"
 )
 
@@ -148,3 +149,58 @@ func CreateRedisHeadlessServiceByIndex(rf *roav1.Redis, ownerRefs []metav1.Owner
 		},
 	}
 }
+
+func GetRedisMasterServiceName(rf *roav1.Redis) string {
+	return rf.Name + "-" + RoleMaster
+}
+
+func GetRedisReplicasServiceName(rf *roav1.Redis) string {
+	return rf.Name + "-replicas"
+}
+
+// GetRedisRoleServiceSelector selects the redis pods the checker labelled with role
+func GetRedisRoleServiceSelector(rf *roav1.Redis, role string) map[string]string {
+	return MergeLabels(GetRedisLabels(rf), map[string]string{
+		RoleLabelKey: role,
+	})
+}
+
+// CreateRedisMasterService creates the read/write Service of the master
+func CreateRedisMasterService(rf *roav1.Redis, ownerRefs []metav1.OwnerReference) *corev1.Service {
+	return createRedisRoleService(rf, ownerRefs, GetRedisMasterServiceName(rf), RoleMaster)
+}
+
+// CreateRedisReplicasService creates the read-only Service of the replicas
+func CreateRedisReplicasService(rf *roav1.Redis, ownerRefs []metav1.OwnerReference) *corev1.Service {
+	return createRedisRoleService(rf, ownerRefs, GetRedisReplicasServiceName(rf), RoleReplica)
+}
+
+func createRedisRoleService(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, name, role string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       rf.Namespace,
+			Labels:          GetRedisServiceLabels(rf),
+			OwnerReferences: ownerRefs,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: GetRedisRoleServiceSelector(rf, role),
+			Type:     corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name: redisName,
+					Port: redisContainerPort,
+					// the port of a pod with spec.redis.staticResources is the one of its index
+					TargetPort: intstr.FromString(redisName),
+					Protocol:   "TCP",
+				},
+			},
+		},
+	}
+}
+
+// RedisRoleServiceEqual returns true when the existing Service selects and exposes the pods as the desired one
+func RedisRoleServiceEqual(desired, existing *corev1.Service) bool {
+	return reflect.DeepEqual(desired.Spec.Selector, existing.Spec.Selector) &&
+		reflect.DeepEqual(desired.Spec.Ports, existing.Spec.Ports)
+}
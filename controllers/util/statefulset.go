@@ -1,13 +1,20 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
+	"strings"
 )
 
+// SpecHashAnnotation is the hash of the pod template a StatefulSet is built from, a StatefulSet
+// whose hash differs from the one of the current spec has to be rolled
+const SpecHashAnnotation = "redis.component.zhizuqiu/spec-hash"
+
 func generateName(typeName, metaName string) string {
 	return fmt.Sprintf("%s%s-%s", baseName, typeName, metaName)
 }
@@ -20,6 +27,14 @@ func GenerateSelectorLabels(component string, rf *roav1.Redis) map[string]string
 	}, rf.Labels)
 }
 
+// GetRedisNameByLabels returns the name of the Redis of the labels GenerateSelectorLabels sets
+func GetRedisNameByLabels(labels map[string]string) (string, bool) {
+	if labels[appPartOfLabelKey] != appLabel || labels[appNameLabelKey] == "" {
+		return "", false
+	}
+	return labels[appNameLabelKey], true
+}
+
 func getDnsPolicy(dnspolicy corev1.DNSPolicy) corev1.DNSPolicy {
 	if dnspolicy == "" {
 		return corev1.DNSClusterFirst
@@ -140,3 +155,163 @@ func pullPolicy(specPolicy corev1.PullPolicy) corev1.PullPolicy {
 	}
 	return specPolicy
 }
+
+// getVolumeClaimTemplates returns a VolumeClaimTemplate for each of the storages with a PersistentVolumeClaim,
+// e.g. both the data and the log
+func getVolumeClaimTemplates(ownerRefs []metav1.OwnerReference, storages ...roav1.RedisStorage) []corev1.PersistentVolumeClaim {
+	var templates []corev1.PersistentVolumeClaim
+	for _, storage := range storages {
+		if storage.PersistentVolumeClaim == nil {
+			continue
+		}
+		template := *storage.PersistentVolumeClaim.DeepCopy()
+		if !storage.KeepAfterDeletion {
+			// Set an owner reference so the persistent volumes are deleted when the RF is
+			template.OwnerReferences = ownerRefs
+		}
+		templates = append(templates, template)
+	}
+	return templates
+}
+
+// isPvcKeptAfterDeletion returns true when the PersistentVolumeClaim of a StatefulSet named rootName-* is created
+// from the template of a storage with KeepAfterDeletion
+func isPvcKeptAfterDeletion(pvcName, rootName string, storages ...roav1.RedisStorage) bool {
+	for _, storage := range storages {
+		if storage.PersistentVolumeClaim == nil || !storage.KeepAfterDeletion {
+			continue
+		}
+		if strings.HasPrefix(pvcName, storage.PersistentVolumeClaim.Name+"-"+rootName+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPodTemplateSettings merges the overrides of the spec into the pod template, before its hash is computed so
+// that changing them rolls the StatefulSet. The container named mainContainer gets the env, the probes and the
+// lifecycle, the init containers already in the template get the env and the resources
+func applyPodTemplateSettings(template *corev1.PodTemplateSpec, settings roav1.PodTemplateSettings, mainContainer string) {
+	template.Labels = MergeLabels(settings.Labels, template.Labels)
+
+	spec := &template.Spec
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].Env = mergeEnv(spec.InitContainers[i].Env, settings.Env)
+		if settings.InitContainerResources != nil {
+			spec.InitContainers[i].Resources = *settings.InitContainerResources
+		}
+	}
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if c.Name != mainContainer {
+			continue
+		}
+		c.Env = mergeEnv(c.Env, settings.Env)
+		mergeProbe(c.ReadinessProbe, settings.ReadinessProbe)
+		mergeProbe(c.LivenessProbe, settings.LivenessProbe)
+		if settings.Lifecycle != nil {
+			c.Lifecycle = settings.Lifecycle
+		}
+	}
+	spec.InitContainers = append(spec.InitContainers, settings.InitContainers...)
+	spec.Containers = append(spec.Containers, settings.Containers...)
+
+	if len(settings.TopologySpreadConstraints) > 0 {
+		spec.TopologySpreadConstraints = settings.TopologySpreadConstraints
+	}
+	if settings.ServiceAccountName != "" {
+		spec.ServiceAccountName = settings.ServiceAccountName
+	}
+	if settings.RuntimeClassName != nil {
+		spec.RuntimeClassName = settings.RuntimeClassName
+	}
+}
+
+// mergeEnv returns env with the variables of overrides, the ones of the same name are replaced in place
+func mergeEnv(env, overrides []corev1.EnvVar) []corev1.EnvVar {
+	merged := append([]corev1.EnvVar{}, env...)
+	for _, override := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == override.Name {
+				merged[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
+
+// mergeProbe sets the timings of settings which are not 0 on the probe
+func mergeProbe(probe *corev1.Probe, settings *roav1.ProbeSettings) {
+	if probe == nil || settings == nil {
+		return
+	}
+	if settings.InitialDelaySeconds != 0 {
+		probe.InitialDelaySeconds = settings.InitialDelaySeconds
+	}
+	if settings.TimeoutSeconds != 0 {
+		probe.TimeoutSeconds = settings.TimeoutSeconds
+	}
+	if settings.PeriodSeconds != 0 {
+		probe.PeriodSeconds = settings.PeriodSeconds
+	}
+	if settings.SuccessThreshold != 0 {
+		probe.SuccessThreshold = settings.SuccessThreshold
+	}
+	if settings.FailureThreshold != 0 {
+		probe.FailureThreshold = settings.FailureThreshold
+	}
+}
+
+// setSpecHash writes the hash of the pod template to the annotations of the StatefulSet
+func setSpecHash(ss *appsv1.StatefulSet) {
+	b, _ := json.Marshal(ss.Spec.Template)
+	if ss.Annotations == nil {
+		ss.Annotations = map[string]string{}
+	}
+	ss.Annotations[SpecHashAnnotation] = MD5(string(b))
+}
+
+// GetSpecHash returns the hash of the pod template the StatefulSet was built from, it is empty
+// for the StatefulSets created before the hash was introduced
+func GetSpecHash(ss *appsv1.StatefulSet) string {
+	return ss.Annotations[SpecHashAnnotation]
+}
+
+// SetSpecHash writes the hash of desired to the existing StatefulSet without touching its pod template
+func SetSpecHash(existing, desired *appsv1.StatefulSet) *appsv1.StatefulSet {
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	existing.Annotations[SpecHashAnnotation] = GetSpecHash(desired)
+	return existing
+}
+
+// setDesiredTemplate copies the pod template, the update strategy and the hash of desired to the existing
+// StatefulSet, the selector and the VolumeClaimTemplates can not be changed so they are kept
+func setDesiredTemplate(existing, desired *appsv1.StatefulSet) *appsv1.StatefulSet {
+	existing.Spec.Template = desired.Spec.Template
+	existing.Spec.UpdateStrategy = desired.Spec.UpdateStrategy
+	return SetSpecHash(existing, desired)
+}
+
+// IsStatefulSetRolledOut returns true when all the pods of the StatefulSet run its current template and are ready
+func IsStatefulSetRolledOut(ss *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if ss.Spec.Replicas != nil {
+		replicas = *ss.Spec.Replicas
+	}
+	if ss.Status.ObservedGeneration < ss.Generation || ss.Status.ReadyReplicas < replicas {
+		return false
+	}
+	if ss.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		// the pods are only updated when they are deleted
+		return true
+	}
+	return ss.Status.UpdatedReplicas >= replicas && ss.Status.UpdateRevision == ss.Status.CurrentRevision
+}
@@ -0,0 +1,207 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+	"strconv"
+	"text/template"
+)
+
+// CreateRedisClusterStatefulSetObjByIndex returns the StatefulSet of a shard, its pods are built like
+// the redis pods of the sentinel mode, with the cluster ConfigMap and the pod ip announced to the cluster
+func CreateRedisClusterStatefulSetObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, index int) *v1.StatefulSet {
+	ss := CreateRedisStatefulSetObjByIndex(rf, ownerRefs, index)
+
+	name := GetRedisClusterShardNameByIndex(rf, index)
+	labels := GetRedisLabelsWithName(rf, name)
+
+	ss.Name = name
+	ss.Labels = labels
+	ss.Spec.Replicas = Int32P(GetRedisClusterShardReplicas(rf))
+	ss.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+	ss.Spec.Template.Labels = MergeLabels(rf.Spec.Redis.PodTemplate.Labels, labels)
+
+	for i, volume := range ss.Spec.Template.Spec.Volumes {
+		if volume.Name == redisConfig {
+			ss.Spec.Template.Spec.Volumes[i].ConfigMap.Name = GetRedisClusterConfigMapName(rf)
+		}
+	}
+
+	for i, c := range ss.Spec.Template.Spec.Containers {
+		if c.Name == redisName {
+			ss.Spec.Template.Spec.Containers[i].Command = getRedisClusterCommand(rf)
+			ss.Spec.Template.Spec.Containers[i].Env = append(c.Env, corev1.EnvVar{
+				Name: "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "status.podIP",
+					},
+				},
+			})
+		}
+	}
+
+	setSpecHash(ss)
+
+	return ss
+}
+
+func getRedisClusterCommand(rf *roav1.Redis) []string {
+	if len(rf.Spec.Redis.Command) > 0 {
+		return rf.Spec.Redis.Command
+	}
+	// the pod ip changes when the pod is recreated, announce the current one so that
+	// the other nodes can update the address they have in nodes.conf
+	return []string{
+		"sh",
+		"-c",
+		"exec redis-server " + GetRedisConfigWritablePath() + " --cluster-announce-ip \"${POD_IP}\"",
+	}
+}
+
+func GetRedisClusterShardNameByIndex(rf *roav1.Redis, index int) string {
+	return GetRedisRootName(rf) + "-" + redisClusterShardName + "-" + strconv.Itoa(index)
+}
+
+func GetRedisClusterShardReplicas(rf *roav1.Redis) int32 {
+	return 1 + rf.Spec.Cluster.ReplicasPerShard
+}
+
+func GetRedisClusterShardPodNames(rf *roav1.Redis, index int) []string {
+	names := make([]string, 0)
+	for i := int32(0); i < GetRedisClusterShardReplicas(rf); i++ {
+		names = append(names, GetRedisClusterShardNameByIndex(rf, index)+"-"+strconv.Itoa(int(i)))
+	}
+	return names
+}
+
+// GetRedisClusterShardNameFromLabel returns the shard (StatefulSet) name of a cluster pod
+func GetRedisClusterShardNameFromLabel(pod corev1.Pod) string {
+	if pod.Labels == nil {
+		return ""
+	}
+	return pod.Labels[statefulSetNameLabelKey]
+}
+
+func CreateRedisClusterStatefulSetObjByExistingObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, oldStatefulSet *v1.StatefulSet, index int) *v1.StatefulSet {
+	oldStatefulSet.Spec.Replicas = Int32P(GetRedisClusterShardReplicas(rf))
+	return setDesiredTemplate(oldStatefulSet, CreateRedisClusterStatefulSetObjByIndex(rf, ownerRefs, index))
+}
+
+func RedisClusterStatefulSetEqual(a *v1.StatefulSet, b *v1.StatefulSet) bool {
+	return reflect.DeepEqual(a.Spec.Replicas, b.Spec.Replicas) && RedisStatefulSetEqual(a, b)
+}
+
+// --- ConfigMap ---
+
+func getRedisClusterConfigContent(rf *roav1.Redis, password string) string {
+	tmpl, err := template.New("redis").Parse(redisConfigTemplate)
+	if err != nil {
+		panic(err)
+	}
+
+	var tplOutput bytes.Buffer
+	if err := tmpl.Execute(&tplOutput, rf); err != nil {
+		panic(err)
+	}
+
+	redisConfigFileContent := tplOutput.String()
+	redisConfigFileContent = fmt.Sprintf("%s\n%s%s", getPortConfig(rf, strconv.Itoa(redisContainerPort)), redisConfigFileContent, redisClusterConfig)
+	if rf.IsTLSEnabled() {
+		redisConfigFileContent = redisConfigFileContent + "tls-cluster yes\n"
+	}
+
+	if password != "" {
+		redisConfigFileContent = fmt.Sprintf("%s\nmasterauth \"%s\"\nrequirepass \"%s\"", redisConfigFileContent, password, password)
+	}
+	return redisConfigFileContent
+}
+
+func CreateRedisClusterConfigMap(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, password string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GetRedisClusterConfigMapName(rf),
+			Namespace:       rf.Namespace,
+			Labels:          GetRedisMasterConfigMapLabels(rf),
+			OwnerReferences: ownerRefs,
+		},
+		Data: map[string]string{
+			redisConfigFileName: getRedisClusterConfigContent(rf, password),
+		},
+	}
+}
+
+func CreateRedisClusterConfigMapObjByExistingObj(rf *roav1.Redis, password string, oldConfigMap *corev1.ConfigMap) *corev1.ConfigMap {
+	oldConfigMap.Data = map[string]string{
+		redisConfigFileName: getRedisClusterConfigContent(rf, password),
+	}
+	return oldConfigMap
+}
+
+func GetRedisClusterConfigMapName(rf *roav1.Redis) string {
+	return generateName(redisClusterConfigMapName, rf.Name)
+}
+
+// --- Service ---
+
+func GetRedisClusterHeadlessServiceNameByIndex(rf *roav1.Redis, index int) string {
+	return headlessServiceBaseName + "-" + GetRedisClusterShardNameByIndex(rf, index)
+}
+
+func CreateRedisClusterHeadlessServiceByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, index int) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            GetRedisClusterHeadlessServiceNameByIndex(rf, index),
+			Namespace:       rf.Namespace,
+			Labels:          GetRedisServiceLabels(rf),
+			OwnerReferences: ownerRefs,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				statefulSetNameLabelKey: GetRedisClusterShardNameByIndex(rf, index),
+			},
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       redisName,
+					Port:       redisContainerPort,
+					TargetPort: intstr.FromInt(redisContainerPort),
+					Protocol:   "TCP",
+				},
+			},
+		},
+	}
+}
+
+// --- Slots ---
+
+// GetClusterSlotRangeByIndex returns the first and the last slot a shard is given when the
+// cluster is created, the slots are split evenly and the first shards take the remainder
+func GetClusterSlotRangeByIndex(rf *roav1.Redis, index int) (int, int) {
+	shards := int(rf.Spec.Cluster.Shards)
+	size := ClusterSlots / shards
+	remainder := ClusterSlots % shards
+
+	start := index*size + minInt(index, remainder)
+	end := start + size - 1
+	if index < remainder {
+		end++
+	}
+	return start, end
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
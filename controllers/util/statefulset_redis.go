@@ -6,7 +6,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"reflect"
 	"strconv"
 )
 
@@ -24,7 +23,7 @@ func CreateRedisStatefulSetObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerR
 	affinity := getNodeAndPodAffinity(rf.Spec.Redis.Affinity, rf.Spec.Redis.EnabledPodAntiAffinity, selector, nodeAffinity)
 	port := GetRedisPortFromSpecByIndex(rf, index)
 	portInt, _ := strconv.Atoi(port)
-	livenessProbeCommand := "redis-cli -p " + port + " -h $(hostname) ping"
+	livenessProbeCommand := "redis-cli " + getRedisCliTLSArgs(rf) + "-p " + port + " -h $(hostname) ping"
 
 	ss := &v1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -149,56 +148,28 @@ func CreateRedisStatefulSetObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerR
 		},
 	}
 
-	if rf.Spec.Redis.Storage.PersistentVolumeClaim != nil {
-		if !rf.Spec.Redis.Storage.KeepAfterDeletion {
-			// Set an owner reference so the persistent volumes are deleted when the RF is
-			rf.Spec.Redis.Storage.PersistentVolumeClaim.OwnerReferences = ownerRefs
-		}
-		ss.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
-			*rf.Spec.Redis.Storage.PersistentVolumeClaim,
-		}
-	}
+	applyPodTemplateSettings(&ss.Spec.Template, rf.Spec.Redis.PodTemplate, redisName)
 
-	if rf.Spec.Redis.StorageLog.PersistentVolumeClaim != nil {
-		if !rf.Spec.Redis.StorageLog.KeepAfterDeletion {
-			// Set an owner reference so the persistent volumes are deleted when the RF is
-			rf.Spec.Redis.StorageLog.PersistentVolumeClaim.OwnerReferences = ownerRefs
-		}
-		ss.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
-			*rf.Spec.Redis.StorageLog.PersistentVolumeClaim,
-		}
+	// the restore container only runs once, it is not part of the hash so that removing it
+	// after the restore does not roll the master
+	setSpecHash(ss)
+
+	if NeedRestoreByIndex(rf, index) {
+		ss.Spec.Template.Spec.InitContainers = append([]corev1.Container{getRedisRestoreContainer(rf)}, ss.Spec.Template.Spec.InitContainers...)
 	}
 
+	ss.Spec.VolumeClaimTemplates = getVolumeClaimTemplates(ownerRefs, rf.Spec.Redis.Storage, rf.Spec.Redis.StorageLog)
+
 	return ss
 }
 
 func CreateRedisStatefulSetObjByExistingObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, oldStatefulSet *v1.StatefulSet, index int) *v1.StatefulSet {
-	oldStatefulSet.Spec.Template.Spec.Containers = SetResourcesByContainerName(redisName, oldStatefulSet.Spec.Template.Spec.Containers, rf.Spec.Redis.Resources)
-	return oldStatefulSet
+	return setDesiredTemplate(oldStatefulSet, CreateRedisStatefulSetObjByIndex(rf, ownerRefs, index))
 }
 
+// RedisStatefulSetEqual compares the hash of the pod templates
 func RedisStatefulSetEqual(a *v1.StatefulSet, b *v1.StatefulSet) bool {
-	return reflect.DeepEqual(getResourcesByContainerName(redisName, a.Spec.Template.Spec.Containers), getResourcesByContainerName(redisName, b.Spec.Template.Spec.Containers))
-}
-
-func getResourcesByContainerName(name string, container []corev1.Container) *corev1.ResourceRequirements {
-	for _, c := range container {
-		if c.Name == name {
-			return &c.Resources
-		}
-	}
-	return nil
-}
-
-func SetResourcesByContainerName(name string, oldContainers []corev1.Container, resources corev1.ResourceRequirements) []corev1.Container {
-	containers := make([]corev1.Container, 0)
-	for _, c := range oldContainers {
-		if c.Name == name {
-			c.Resources = resources
-		}
-		containers = append(containers, c)
-	}
-	return containers
+	return GetSpecHash(a) == GetSpecHash(b)
 }
 
 func getRedisUpdateStrategy(rf *roav1.Redis) v1.StatefulSetUpdateStrategy {
@@ -226,6 +197,12 @@ func getRedisVolumeMounts(rf *roav1.Redis) []corev1.VolumeMount {
 		},
 	}
 
+	if rf.IsTLSEnabled() {
+		volumeMounts = append(volumeMounts, getTLSVolumeMount())
+	}
+
+	volumeMounts = append(volumeMounts, rf.Spec.Redis.ExtraVolumeMounts...)
+
 	return volumeMounts
 }
 
@@ -272,6 +249,12 @@ func getRedisVolumesByIndex(rf *roav1.Redis, index int) []corev1.Volume {
 		volumes = append(volumes, *logVolume)
 	}
 
+	if rf.IsTLSEnabled() {
+		volumes = append(volumes, getTLSVolume(rf))
+	}
+
+	volumes = append(volumes, rf.Spec.Redis.ExtraVolumes...)
+
 	return volumes
 }
 
@@ -366,6 +349,26 @@ func GetRedisNameByIndex(rf *roav1.Redis, index int) string {
 	return GetRedisRootName(rf) + "-" + strconv.Itoa(index)
 }
 
+// GetRedisPvcNamesByIndex returns the PersistentVolumeClaims created by the StatefulSet of the index,
+// which are not kept after deletion
+func GetRedisPvcNamesByIndex(rf *roav1.Redis, index int) []string {
+	names := make([]string, 0)
+	podName := GetRedisNameByIndex(rf, index) + "-0"
+	if rf.Spec.Redis.Storage.PersistentVolumeClaim != nil && !rf.Spec.Redis.Storage.KeepAfterDeletion {
+		names = append(names, rf.Spec.Redis.Storage.PersistentVolumeClaim.Name+"-"+podName)
+	}
+	if rf.Spec.Redis.StorageLog.PersistentVolumeClaim != nil && !rf.Spec.Redis.StorageLog.KeepAfterDeletion {
+		names = append(names, rf.Spec.Redis.StorageLog.PersistentVolumeClaim.Name+"-"+podName)
+	}
+	return names
+}
+
+// IsRedisPvcKeptAfterDeletion returns true when the PersistentVolumeClaim of a redis StatefulSet is kept after the
+// deletion of the Redis
+func IsRedisPvcKeptAfterDeletion(rf *roav1.Redis, pvcName string) bool {
+	return isPvcKeptAfterDeletion(pvcName, GetRedisRootName(rf), rf.Spec.Redis.Storage, rf.Spec.Redis.StorageLog)
+}
+
 func GetRedisLabels(rf *roav1.Redis) map[string]string {
 	return GenerateSelectorLabels(redisRootName, rf)
 }
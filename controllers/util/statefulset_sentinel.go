@@ -6,8 +6,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"reflect"
 	"strconv"
+	"time"
 )
 
 func CreateSentinelStatefulSetObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, index int) *v1.StatefulSet {
@@ -24,7 +24,7 @@ func CreateSentinelStatefulSetObjByIndex(rf *roav1.Redis, ownerRefs []metav1.Own
 	affinity := getNodeAndPodAffinity(rf.Spec.Sentinel.Affinity, rf.Spec.Sentinel.EnabledPodAntiAffinity, selector, nodeAffinity)
 	port := GetSentinelPortFromSpecByIndex(rf, index)
 	portInt, _ := strconv.Atoi(port)
-	livenessProbeCommand := "redis-cli -p " + port + " -h $(hostname) ping"
+	livenessProbeCommand := "redis-cli " + getRedisCliTLSArgs(rf) + "-p " + port + " -h $(hostname) ping"
 
 	ss := &v1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -153,36 +153,22 @@ func CreateSentinelStatefulSetObjByIndex(rf *roav1.Redis, ownerRefs []metav1.Own
 		},
 	}
 
-	if rf.Spec.Sentinel.Storage.PersistentVolumeClaim != nil {
-		if !rf.Spec.Sentinel.Storage.KeepAfterDeletion {
-			// Set an owner reference so the persistent volumes are deleted when the RF is
-			rf.Spec.Sentinel.Storage.PersistentVolumeClaim.OwnerReferences = ownerRefs
-		}
-		ss.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
-			*rf.Spec.Sentinel.Storage.PersistentVolumeClaim,
-		}
-	}
+	ss.Spec.VolumeClaimTemplates = getVolumeClaimTemplates(ownerRefs, rf.Spec.Sentinel.Storage, rf.Spec.Sentinel.StorageLog)
 
-	if rf.Spec.Sentinel.StorageLog.PersistentVolumeClaim != nil {
-		if !rf.Spec.Sentinel.StorageLog.KeepAfterDeletion {
-			// Set an owner reference so the persistent volumes are deleted when the RF is
-			rf.Spec.Sentinel.StorageLog.PersistentVolumeClaim.OwnerReferences = ownerRefs
-		}
-		ss.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
-			*rf.Spec.Sentinel.StorageLog.PersistentVolumeClaim,
-		}
-	}
+	applyPodTemplateSettings(&ss.Spec.Template, rf.Spec.Sentinel.PodTemplate, sentinelName)
+
+	setSpecHash(ss)
 
 	return ss
 }
 
 func CreateSentinelStatefulSetObjByExistingObjByIndex(rf *roav1.Redis, ownerRefs []metav1.OwnerReference, oldStatefulSet *v1.StatefulSet, index int) *v1.StatefulSet {
-	oldStatefulSet.Spec.Template.Spec.Containers = SetResourcesByContainerName(sentinelName, oldStatefulSet.Spec.Template.Spec.Containers, rf.Spec.Sentinel.Resources)
-	return oldStatefulSet
+	return setDesiredTemplate(oldStatefulSet, CreateSentinelStatefulSetObjByIndex(rf, ownerRefs, index))
 }
 
+// SentinelStatefulSetEqual compares the hash of the pod templates
 func SentinelStatefulSetEqual(a *v1.StatefulSet, b *v1.StatefulSet) bool {
-	return reflect.DeepEqual(getResourcesByContainerName(sentinelName, a.Spec.Template.Spec.Containers), getResourcesByContainerName(sentinelName, b.Spec.Template.Spec.Containers))
+	return GetSpecHash(a) == GetSpecHash(b)
 }
 
 func getSentinelUpdateStrategy(rf *roav1.Redis) v1.StatefulSetUpdateStrategy {
@@ -195,7 +181,7 @@ func getSentinelUpdateStrategy(rf *roav1.Redis) v1.StatefulSetUpdateStrategy {
 }
 
 func getSentinelVolumeMounts(rf *roav1.Redis) []corev1.VolumeMount {
-	return []corev1.VolumeMount{
+	volumeMounts := []corev1.VolumeMount{
 		{
 			Name:      getSentinelDataVolumeName(rf),
 			MountPath: "/data",
@@ -205,6 +191,12 @@ func getSentinelVolumeMounts(rf *roav1.Redis) []corev1.VolumeMount {
 			MountPath: "/redislog",
 		},
 	}
+
+	if rf.IsTLSEnabled() {
+		volumeMounts = append(volumeMounts, getTLSVolumeMount())
+	}
+
+	return volumeMounts
 }
 
 func getSentinelVolumes(rf *roav1.Redis, index int) []corev1.Volume {
@@ -235,6 +227,10 @@ func getSentinelVolumes(rf *roav1.Redis, index int) []corev1.Volume {
 		volumes = append(volumes, *logVolume)
 	}
 
+	if rf.IsTLSEnabled() {
+		volumes = append(volumes, getTLSVolume(rf))
+	}
+
 	return volumes
 }
 
@@ -330,6 +326,26 @@ func GetSentinelNameByIndex(rf *roav1.Redis, index int) string {
 	return GetSentinelRootName(rf) + "-" + strconv.Itoa(index)
 }
 
+// GetSentinelPvcNamesByIndex returns the PersistentVolumeClaims created by the StatefulSet of the index,
+// which are not kept after deletion
+func GetSentinelPvcNamesByIndex(rf *roav1.Redis, index int) []string {
+	names := make([]string, 0)
+	podName := GetSentinelNameByIndex(rf, index) + "-0"
+	if rf.Spec.Sentinel.Storage.PersistentVolumeClaim != nil && !rf.Spec.Sentinel.Storage.KeepAfterDeletion {
+		names = append(names, rf.Spec.Sentinel.Storage.PersistentVolumeClaim.Name+"-"+podName)
+	}
+	if rf.Spec.Sentinel.StorageLog.PersistentVolumeClaim != nil && !rf.Spec.Sentinel.StorageLog.KeepAfterDeletion {
+		names = append(names, rf.Spec.Sentinel.StorageLog.PersistentVolumeClaim.Name+"-"+podName)
+	}
+	return names
+}
+
+// IsSentinelPvcKeptAfterDeletion returns true when the PersistentVolumeClaim of a sentinel StatefulSet is kept after
+// the deletion of the Redis
+func IsSentinelPvcKeptAfterDeletion(rf *roav1.Redis, pvcName string) bool {
+	return isPvcKeptAfterDeletion(pvcName, GetSentinelRootName(rf), rf.Spec.Sentinel.Storage, rf.Spec.Sentinel.StorageLog)
+}
+
 func GetSentinelLabels(rf *roav1.Redis) map[string]string {
 	return GenerateSelectorLabels(sentinelRootName, rf)
 }
@@ -345,6 +361,11 @@ func GetSentinelLabelsWithName(rf *roav1.Redis, name string) map[string]string {
 
 func getSentinelAnnotations(rf *roav1.Redis) map[string]string {
 	annotations := rf.Spec.Sentinel.PodAnnotations
+	if rf.IsTLSEnabled() && rf.Status.TLS.RotationTime != nil {
+		annotations = MergeLabels(annotations, map[string]string{
+			TLSRotatedAtAnnotation: rf.Status.TLS.RotationTime.UTC().Format(time.RFC3339),
+		})
+	}
 	return annotations
 }
 
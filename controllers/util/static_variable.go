@@ -5,6 +5,9 @@ import (
 )
 
 const (
+	// defaultRedisSlavePriority is the slave-priority of redisConfigTemplate
+	defaultRedisSlavePriority = "50"
+
 	redisConfigTemplate = `protected-mode no
 pidfile /redis/redis.pid
 dir /data/
@@ -45,20 +48,30 @@ timeout 600`
 	}
 
 	function getPort(){
-		local port=$(cat /data/conf/redis.conf | grep port | awk '{print $2}')
+		local port=$(cat /data/conf/redis.conf | grep '^tls-port [1-9]' | awk '{print $2}')
+		if [ -z "$port" ]; then
+			port=$(cat /data/conf/redis.conf | grep '^port ' | awk '{print $2}')
+		fi
 		echo "$port"
 	}
 
+	function getTLS(){
+		if cat /data/conf/redis.conf | grep -q '^tls-port [1-9]'; then
+			echo "--tls --cert /tls/tls.crt --key /tls/tls.key --cacert /tls/ca.crt"
+		fi
+	}
+
 	REDIS_PASSWORD="$(getPass)"
 	REDIS_PORT="$(getPort)"
+	REDIS_TLS="$(getTLS)"
 
    check_master(){
            exit 0
    }
 
    check_slave(){
-           in_sync=$(redis-cli -p "${REDIS_PORT}" --no-auth-warning -a "${REDIS_PASSWORD}" info replication | grep $IN_SYNC | tr -d "\r" | tr -d "\n")
-           no_master=$(redis-cli -p "${REDIS_PORT}" --no-auth-warning -a "${REDIS_PASSWORD}" info replication | grep $NO_MASTER | tr -d "\r" | tr -d "\n")
+           in_sync=$(redis-cli ${REDIS_TLS} -p "${REDIS_PORT}" --no-auth-warning -a "${REDIS_PASSWORD}" info replication | grep $IN_SYNC | tr -d "\r" | tr -d "\n")
+           no_master=$(redis-cli ${REDIS_TLS} -p "${REDIS_PORT}" --no-auth-warning -a "${REDIS_PASSWORD}" info replication | grep $NO_MASTER | tr -d "\r" | tr -d "\n")
 
            if [ -z "$in_sync" ] && [ -z "$no_master" ]; then
                    exit 0
@@ -67,7 +80,7 @@ timeout 600`
            exit 1
    }
 
-   role=$(redis-cli -p "${REDIS_PORT}" --no-auth-warning -a "${REDIS_PASSWORD}" info replication | grep $ROLE | tr -d "\r" | tr -d "\n")
+   role=$(redis-cli ${REDIS_TLS} -p "${REDIS_PORT}" --no-auth-warning -a "${REDIS_PASSWORD}" info replication | grep $ROLE | tr -d "\r" | tr -d "\n")
 
    case $role in
            $ROLE_MASTER)
@@ -81,6 +94,13 @@ timeout 600`
                    exit 1
    esac`
 
+	redisClusterConfig = `
+cluster-enabled yes
+cluster-config-file /data/conf/nodes.conf
+cluster-node-timeout 5000
+cluster-require-full-coverage yes
+`
+
 	redisReadinessVolumeName  = "redis-readiness-config"
 	redisStorageVolumeName    = "redis-data"
 	redisLogStorageVolumeName = "redis-log"
@@ -114,6 +134,9 @@ const (
 	redisReadinessName = "redis-readiness"
 	redisGroupName     = "mymaster"
 	redisContainerPort = 6379
+	// cluster mode, {redis}-{INSTANCE_NAME}-{shard}-{index}
+	redisClusterShardName     = "shard"
+	redisClusterConfigMapName = "redis-cluster"
 	// 用于区分不同实例，用于拼接name、label
 	exporterRootName = "exporter"
 	// container、port name
@@ -138,6 +161,15 @@ const (
 	statefulSetPodLabelKey = "statefulset.kubernetes.io/pod-name"
 
 	RedisFinalizer = "redis.component.zhizuqiu/finalizer"
+
+	// RoleLabelKey is the label the checker sets on the redis pods to their current role, the master and
+	// replicas Services select on it
+	RoleLabelKey = "redis.component.zhizuqiu/role"
+	RoleMaster   = "master"
+	RoleReplica  = "replica"
+
+	// ClusterSlots is the number of hash slots of a redis cluster
+	ClusterSlots = 16384
 )
 
 const (
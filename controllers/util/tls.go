@@ -0,0 +1,101 @@
+package util
+
+import (
+	"fmt"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"strings"
+)
+
+const (
+	tlsVolumeName = "redis-tls"
+	tlsMountPath  = "/tls"
+
+	// the keys of the Secret of Spec.TLS
+	TLSCertKey   = corev1.TLSCertKey
+	TLSKeyKey    = corev1.TLSPrivateKeyKey
+	TLSCACertKey = "ca.crt"
+
+	// TLSRotatedAtAnnotation is Status.TLS.RotationTime on the pod template of the sentinels, the sentinels
+	// can not reload their certificates so they are rolled when the Secret changes
+	TLSRotatedAtAnnotation = "redis.component.zhizuqiu/tls-rotated-at"
+)
+
+func GetTLSCertFile() string {
+	return tlsMountPath + "/" + TLSCertKey
+}
+
+func GetTLSKeyFile() string {
+	return tlsMountPath + "/" + TLSKeyKey
+}
+
+func GetTLSCACertFile() string {
+	return tlsMountPath + "/" + TLSCACertKey
+}
+
+// getPortConfig returns the port of redis.conf and sentinel.conf, with TLS the port is the tls-port and the
+// plain port is disabled, the sentinels and the slaves connect to the master with TLS too
+func getPortConfig(rf *roav1.Redis, port string) string {
+	if !rf.IsTLSEnabled() {
+		return fmt.Sprintf("port %s", port)
+	}
+	return strings.Join([]string{
+		"port 0",
+		"tls-port " + port,
+		"tls-cert-file " + GetTLSCertFile(),
+		"tls-key-file " + GetTLSKeyFile(),
+		"tls-ca-cert-file " + GetTLSCACertFile(),
+		"tls-replication yes",
+	}, "\n")
+}
+
+// getRedisCliTLSArgs returns the arguments of redis-cli in the probes
+func getRedisCliTLSArgs(rf *roav1.Redis) string {
+	if !rf.IsTLSEnabled() {
+		return ""
+	}
+	return "--tls --cert " + GetTLSCertFile() + " --key " + GetTLSKeyFile() + " --cacert " + GetTLSCACertFile() + " "
+}
+
+// GetRedisCliExport exports REDIS_PORT and REDIS_TLS, the redis-cli arguments, from a redis.conf or
+// sentinel.conf, so that redis-cli run in the pods works with and without TLS
+func GetRedisCliExport(configPath string) string {
+	return "export REDIS_PORT=$(cat " + configPath + " | grep '^tls-port [1-9]' | awk '{print $2}') && " +
+		"if [ -n \"${REDIS_PORT}\" ]; then " +
+		"export REDIS_TLS=\"--tls --cert " + GetTLSCertFile() + " --key " + GetTLSKeyFile() + " --cacert " + GetTLSCACertFile() + "\"; " +
+		"else export REDIS_TLS=\"\" REDIS_PORT=$(cat " + configPath + " | grep '^port ' | awk '{print $2}'); fi && "
+}
+
+func getTLSVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      tlsVolumeName,
+		MountPath: tlsMountPath,
+		ReadOnly:  true,
+	}
+}
+
+// getTLSVolume mounts the whole Secret, not with subPath, so that the kubelet updates the files when the
+// certificates are rotated
+func getTLSVolume(rf *roav1.Redis) corev1.Volume {
+	defaultMode := int32(0644)
+	return corev1.Volume{
+		Name: tlsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  rf.Spec.TLS.SecretName,
+				DefaultMode: &defaultMode,
+			},
+		},
+	}
+}
+
+// GetTLSCertMd5 returns the md5 of the certificates of the Secret of Spec.TLS
+func GetTLSCertMd5(secret *corev1.Secret) string {
+	var sb strings.Builder
+	for _, key := range []string{TLSCertKey, TLSKeyKey, TLSCACertKey} {
+		sb.WriteString(key + ":")
+		sb.Write(secret.Data[key])
+		sb.WriteString("\n")
+	}
+	return MD5(sb.String())
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -122,3 +124,86 @@ func HasNoHostNetwork(rf *roav1.Redis) bool {
 	}
 	return false
 }
+
+// GetIndexFromName returns the index of a per index resource, like 2 for `redis-redis-sample-2`
+// and the root name `redis-redis-sample`, false if the name is not one of them
+func GetIndexFromName(rootName, name string) (int, bool) {
+	if !strings.HasPrefix(name, rootName+"-") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(name, rootName+"-"))
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
+
+// GetScaleDownIndexes returns the indexes of the names that are not lower than replicas, the highest first
+func GetScaleDownIndexes(rootName string, names []string, replicas int32) []int {
+	found := make(map[int]bool)
+	indexes := make([]int, 0)
+	for _, name := range names {
+		index, ok := GetIndexFromName(rootName, name)
+		if !ok || index < int(replicas) || found[index] {
+			continue
+		}
+		found[index] = true
+		indexes = append(indexes, index)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+	return indexes
+}
+
+// GetRedisSlavePriorityConfig returns the slave-priority of the redis config, the one of Spec.Redis.CustomConfig
+// if it is set, otherwise the one of redisConfigTemplate
+func GetRedisSlavePriorityConfig(rf *roav1.Redis) string {
+	priority := defaultRedisSlavePriority
+	for _, config := range rf.Spec.Redis.CustomConfig {
+		fields := strings.Fields(config)
+		if len(fields) == 2 && (fields[0] == "slave-priority" || fields[0] == "replica-priority") {
+			priority = fields[1]
+		}
+	}
+	return "slave-priority " + priority
+}
+
+// GetRedisSlavePriorityConfigByIndex returns the slave-priority of the redis of an index, the one of
+// Spec.Redis.ReplicaPriorities if it is set, otherwise the one of GetRedisSlavePriorityConfig
+func GetRedisSlavePriorityConfigByIndex(rf *roav1.Redis, index int) string {
+	if priority, ok := getReplicaPriority(rf, index); ok {
+		return "slave-priority " + strconv.Itoa(int(priority))
+	}
+	return GetRedisSlavePriorityConfig(rf)
+}
+
+// GetRedisSlavePriorityByIndex returns the slave-priority of GetRedisSlavePriorityConfigByIndex as a number
+func GetRedisSlavePriorityByIndex(rf *roav1.Redis, index int) int {
+	priority, err := strconv.Atoi(strings.TrimPrefix(GetRedisSlavePriorityConfigByIndex(rf, index), "slave-priority "))
+	if err != nil {
+		priority, _ = strconv.Atoi(defaultRedisSlavePriority)
+	}
+	return priority
+}
+
+func getReplicaPriority(rf *roav1.Redis, index int) (int32, bool) {
+	for _, priority := range rf.Spec.Redis.ReplicaPriorities {
+		if int(priority.Index) == index {
+			return priority.Priority, true
+		}
+	}
+	return 0, false
+}
+
+// GetRedisIndexFromPodName returns the index of a redis pod, like 2 for `redis-redis-sample-2-0`
+func GetRedisIndexFromPodName(rf *roav1.Redis, podName string) (int, bool) {
+	return GetIndexFromName(GetRedisRootName(rf), strings.TrimSuffix(podName, "-0"))
+}
+
+// setRedisSlavePriority replaces the slave-priority of redisConfigTemplate with the one of
+// Spec.Redis.ReplicaPriorities for the index, the config of the other indexes is unchanged
+func setRedisSlavePriority(rf *roav1.Redis, content string, index int) string {
+	if _, ok := getReplicaPriority(rf, index); !ok {
+		return content
+	}
+	return strings.Replace(content, "\nslave-priority "+defaultRedisSlavePriority+"\n", "\n"+GetRedisSlavePriorityConfigByIndex(rf, index)+"\n", 1)
+}
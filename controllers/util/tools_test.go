@@ -2,6 +2,8 @@ package util
 
 import (
 	"encoding/base64"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	"strings"
 	"testing"
 )
 
@@ -59,3 +61,49 @@ func TestBase64Decrypt(t *testing.T) {
 		}
 	}
 }
+
+func TestGetRedisSlavePriorityConfig(t *testing.T) {
+	var priorityTests = []struct {
+		customConfig []string
+		expected     string
+	}{
+		{nil, "slave-priority 50"},
+		{[]string{"maxmemory 100mb"}, "slave-priority 50"},
+		{[]string{"slave-priority 10"}, "slave-priority 10"},
+		{[]string{"replica-priority 20", "maxmemory 100mb"}, "slave-priority 20"},
+	}
+
+	for _, tt := range priorityTests {
+		rf := redisIn.DeepCopy()
+		rf.Spec.Redis.CustomConfig = tt.customConfig
+		actual := GetRedisSlavePriorityConfig(rf)
+		if actual != tt.expected {
+			t.Errorf("GetRedisSlavePriorityConfig(%v) = %s; expected %s", tt.customConfig, actual, tt.expected)
+		}
+	}
+}
+
+func TestGetRedisSlavePriorityConfigByIndex(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.Redis.CustomConfig = []string{"slave-priority 10"}
+	rf.Spec.Redis.ReplicaPriorities = []roav1.ReplicaPriority{{Index: 2, Priority: 0}}
+
+	if actual := GetRedisSlavePriorityConfigByIndex(rf, 1); actual != "slave-priority 10" {
+		t.Errorf("GetRedisSlavePriorityConfigByIndex(1) = %s; expected slave-priority 10", actual)
+	}
+	if actual := GetRedisSlavePriorityConfigByIndex(rf, 2); actual != "slave-priority 0" {
+		t.Errorf("GetRedisSlavePriorityConfigByIndex(2) = %s; expected slave-priority 0", actual)
+	}
+	if index, ok := GetRedisIndexFromPodName(rf, "redis-redis-sample-2-0"); !ok || GetRedisSlavePriorityByIndex(rf, index) != 0 {
+		t.Errorf("the priority of redis-redis-sample-2-0 should be 0")
+	}
+
+	configMap := CreateRedisSlaveConfigMapByIndex(rf, nil, "", 2)
+	if content := configMap.Data[redisConfigFileName]; !strings.Contains(content, "\nslave-priority 0\n") || strings.Contains(content, "slave-priority 50") {
+		t.Errorf("the config of index 2 should have slave-priority 0:\n%s", content)
+	}
+	configMap = CreateRedisSlaveConfigMapByIndex(rf, nil, "", 1)
+	if content := configMap.Data[redisConfigFileName]; !strings.Contains(content, "\nslave-priority 50\n") {
+		t.Errorf("the config of index 1 should be unchanged:\n%s", content)
+	}
+}
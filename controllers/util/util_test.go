@@ -1,9 +1,19 @@
 package util
 
 import (
+	"encoding/json"
+	"errors"
 	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
 	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -104,6 +114,63 @@ func TestGetSentinelServiceName(t *testing.T) {
 	}
 }
 
+func TestCreateRedisRoleServices(t *testing.T) {
+	master := CreateRedisMasterService(redisIn, nil)
+	if master.Name != "redis-sample-master" || master.Spec.Selector[RoleLabelKey] != RoleMaster {
+		t.Fatalf("master Service = %s selecting %v; expected redis-sample-master selecting the master", master.Name, master.Spec.Selector)
+	}
+	replicas := CreateRedisReplicasService(redisIn, nil)
+	if replicas.Name != "redis-sample-replicas" || replicas.Spec.Selector[RoleLabelKey] != RoleReplica {
+		t.Fatalf("replicas Service = %s selecting %v; expected redis-sample-replicas selecting the replicas", replicas.Name, replicas.Spec.Selector)
+	}
+	if replicas.Spec.Selector[appComponentLabelKey] != redisRootName {
+		t.Fatalf("replicas Service selects %v; expected the redis pods", replicas.Spec.Selector)
+	}
+
+	existing := master.DeepCopy()
+	if !RedisRoleServiceEqual(master, existing) {
+		t.Fatalf("expected equal")
+	}
+	existing.Spec.Selector[RoleLabelKey] = RoleReplica
+	if RedisRoleServiceEqual(master, existing) {
+		t.Fatalf("selector changed: expected not equal")
+	}
+}
+
+func TestCreatePodDisruptionBudgets(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.Redis.Replicas = 3
+	rf.Spec.Sentinel.Replicas = 3
+
+	redisPdb := CreateRedisPodDisruptionBudget(rf, nil)
+	if redisPdb.Name != "redis-redis-sample" || redisPdb.Spec.MinAvailable.IntValue() != 2 {
+		t.Fatalf("redis PodDisruptionBudget = %s minAvailable %v; expected redis-redis-sample minAvailable 2", redisPdb.Name, redisPdb.Spec.MinAvailable)
+	}
+	if redisPdb.Spec.Selector.MatchLabels[appComponentLabelKey] != redisRootName {
+		t.Fatalf("redis PodDisruptionBudget selects %v; expected the redis pods", redisPdb.Spec.Selector.MatchLabels)
+	}
+	sentinelPdb := CreateSentinelPodDisruptionBudget(rf, nil)
+	if sentinelPdb.Spec.MinAvailable.IntValue() != int(GetQuorum(rf)) {
+		t.Fatalf("sentinel minAvailable = %v; expected the quorum %d", sentinelPdb.Spec.MinAvailable, GetQuorum(rf))
+	}
+
+	// a single sentinel can be evicted
+	rf.Spec.Sentinel.Replicas = 1
+	if minAvailable := CreateSentinelPodDisruptionBudget(rf, nil).Spec.MinAvailable.IntValue(); minAvailable != 0 {
+		t.Fatalf("sentinel minAvailable = %d; expected 0", minAvailable)
+	}
+
+	maxUnavailable := intstr.FromString("50%")
+	rf.Spec.Redis.PodDisruptionBudget.MaxUnavailable = &maxUnavailable
+	redisPdb = CreateRedisPodDisruptionBudget(rf, nil)
+	if redisPdb.Spec.MinAvailable != nil || redisPdb.Spec.MaxUnavailable.String() != "50%" {
+		t.Fatalf("redis PodDisruptionBudget = %v; expected maxUnavailable 50%%", redisPdb.Spec)
+	}
+	if PodDisruptionBudgetEqual(redisPdb, CreateRedisPodDisruptionBudget(redisIn, nil)) {
+		t.Fatalf("expected not equal")
+	}
+}
+
 func TestGetRedisConfigWritablePath(t *testing.T) {
 	expected := "/data/conf/redis.conf"
 	actual := GetRedisConfigWritablePath()
@@ -135,3 +202,427 @@ func TestGetSentinelConfigPath(t *testing.T) {
 		t.Fatalf("actual = %s; expected = %s", actual, expected)
 	}
 }
+
+func TestGetClusterSlotRangeByIndex(t *testing.T) {
+	for _, shards := range []int32{3, 5, 7} {
+		rf := redisIn.DeepCopy()
+		rf.Spec.Cluster.Shards = shards
+
+		next := 0
+		for i := 0; i < int(shards); i++ {
+			start, end := GetClusterSlotRangeByIndex(rf, i)
+			if start != next || end < start {
+				t.Fatalf("shards = %d, index = %d: actual = %d-%d; expected to start at %d", shards, i, start, end, next)
+			}
+			next = end + 1
+		}
+		if next != ClusterSlots {
+			t.Fatalf("shards = %d: slots end at %d; expected %d", shards, next-1, ClusterSlots-1)
+		}
+	}
+}
+
+func TestGetRedisClusterShardNameByIndex(t *testing.T) {
+	expected := "redis-redis-sample-shard-1"
+	actual := GetRedisClusterShardNameByIndex(redisIn, 1)
+	if actual != expected {
+		t.Fatalf("actual = %s; expected = %s", actual, expected)
+	}
+}
+
+func TestGetScaleDownIndexes(t *testing.T) {
+	names := []string{
+		"redis-redis-sample-0",
+		"redis-redis-sample-3",
+		"redis-redis-sample-1",
+		"redis-redis-sample-2",
+		"redis-redis-sample-shard-0",
+		"redis-redis-sample",
+	}
+	expected := []int{3, 2}
+	actual := GetScaleDownIndexes(GetRedisRootName(redisIn), names, 2)
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Fatalf("actual = %v; expected = %v", actual, expected)
+	}
+}
+
+func TestCreateRedisStatefulSetObjByIndexRestore(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.Restore.From.URL = "http://minio:9000/backup/dump.rdb"
+
+	initContainers := func(index int) []string {
+		names := make([]string, 0)
+		for _, c := range CreateRedisStatefulSetObjByIndex(rf, nil, index).Spec.Template.Spec.InitContainers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	if actual := initContainers(0); len(actual) != 1 {
+		t.Fatalf("Status.Restore.Phase=\"\": init containers = %v; expected [%s]", actual, redisConfigCopy)
+	}
+
+	rf.Status.Restore.Phase = roav1.Restoring
+	if actual := initContainers(0); len(actual) != 2 || actual[0] != redisRestoreName || actual[1] != redisConfigCopy {
+		t.Fatalf("index 0: init containers = %v; expected [%s %s]", actual, redisRestoreName, redisConfigCopy)
+	}
+	if actual := initContainers(1); len(actual) != 1 {
+		t.Fatalf("index 1: init containers = %v; expected [%s]", actual, redisConfigCopy)
+	}
+
+	rf.Status.Restore.Phase = roav1.RestoreComplete
+	if actual := initContainers(0); len(actual) != 1 {
+		t.Fatalf("Status.Restore.Phase=Completed: init containers = %v; expected [%s]", actual, redisConfigCopy)
+	}
+}
+
+func TestCreateRedisStatefulSetObjByIndexVolumes(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "redis-data"}}
+	rf.Spec.Redis.StorageLog = roav1.RedisStorage{
+		KeepAfterDeletion:     true,
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "redis-log"}},
+	}
+	rf.Spec.Redis.ExtraVolumes = []corev1.Volume{{Name: "modules"}}
+	rf.Spec.Redis.ExtraVolumeMounts = []corev1.VolumeMount{{Name: "modules", MountPath: "/modules"}}
+	ownerRefs := []metav1.OwnerReference{{Name: rf.Name}}
+
+	ss := CreateRedisStatefulSetObjByIndex(rf, ownerRefs, 0)
+	templates := ss.Spec.VolumeClaimTemplates
+	if len(templates) != 2 || templates[0].Name != "redis-data" || templates[1].Name != "redis-log" {
+		t.Fatalf("VolumeClaimTemplates = %v; expected [redis-data redis-log]", templates)
+	}
+	if len(templates[0].OwnerReferences) != 1 || len(templates[1].OwnerReferences) != 0 {
+		t.Fatalf("only redis-data is expected to be owned by the Redis")
+	}
+	if rf.Spec.Redis.Storage.PersistentVolumeClaim.OwnerReferences != nil {
+		t.Fatalf("the storage of the spec is modified")
+	}
+
+	volumes := ss.Spec.Template.Spec.Volumes
+	if volumes[len(volumes)-1].Name != "modules" {
+		t.Fatalf("volumes = %v; expected modules appended", volumes)
+	}
+	mounts := ss.Spec.Template.Spec.Containers[0].VolumeMounts
+	if mounts[len(mounts)-1].MountPath != "/modules" {
+		t.Fatalf("volume mounts = %v; expected /modules appended", mounts)
+	}
+
+	if IsRedisPvcKeptAfterDeletion(rf, "redis-data-redis-redis-sample-0-0") {
+		t.Fatalf("redis-data is expected to be deleted")
+	}
+	if !IsRedisPvcKeptAfterDeletion(rf, "redis-log-redis-redis-sample-0-0") {
+		t.Fatalf("redis-log is expected to be kept")
+	}
+}
+
+func TestCreateStatefulSetObjByIndexPodTemplate(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	runtimeClassName := "gvisor"
+	rf.Spec.Redis.PodTemplate = roav1.PodTemplateSettings{
+		Labels:                 map[string]string{"team": "cache", appComponentLabelKey: "other"},
+		Env:                    []corev1.EnvVar{{Name: "TZ", Value: "UTC"}, {Name: "LANG", Value: "C"}},
+		InitContainerResources: &corev1.ResourceRequirements{},
+		Containers:             []corev1.Container{{Name: "log-shipper", Image: "fluent-bit"}},
+		ReadinessProbe:         &roav1.ProbeSettings{InitialDelaySeconds: 5},
+		ServiceAccountName:     "redis",
+		RuntimeClassName:       &runtimeClassName,
+	}
+
+	ss := CreateRedisStatefulSetObjByIndex(rf, nil, 0)
+	template := ss.Spec.Template
+	if template.Labels["team"] != "cache" || template.Labels[appComponentLabelKey] != redisRootName {
+		t.Fatalf("labels = %v; expected team=cache and the labels of the operator", template.Labels)
+	}
+	if len(template.Spec.Containers) != 2 || template.Spec.Containers[1].Name != "log-shipper" {
+		t.Fatalf("containers = %v; expected the log-shipper sidecar", template.Spec.Containers)
+	}
+	redis := template.Spec.Containers[0]
+	if len(redis.Env) != 2 || redis.Env[0].Value != "UTC" || redis.Env[1].Name != "LANG" {
+		t.Fatalf("env = %v; expected [TZ=UTC LANG=C]", redis.Env)
+	}
+	if redis.ReadinessProbe.InitialDelaySeconds != 5 || redis.ReadinessProbe.TimeoutSeconds != 5 || redis.LivenessProbe.InitialDelaySeconds != graceTime {
+		t.Fatalf("only the initialDelaySeconds of the readiness probe is expected to change")
+	}
+	if len(template.Spec.InitContainers[0].Resources.Limits) != 0 {
+		t.Fatalf("init container resources = %v; expected the override", template.Spec.InitContainers[0].Resources)
+	}
+	if template.Spec.ServiceAccountName != "redis" || *template.Spec.RuntimeClassName != runtimeClassName {
+		t.Fatalf("serviceAccountName and runtimeClassName are not set")
+	}
+	if RedisStatefulSetEqual(ss, CreateRedisStatefulSetObjByIndex(redisIn, nil, 0)) {
+		t.Fatalf("the pod template does not change the hash")
+	}
+
+	sentinel := CreateSentinelStatefulSetObjByIndex(rf, nil, 0)
+	if len(sentinel.Spec.Template.Spec.Containers) != 1 || sentinel.Spec.Template.Spec.ServiceAccountName != "" {
+		t.Fatalf("the redis pod template is applied to the sentinels")
+	}
+}
+
+func TestRedisStatefulSetSpecHash(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.Redis.Image = "redis:6.2"
+	existing := CreateRedisStatefulSetObjByIndex(rf, nil, 0)
+	if GetSpecHash(existing) == "" {
+		t.Fatalf("%s is not set", SpecHashAnnotation)
+	}
+
+	changes := map[string]func(rf *roav1.Redis){
+		"image": func(rf *roav1.Redis) {
+			rf.Spec.Redis.Image = "redis:7.0"
+		},
+		"tolerations": func(rf *roav1.Redis) {
+			rf.Spec.Redis.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+		},
+		"annotations": func(rf *roav1.Redis) {
+			rf.Spec.Redis.PodAnnotations = map[string]string{"a": "b"}
+		},
+	}
+	for name, change := range changes {
+		desired := rf.DeepCopy()
+		change(desired)
+		if RedisStatefulSetEqual(CreateRedisStatefulSetObjByIndex(desired, nil, 0), existing) {
+			t.Fatalf("%s: the hash does not change", name)
+		}
+	}
+
+	restoring := rf.DeepCopy()
+	restoring.Spec.Restore.From.URL = "http://minio:9000/backup/dump.rdb"
+	restoring.Status.Restore.Phase = roav1.Restoring
+	if !RedisStatefulSetEqual(CreateRedisStatefulSetObjByIndex(restoring, nil, 0), existing) {
+		t.Fatalf("the restore container changes the hash")
+	}
+
+	updated := CreateRedisStatefulSetObjByExistingObjByIndex(rf, nil, existing.DeepCopy(), 0)
+	if !RedisStatefulSetEqual(updated, existing) {
+		t.Fatalf("the hash of the updated StatefulSet differs")
+	}
+}
+
+func TestIsStatefulSetRolledOut(t *testing.T) {
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsv1.StatefulSetSpec{Replicas: Int32P(1)},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			ReadyReplicas:      1,
+			UpdatedReplicas:    1,
+			CurrentRevision:    "r2",
+			UpdateRevision:     "r2",
+		},
+	}
+	if !IsStatefulSetRolledOut(ss) {
+		t.Fatalf("expected rolled out")
+	}
+
+	rolling := ss.DeepCopy()
+	rolling.Status.CurrentRevision = "r1"
+	if IsStatefulSetRolledOut(rolling) {
+		t.Fatalf("CurrentRevision differs: expected not rolled out")
+	}
+
+	notObserved := ss.DeepCopy()
+	notObserved.Generation = 3
+	if IsStatefulSetRolledOut(notObserved) {
+		t.Fatalf("generation not observed: expected not rolled out")
+	}
+
+	notReady := ss.DeepCopy()
+	notReady.Status.ReadyReplicas = 0
+	if IsStatefulSetRolledOut(notReady) {
+		t.Fatalf("pod not ready: expected not rolled out")
+	}
+}
+
+func TestVolumeExpansion(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.Redis.Replicas = 2
+	rf.Spec.Redis.Storage.PersistentVolumeClaim = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis-data"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+		},
+	}
+	existing := CreateRedisStatefulSetObjByIndex(rf, nil, 0)
+	existing.ResourceVersion = "100"
+
+	rf.Spec.Redis.Storage.PersistentVolumeClaim.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}
+	desired := CreateRedisStatefulSetObjByIndex(rf, nil, 0)
+	expanded := GetExpandedVolumeClaimTemplates(existing, desired)
+	if request, ok := expanded["redis-data"]; !ok || request.String() != "2Gi" || len(expanded) != 1 {
+		t.Fatalf("expanded = %v; expected redis-data 2Gi", expanded)
+	}
+	if expanded := GetExpandedVolumeClaimTemplates(desired, existing); len(expanded) != 0 {
+		t.Fatalf("expanded = %v; expected none for a smaller storage", expanded)
+	}
+
+	recreated := CreateStatefulSetObjWithExpandedVolumes(existing, expanded)
+	if recreated.ResourceVersion != "" || GetSpecHash(recreated) != GetSpecHash(existing) {
+		t.Fatalf("recreated = %s with hash %s; expected no resourceVersion and the hash of existing", recreated.ResourceVersion, GetSpecHash(recreated))
+	}
+	if storage := recreated.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage(); storage.String() != "2Gi" {
+		t.Fatalf("recreated storage = %s; expected 2Gi", storage.String())
+	}
+	if storage := existing.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage(); storage.String() != "1Gi" {
+		t.Fatalf("existing storage = %s; expected it unchanged", storage.String())
+	}
+
+	// the recreated object is kept in the status while the orphan deletion is in progress
+	if _, ok, _ := GetRecreatingStatefulSet(rf, recreated.Name); ok {
+		t.Fatalf("%s is not being recreated", recreated.Name)
+	}
+	kept, err := NewRecreatingStatefulSet(recreated)
+	if err != nil {
+		t.Fatalf("NewRecreatingStatefulSet error: %s", err)
+	}
+	rf.Status.RecreatingStatefulSets = []roav1.RecreatingStatefulSet{kept}
+	status, _ := json.Marshal(rf.Status)
+	rf.Status = roav1.RedisStatus{}
+	if err := json.Unmarshal(status, &rf.Status); err != nil {
+		t.Fatalf("unmarshal status error: %s", err)
+	}
+	keptStatefulSet, ok, err := GetRecreatingStatefulSet(rf, recreated.Name)
+	if err != nil || !ok {
+		t.Fatalf("GetRecreatingStatefulSet = %v, %v; expected the kept object", ok, err)
+	}
+	if GetSpecHash(keptStatefulSet) != GetSpecHash(existing) || keptStatefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String() != "2Gi" {
+		t.Fatalf("kept = %s with hash %s; expected the pod template of existing and 2Gi", keptStatefulSet.Name, GetSpecHash(keptStatefulSet))
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "redis-data-redis-redis-sample-0-0"}}
+	if template, ok := GetStatefulSetPvcTemplate(existing, pvc); !ok || template != "redis-data" {
+		t.Fatalf("template = %s, %v; expected redis-data", template, ok)
+	}
+	// redis-redis-sample-1 is another StatefulSet
+	if _, ok := GetStatefulSetPvcTemplate(existing, &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "redis-data-redis-redis-sample-1-0"}}); ok {
+		t.Fatalf("redis-data-redis-redis-sample-1-0 is not a PersistentVolumeClaim of %s", existing.Name)
+	}
+
+	pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}
+	pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+	if state, ok := GetVolumeExpansionState(pvc); !ok || state.Phase != roav1.VolumeResizing || state.Capacity != "1Gi" {
+		t.Fatalf("state = %v, %v; expected Resizing from 1Gi", state, ok)
+	}
+	pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+		{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+	}
+	if state, ok := GetVolumeExpansionState(pvc); !ok || state.Phase != roav1.VolumeFileSystemResizePending {
+		t.Fatalf("state = %v, %v; expected FileSystemResizePending", state, ok)
+	}
+	pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}
+	if state, ok := GetVolumeExpansionState(pvc); ok {
+		t.Fatalf("state = %v; expected expanded", state)
+	}
+}
+
+func TestMergeConditions(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Status.State.Ready = true
+	rf.Status.Conditions = []metav1.Condition{
+		{Type: roav1.ConditionSentinelsConsistent, Status: metav1.ConditionTrue, Reason: ReasonConsistent},
+	}
+	observed := []metav1.Condition{
+		{Type: roav1.ConditionMasterElected, Status: metav1.ConditionTrue, Reason: ReasonMasterElected},
+	}
+
+	conditions := MergeConditions(rf, observed, 2, "", nil)
+	if !meta.IsStatusConditionTrue(conditions, roav1.ConditionAvailable) {
+		t.Fatalf("Available = %v; expected True", meta.FindStatusCondition(conditions, roav1.ConditionAvailable))
+	}
+	if !meta.IsStatusConditionFalse(conditions, roav1.ConditionDegraded) {
+		t.Fatalf("Degraded = %v; expected False", meta.FindStatusCondition(conditions, roav1.ConditionDegraded))
+	}
+	if !meta.IsStatusConditionTrue(conditions, roav1.ConditionSentinelsConsistent) {
+		t.Fatalf("SentinelsConsistent = %v; expected the previous True", meta.FindStatusCondition(conditions, roav1.ConditionSentinelsConsistent))
+	}
+	for _, condition := range conditions {
+		if condition.ObservedGeneration != 2 {
+			t.Fatalf("%s.ObservedGeneration = %d; expected 2", condition.Type, condition.ObservedGeneration)
+		}
+	}
+
+	rf.Status.Conditions = conditions
+	conditions = MergeConditions(rf, nil, 2, ReasonHealing, []error{errors.New("Master Number = 0"), errors.New("No master found, wait until failover")})
+	degraded := meta.FindStatusCondition(conditions, roav1.ConditionDegraded)
+	if degraded.Status != metav1.ConditionTrue || degraded.Reason != ReasonHealing || degraded.Message != "Master Number = 0; No master found, wait until failover" {
+		t.Fatalf("Degraded = %v; expected True with the joined errors", degraded)
+	}
+
+	rf.Status.State.Ready = false
+	rf.Spec.Mode = roav1.ClusterMode
+	conditions = MergeConditions(rf, nil, 3, "", nil)
+	available := meta.FindStatusCondition(conditions, roav1.ConditionAvailable)
+	if available.Status != metav1.ConditionFalse || available.Reason != ReasonPodsNotReady {
+		t.Fatalf("Available = %v; expected False with reason %s", available, ReasonPodsNotReady)
+	}
+	if meta.FindStatusCondition(conditions, roav1.ConditionSentinelsConsistent) != nil {
+		t.Fatalf("SentinelsConsistent is set in cluster mode")
+	}
+}
+
+func TestGetACLUserRules(t *testing.T) {
+	user := roav1.ACLUser{
+		Name:     "app",
+		Commands: []string{"+@read", "-keys"},
+		Keys:     []string{"cache:*"},
+		Channels: []string{"notifications:*"},
+	}
+	rules := GetACLUserRules(user, "secret")
+	expected := "reset on #2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b ~cache:* &notifications:* +@read -keys"
+	if actual := strings.Join(rules.Rules, " "); rules.Name != "app" || actual != expected {
+		t.Fatalf("GetACLUserRules() = %s %s; expected app %s", rules.Name, actual, expected)
+	}
+	if GetACLUserMd5(rules) == GetACLUserMd5(GetACLUserRules(user, "other")) {
+		t.Fatalf("the md5 does not change with the password")
+	}
+
+	if actual := strings.Join(GetOperatorACLUserRules("").Rules, " "); actual != "reset on nopass ~* &* +@all" {
+		t.Fatalf("GetOperatorACLUserRules(\"\") = %s", actual)
+	}
+
+	rf := redisIn.DeepCopy()
+	if actual := GetMasterUser(rf); actual != "default" {
+		t.Fatalf("GetMasterUser() = %s; expected default", actual)
+	}
+	rf.Spec.Auth.Users = []roav1.ACLUser{user}
+	if actual := GetMasterUser(rf); actual != roav1.OperatorACLUser {
+		t.Fatalf("GetMasterUser() = %s; expected %s", actual, roav1.OperatorACLUser)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	rf := redisIn.DeepCopy()
+	rf.Spec.TLS = roav1.TLSSettings{Enabled: true, SecretName: "redis-tls"}
+
+	config := CreateRedisSlaveConfigMapByIndex(rf, nil, "", 1).Data[redisConfigFileName]
+	for _, line := range []string{"port 0", "tls-port 6379", "tls-cert-file /tls/tls.crt", "tls-replication yes"} {
+		if !strings.Contains(config, line+"\n") {
+			t.Fatalf("redis.conf does not have %q:\n%s", line, config)
+		}
+	}
+	if !strings.Contains(getRedisClusterConfigContent(rf, ""), "tls-cluster yes") {
+		t.Fatalf("the cluster redis.conf does not have tls-cluster yes")
+	}
+
+	ss := CreateRedisStatefulSetObjByIndex(rf, nil, 0)
+	container := ss.Spec.Template.Spec.Containers[0]
+	if container.VolumeMounts[len(container.VolumeMounts)-1].MountPath != tlsMountPath {
+		t.Fatalf("the certificates are not mounted in %s", tlsMountPath)
+	}
+	if command := container.LivenessProbe.Exec.Command[2]; !strings.HasPrefix(command, "redis-cli --tls ") {
+		t.Fatalf("liveness probe = %s; expected redis-cli with --tls", command)
+	}
+
+	sentinel := CreateSentinelStatefulSetObjByIndex(rf, nil, 0)
+	rf.Status.TLS.RotationTime = &metav1.Time{Time: metav1.Now().Add(-time.Minute)}
+	rotated := CreateSentinelStatefulSetObjByIndex(rf, nil, 0)
+	if SentinelStatefulSetEqual(sentinel, rotated) || !RedisStatefulSetEqual(ss, CreateRedisStatefulSetObjByIndex(rf, nil, 0)) {
+		t.Fatalf("a rotation should roll the sentinels only")
+	}
+
+	rf.Spec.Redis.Replicas = 2
+	if addr := GetRedisAddr(rf); !strings.HasPrefix(addr, "rediss://") {
+		t.Fatalf("GetRedisAddr() = %s; expected rediss://", addr)
+	}
+}
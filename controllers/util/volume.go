@@ -0,0 +1,118 @@
+package util
+
+import (
+	"encoding/json"
+	roav1 "github.com/zhizuqiu/redis-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"strconv"
+	"strings"
+)
+
+// GetExpandedVolumeClaimTemplates returns the storage of the VolumeClaimTemplates desired requests more than
+// existing, by the name of the template
+func GetExpandedVolumeClaimTemplates(existing, desired *appsv1.StatefulSet) map[string]resource.Quantity {
+	expanded := make(map[string]resource.Quantity)
+	for _, desiredTemplate := range desired.Spec.VolumeClaimTemplates {
+		for _, existingTemplate := range existing.Spec.VolumeClaimTemplates {
+			if existingTemplate.Name != desiredTemplate.Name {
+				continue
+			}
+			request := desiredTemplate.Spec.Resources.Requests.Storage()
+			if request.Cmp(*existingTemplate.Spec.Resources.Requests.Storage()) > 0 {
+				expanded[desiredTemplate.Name] = *request
+			}
+		}
+	}
+	return expanded
+}
+
+// CreateStatefulSetObjWithExpandedVolumes returns a copy of existing without the fields set by the server and with
+// the expanded storage, the VolumeClaimTemplates are immutable so the StatefulSet is recreated. Its pod template is
+// kept so that the pods are adopted without a restart
+func CreateStatefulSetObjWithExpandedVolumes(existing *appsv1.StatefulSet, expanded map[string]resource.Quantity) *appsv1.StatefulSet {
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            existing.Name,
+			Namespace:       existing.Namespace,
+			Labels:          existing.Labels,
+			Annotations:     existing.Annotations,
+			OwnerReferences: existing.OwnerReferences,
+		},
+		Spec: *existing.Spec.DeepCopy(),
+	}
+	for i, template := range ss.Spec.VolumeClaimTemplates {
+		if request, ok := expanded[template.Name]; ok {
+			if template.Spec.Resources.Requests == nil {
+				ss.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			ss.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests[corev1.ResourceStorage] = request
+		}
+	}
+	return ss
+}
+
+// GetStatefulSetPvcTemplate returns the name of the VolumeClaimTemplate of the StatefulSet the PersistentVolumeClaim
+// was created from, <template>-<statefulset>-<ordinal>
+func GetStatefulSetPvcTemplate(ss *appsv1.StatefulSet, pvc *corev1.PersistentVolumeClaim) (string, bool) {
+	for _, template := range ss.Spec.VolumeClaimTemplates {
+		prefix := template.Name + "-" + ss.Name + "-"
+		if !strings.HasPrefix(pvc.Name, prefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(pvc.Name, prefix)); err == nil {
+			return template.Name, true
+		}
+	}
+	return "", false
+}
+
+// GetVolumeExpansionState returns the progress of a PersistentVolumeClaim whose capacity is below its request,
+// false once it is expanded
+func GetVolumeExpansionState(pvc *corev1.PersistentVolumeClaim) (roav1.VolumeExpansionState, bool) {
+	request := pvc.Spec.Resources.Requests.Storage()
+	capacity := pvc.Status.Capacity.Storage()
+	if capacity.IsZero() || capacity.Cmp(*request) >= 0 {
+		return roav1.VolumeExpansionState{}, false
+	}
+	state := roav1.VolumeExpansionState{
+		Name:      pvc.Name,
+		Requested: request.String(),
+		Capacity:  capacity.String(),
+		Phase:     roav1.VolumeResizing,
+	}
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending && condition.Status == corev1.ConditionTrue {
+			state.Phase = roav1.VolumeFileSystemResizePending
+		}
+	}
+	return state, true
+}
+
+// NewRecreatingStatefulSet returns the status of a StatefulSet deleted to be created again as ss
+func NewRecreatingStatefulSet(ss *appsv1.StatefulSet) (roav1.RecreatingStatefulSet, error) {
+	raw, err := json.Marshal(ss)
+	if err != nil {
+		return roav1.RecreatingStatefulSet{}, err
+	}
+	return roav1.RecreatingStatefulSet{Name: ss.Name, StatefulSet: runtime.RawExtension{Raw: raw}}, nil
+}
+
+// GetRecreatingStatefulSet returns the object a StatefulSet deleted to expand its volumes is created again with,
+// false if it is not being recreated
+func GetRecreatingStatefulSet(rf *roav1.Redis, name string) (*appsv1.StatefulSet, bool, error) {
+	for _, recreating := range rf.Status.RecreatingStatefulSets {
+		if recreating.Name != name {
+			continue
+		}
+		ss := &appsv1.StatefulSet{}
+		if err := json.Unmarshal(recreating.StatefulSet.Raw, ss); err != nil {
+			return nil, false, err
+		}
+		return ss, true, nil
+	}
+	return nil, false, nil
+}
@@ -4,6 +4,8 @@ go 1.16
 
 require (
 	github.com/go-logr/logr v0.4.0
+	github.com/prometheus/client_golang v1.11.0
+	github.com/robfig/cron/v3 v3.0.1
 	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/api v0.21.3
 	k8s.io/apimachinery v0.21.3
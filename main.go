@@ -21,16 +21,20 @@ import (
 	"fmt"
 	_ "net/http/pprof"
 	"os"
+	"strings"
 
 	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
+	"github.com/zhizuqiu/redis-operator/controllers/service/backup"
 	"github.com/zhizuqiu/redis-operator/controllers/service/check"
 	"github.com/zhizuqiu/redis-operator/controllers/service/ensure"
 	"github.com/zhizuqiu/redis-operator/controllers/service/exec"
 	"github.com/zhizuqiu/redis-operator/controllers/service/k8s"
 	"github.com/zhizuqiu/redis-operator/controllers/service/redis_client"
+	"github.com/zhizuqiu/redis-operator/controllers/util/password"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -44,6 +48,8 @@ import (
 	// +kubebuilder:scaffold:imports
 )
 
+const operatorName = "redis-operator"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -68,12 +74,42 @@ func main() {
 
 	var metricsAddr string
 	var enableLeaderElection bool
+	var redisClientMode string
+	var passwordKeySecret string
+	var kmsPluginEndpoint string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":38111", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&redisClientMode, "redis-client", "exec",
+		"How the operator talks to redis and sentinel pods. "+
+			"exec runs redis-cli in the pod, native connects to the pod ip with the RESP protocol.")
+	flag.StringVar(&passwordKeySecret, "password-key-secret", "",
+		"The namespace/name of the Secret with the keys of the password encode types, e.g. the sm4 or aes-gcm data, "+
+			"for the Redis without spec.auth.password.keySecret.")
+	flag.StringVar(&kmsPluginEndpoint, "kms-plugin-endpoint", "",
+		"The http url, or unix:///path of the socket, of the plugin decrypting the passwords of the kms encode type.")
+	flag.DurationVar(&controllers.RoleRequeueAfter, "role-requeue-after", controllers.RoleRequeueAfter,
+		"How often a Redis in sentinel mode is reconciled to relabel the roles after a failover the sentinels do on their own, "+
+			"up to this long the <name>-master Service selects the old master. 0 disables it.")
 	flag.Parse()
 
+	if redisClientMode != "exec" && redisClientMode != "native" {
+		setupLog.Error(fmt.Errorf("unknown redis client %q", redisClientMode), "invalid flag", "flag", "redis-client")
+		os.Exit(1)
+	}
+	if passwordKeySecret != "" {
+		parts := strings.Split(passwordKeySecret, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			setupLog.Error(fmt.Errorf("%q is not namespace/name", passwordKeySecret), "invalid flag", "flag", "password-key-secret")
+			os.Exit(1)
+		}
+		password.DefaultKeySecret = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+	if kmsPluginEndpoint != "" {
+		password.Register(componentredisv1alpha1.KMS, password.NewKMSDecoder(kmsPluginEndpoint))
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -104,8 +140,15 @@ func main() {
 		os.Exit(1)
 	}
 	k8sServices := k8s.New(mgr.GetClient(), log, sc)
+	// the backups copy the files out of the pods by exec whatever the redis client is
 	iExec := exec.NewRemoteExec(restClient, mgr.GetConfig(), log)
-	redisApi := redis_client.NewRedisExecApi(log, iExec)
+	var redisApi redis_client.RedisApi
+	if redisClientMode == "native" {
+		redisApi = redis_client.NewRedisNativeApi(log, redis_client.NewPodAddrResolver(k8sServices), redis_client.NewSpecPasswordLookup(k8sServices), redis_client.NewSpecTLSConfigLookup(k8sServices))
+	} else {
+		redisApi = redis_client.NewRedisExecApi(log, iExec)
+	}
+	redisApi = redis_client.NewObservedRedisApi(redisApi, controllers.ObserveRedisCommand)
 	redisClient := redis_client.NewRedisExecClienter(log, redisApi)
 	handler := controllers.NewRedisHandler(
 		ensure.NewRedisEnsurer(k8sServices, log),
@@ -114,6 +157,7 @@ func main() {
 		check.NewRedisDeleteChecker(k8sServices, log),
 		check.NewRedisHealer(k8sServices, redisClient, log),
 		k8sServices,
+		mgr.GetEventRecorderFor(operatorName),
 		log,
 	)
 
@@ -126,12 +170,25 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Redis")
 		os.Exit(1)
 	}
-	/*
+	backupLog := ctrl.Log.WithName("controllers").WithName("RedisBackup")
+	if err = (&controllers.RedisBackupReconciler{
+		Client:      mgr.GetClient(),
+		Log:         backupLog,
+		Scheme:      sc,
+		Backuper:    backup.NewRedisBackuper(k8sServices, redisClient, iExec, backupLog),
+		K8sServices: k8sServices,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RedisBackup")
+		os.Exit(1)
+	}
+	// the webhook server needs the certificate in /tmp/k8s-webhook-server/serving-certs, set
+	// ENABLE_WEBHOOKS=false to run the operator out of the cluster, e.g. make run
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
 		if err = (&componentredisv1alpha1.Redis{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Redis")
 			os.Exit(1)
 		}
-	*/
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")